@@ -21,7 +21,9 @@ import (
 	"t73f.de/r/zero/semver"
 	"t73f.de/r/zsc/domain/meta"
 
+	"zettelstore.de/z/internal/audit"
 	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/oidc"
 	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/config"
 	"zettelstore.de/z/internal/kernel"
@@ -54,7 +56,10 @@ func runFunc(*flag.FlagSet) (int, error) {
 	return exitCode, err
 }
 
-func setupRouting(webSrv server.Server, boxManager box.Manager, authManager auth.Manager, rtConfig config.Config) {
+func setupRouting(
+	webSrv server.Server, boxManager box.Manager, authManager auth.Manager, rtConfig config.Config, auditLog *audit.Log,
+	oidcCfg *oidcConfig,
+) {
 	protectedBoxManager, authPolicy := authManager.BoxWithPolicy(boxManager, rtConfig)
 	kern := kernel.Main
 	webLogger := kern.GetLogger(kernel.WebService)
@@ -63,7 +68,15 @@ func setupRouting(webSrv server.Server, boxManager box.Manager, authManager auth
 	authLogger := kern.GetLogger(kernel.AuthService)
 	ucLogger := kern.GetLogger(kernel.CoreService)
 	ucGetUser := usecase.NewGetUser(authManager, boxManager)
-	ucAuthenticate := usecase.NewAuthenticate(authLogger, authManager, &ucGetUser)
+	ucAuthenticate := usecase.NewAuthenticate(authLogger, authManager, authManager, authManager, rtConfig, boxManager, &ucGetUser)
+	var ucAuthenticateOIDC *usecase.AuthenticateOIDC
+	if oidcCfg != nil && authManager.WithAuth() {
+		cfg := oidcCfg.Config
+		cfg.RedirectURL = webSrv.NewURLBuilderAbs('i').String()
+		uc := usecase.NewAuthenticateOIDC(
+			authLogger, authManager, authManager, rtConfig, oidc.NewClient(cfg, nil), boxManager, &ucGetUser, oidcCfg.opts)
+		ucAuthenticateOIDC = &uc
+	}
 	ucIsAuth := usecase.NewIsAuthenticated(ucLogger, &getUser, authManager)
 	ucCreateZettel := usecase.NewCreateZettel(ucLogger, rtConfig, protectedBoxManager)
 	ucGetAllZettel := usecase.NewGetAllZettel(protectedBoxManager)
@@ -71,7 +84,7 @@ func setupRouting(webSrv server.Server, boxManager box.Manager, authManager auth
 	ucParseZettel := usecase.NewParseZettel(rtConfig, ucGetZettel)
 	ucGetReferences := usecase.NewGetReferences()
 	ucQuery := usecase.NewQuery(protectedBoxManager)
-	ucEvaluate := usecase.NewEvaluate(rtConfig, &ucGetZettel, &ucQuery)
+	ucEvaluate := usecase.NewEvaluate(rtConfig, boxManager, authManager.GroupSubject(), &ucGetZettel, &ucQuery)
 	ucQuery.SetEvaluate(&ucEvaluate)
 	ucTagZettel := usecase.NewTagZettel(protectedBoxManager, &ucQuery)
 	ucRoleZettel := usecase.NewRoleZettel(protectedBoxManager, &ucQuery)
@@ -79,7 +92,25 @@ func setupRouting(webSrv server.Server, boxManager box.Manager, authManager auth
 	ucListRoles := usecase.NewListRoles(protectedBoxManager)
 	ucDelete := usecase.NewDeleteZettel(ucLogger, protectedBoxManager)
 	ucUpdate := usecase.NewUpdateZettel(ucLogger, protectedBoxManager)
+	ucBatch := usecase.NewBatch(ucLogger, authPolicy, boxManager)
+	ucRename := usecase.NewRenameZettel(ucLogger, authPolicy, boxManager)
+	ucMerge := usecase.NewMergeZettel(ucLogger, authPolicy, boxManager)
+	ucBulkMeta := usecase.NewBulkMeta(ucLogger, authPolicy, boxManager)
 	ucRefresh := usecase.NewRefresh(ucLogger, protectedBoxManager)
+	ucCheckIndex := usecase.NewCheckIndex(ucLogger, protectedBoxManager)
+	ucCreateToken := usecase.NewCreatePersonalToken(ucLogger, boxManager)
+	ucRevokeToken := usecase.NewRevokePersonalToken(ucLogger, boxManager)
+	ucEnableTOTP := usecase.NewEnableTOTP(ucLogger, boxManager)
+	ucDisableTOTP := usecase.NewDisableTOTP(ucLogger, boxManager, authManager, rtConfig)
+	ucListSessions := usecase.NewListSessions(authManager, authManager)
+	ucRevokeSession := usecase.NewRevokeSession(ucLogger, boxManager, authManager, authManager)
+	ucLogout := usecase.NewLogout(ucLogger, authManager)
+	ucRevokeAllSessions := usecase.NewRevokeAllSessions(ucLogger, boxManager, authManager, authManager)
+	ucAuditLog := usecase.NewGetAuditLog(auditLog, authManager)
+	ucCreateShareLink := usecase.NewCreateShareLink(ucLogger, authManager, authPolicy, protectedBoxManager)
+	ucGetSharedZettel := usecase.NewGetSharedZettel(authManager, authPolicy, boxManager, &ucEvaluate)
+	ucWatch := usecase.NewWatchChanges(ucLogger, boxManager, protectedBoxManager)
+	ucGetUserByToken := usecase.NewGetUserByPersonalToken(boxManager)
 	ucReIndex := usecase.NewReIndex(ucLogger, protectedBoxManager)
 	ucVersion := usecase.NewVersion(kernel.Main.GetConfig(kernel.CoreService, kernel.CoreVersion).(semver.SemVer))
 
@@ -109,32 +140,59 @@ func setupRouting(webSrv server.Server, boxManager box.Manager, authManager auth
 		webSrv.AddZettelRoute(!isAPI, 'd', server.MethodGet, wui.MakeGetDeleteZettelHandler(ucGetZettel, ucGetAllZettel))
 		webSrv.AddZettelRoute(!isAPI, 'd', server.MethodPost, wui.MakePostDeleteZettelHandler(&ucDelete))
 		webSrv.AddZettelRoute(!isAPI, 'e', server.MethodGet, wui.MakeEditGetZettelHandler(ucGetZettel, ucListRoles, ucListSyntax))
-		webSrv.AddZettelRoute(!isAPI, 'e', server.MethodPost, wui.MakeEditSetZettelHandler(&ucUpdate))
+		webSrv.AddZettelRoute(!isAPI, 'e', server.MethodPost, wui.MakeEditSetZettelHandler(&ucUpdate, ucListRoles, ucListSyntax))
+		webSrv.AddZettelRoute(!isAPI, 'j', server.MethodGet, wui.MakeGetMergeZettelHandler(ucGetZettel))
+		webSrv.AddZettelRoute(!isAPI, 'j', server.MethodPost, wui.MakePostMergeZettelHandler(&ucMerge))
+		webSrv.AddListRoute(!isAPI, 'u', server.MethodGet, wui.MakeGetBulkMetaHandler(&ucQuery))
+		webSrv.AddListRoute(!isAPI, 'u', server.MethodPost, wui.MakePostBulkMetaHandler(&ucQuery, &ucBulkMeta))
+		if authManager.WithAuth() {
+			webSrv.AddListRoute(!isAPI, 't', server.MethodGet, wui.MakeGetPersonalTokensHandler(ucGetZettel))
+			webSrv.AddListRoute(!isAPI, 't', server.MethodPost, wui.MakePostPersonalTokensHandler(
+				ucGetZettel, &ucCreateToken, &ucRevokeToken))
+			webSrv.AddListRoute(!isAPI, 'o', server.MethodGet, wui.MakeGetTOTPHandler())
+			webSrv.AddListRoute(!isAPI, 'o', server.MethodPost, wui.MakePostTOTPHandler(&ucEnableTOTP, &ucDisableTOTP))
+		}
 	}
 	webSrv.AddListRoute(!isAPI, 'g', server.MethodGet, wui.MakeGetGoActionHandler(&ucRefresh))
 	webSrv.AddListRoute(!isAPI, 'h', server.MethodGet, wui.MakeListHTMLMetaHandler(&ucQuery, &ucTagZettel, &ucRoleZettel, &ucReIndex))
 	webSrv.AddZettelRoute(!isAPI, 'h', server.MethodGet, wui.MakeGetHTMLZettelHandler(&ucEvaluate, ucGetZettel))
-	webSrv.AddListRoute(!isAPI, 'i', server.MethodGet, wui.MakeGetLoginOutHandler())
+	webSrv.AddListRoute(!isAPI, 'i', server.MethodGet, wui.MakeGetLoginOutHandler(&ucLogout, ucAuthenticateOIDC))
 	webSrv.AddListRoute(!isAPI, 'i', server.MethodPost, wui.MakePostLoginHandler(&ucAuthenticate))
 	webSrv.AddZettelRoute(!isAPI, 'i', server.MethodGet, wui.MakeGetInfoHandler(
 		ucParseZettel, ucGetReferences, &ucEvaluate, ucGetZettel, ucGetAllZettel, &ucQuery))
+	if authManager.WithAuth() {
+		webSrv.AddListRoute(!isAPI, 's', server.MethodGet, wui.MakeGetSessionsHandler(ucListSessions))
+		webSrv.AddListRoute(!isAPI, 's', server.MethodPost, wui.MakePostSessionsHandler(&ucRevokeSession, &ucRevokeAllSessions))
+		webSrv.AddZettelRoute(!isAPI, 'k', server.MethodGet, wui.MakeGetShareHandler(ucGetZettel))
+		webSrv.AddZettelRoute(!isAPI, 'k', server.MethodPost, wui.MakePostShareHandler(ucGetZettel, &ucCreateShareLink))
+		webSrv.AddZettelRoute(!isAPI, 'v', server.MethodGet, wui.MakeGetSharedZettelHandler(&ucGetSharedZettel))
+	}
 
 	// API
 	webSrv.AddListRoute(isAPI, 'a', server.MethodPost, a.MakePostLoginHandler(&ucAuthenticate))
 	webSrv.AddListRoute(isAPI, 'a', server.MethodPut, a.MakeRenewAuthHandler())
+	webSrv.AddListRoute(isAPI, 'l', server.MethodGet, a.MakeGetAuditLogHandler(ucAuditLog))
+	webSrv.AddZettelRoute(isAPI, 'l', server.MethodGet, a.MakeGetAuditLogHandler(ucAuditLog))
 	webSrv.AddZettelRoute(isAPI, 'r', server.MethodGet, a.MakeGetReferencesHandler(ucParseZettel, ucGetReferences))
+	webSrv.AddListRoute(isAPI, 'w', server.MethodGet, a.MakeWatchHandler(&ucWatch, ucGetUserByToken))
 	webSrv.AddListRoute(isAPI, 'x', server.MethodGet, a.MakeGetDataHandler(ucVersion))
-	webSrv.AddListRoute(isAPI, 'x', server.MethodPost, a.MakePostCommandHandler(&ucIsAuth, &ucRefresh))
+	webSrv.AddListRoute(isAPI, 'x', server.MethodPost, a.MakePostCommandHandler(&ucIsAuth, &ucRefresh, &ucCheckIndex))
 	webSrv.AddListRoute(isAPI, 'z', server.MethodGet, a.MakeQueryHandler(&ucQuery, &ucTagZettel, &ucRoleZettel, &ucReIndex))
 	webSrv.AddZettelRoute(isAPI, 'z', server.MethodGet, a.MakeGetZettelHandler(ucGetZettel, ucParseZettel, ucEvaluate))
 	if !authManager.IsReadonly() {
+		webSrv.AddListRoute(isAPI, 'b', server.MethodPost, a.MakePostBatchHandler(&ucBatch))
+		webSrv.AddZettelRoute(isAPI, 'm', server.MethodPost, a.MakePostMergeZettelHandler(&ucMerge))
 		webSrv.AddListRoute(isAPI, 'z', server.MethodPost, a.MakePostCreateZettelHandler(&ucCreateZettel))
+		webSrv.AddListRoute(isAPI, 'z', server.MethodPatch, a.MakePatchBulkMetaHandler(&ucQuery, &ucBulkMeta))
 		webSrv.AddZettelRoute(isAPI, 'z', server.MethodPut, a.MakeUpdateZettelHandler(&ucUpdate))
 		webSrv.AddZettelRoute(isAPI, 'z', server.MethodDelete, a.MakeDeleteZettelHandler(&ucDelete))
+		webSrv.AddZettelRoute(isAPI, 'z', server.MethodMove, a.MakeRenameZettelHandler(&ucRename))
 	}
 
 	if authManager.WithAuth() {
 		webSrv.SetUserRetriever(usecase.NewGetUserByZid(boxManager))
+		webSrv.SetPersonalTokenRetriever(ucGetUserByToken)
+		webSrv.SetProxyUserRetriever(ucGetUser)
 	}
 }
 
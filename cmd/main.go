@@ -33,15 +33,19 @@ import (
 	"t73f.de/r/zsc/webapi"
 	"t73f.de/r/zsx/input"
 
+	"zettelstore.de/z/internal/audit"
 	"zettelstore.de/z/internal/auth"
 	"zettelstore.de/z/internal/auth/impl"
+	"zettelstore.de/z/internal/auth/oidc"
 	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/box/compbox"
 	"zettelstore.de/z/internal/box/manager"
 	"zettelstore.de/z/internal/config"
 	"zettelstore.de/z/internal/kernel"
 	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/usecase"
 	"zettelstore.de/z/internal/web/server"
+	"zettelstore.de/z/internal/webhook"
 )
 
 const strRunSimple = "run-simple"
@@ -163,19 +167,36 @@ func deleteConfiguredBoxes(cfg *meta.Meta) {
 const (
 	keyAdminPort         = "admin-port"
 	keyAssetDir          = "asset-dir"
+	keyAuditLog          = "audit-log"
 	keyBaseURL           = "base-url"
 	keyBoxOneURI         = kernel.BoxURIs + "1"
 	keyDebug             = "debug-mode"
 	keyDefaultDirBoxType = "default-dir-box-type"
+	keyIndexWorkers      = "index-workers"
 	keyInsecureCookie    = "insecure-cookie"
 	keyInsecureHTML      = "insecure-html"
 	keyListenAddr        = "listen-addr"
+	keyLockoutDuration   = "lockout-duration"
+	keyLockoutThreshold  = "lockout-threshold"
 	keyLogLevel          = "log-level"
 	keyLoopbackIdent     = "loopback-ident"
 	keyLoopbackZid       = "loopback-zid"
 	keyMaxRequestSize    = "max-request-size"
+	keyOIDCAllowOwner    = "oidc-allow-owner"
+	keyOIDCClientID      = "oidc-client-id"
+	keyOIDCClientSecret  = "oidc-client-secret"
+	keyOIDCCreateUsers   = "oidc-create-users"
+	keyOIDCIdentClaim    = "oidc-ident-claim"
+	keyOIDCIssuer        = "oidc-issuer"
+	keyOIDCNameClaim     = "oidc-name-claim"
+	keyOIDCRoleClaim     = "oidc-role-claim"
+	keyOIDCRoles         = "oidc-roles"
+	keyOIDCScopes        = "oidc-scopes"
+	keyOIDCSkipTOTP      = "oidc-skip-totp"
 	keyOwner             = "owner"
 	keyPersistentCookie  = "persistent-cookie"
+	keyProxyAddresses    = "proxy-addresses"
+	keyProxyHeader       = "proxy-header"
 	keyReadOnly          = "read-only-mode"
 	keyRefreshMode       = "refresh-mode"
 	keyRuntimeProfiling  = "runtime-profiling"
@@ -184,6 +205,7 @@ const (
 	keyTokenLifetimeAPI  = "token-lifetime-api"
 	keyURLPrefix         = "url-prefix"
 	keyVerbose           = "verbose-mode"
+	keyWebhookState      = "webhook-state"
 )
 
 func setServiceConfig(cfg *meta.Meta, command Command) bool {
@@ -204,10 +226,19 @@ func setServiceConfig(cfg *meta.Meta, command Command) bool {
 	err = setConfigValue(err, kernel.AuthService, kernel.AuthOwner, cfg.GetDefault(keyOwner, ""))
 	err = setConfigValue(err, kernel.AuthService, kernel.AuthReadonly, cfg.GetBool(keyReadOnly))
 	err = setConfigValue(err, kernel.AuthService, kernel.AuthRefreshMode, cfg.GetBool(keyRefreshMode))
+	if val, found := cfg.Get(keyLockoutThreshold); found {
+		err = setConfigValue(err, kernel.AuthService, kernel.AuthLockoutThreshold, val)
+	}
+	if val, found := cfg.Get(keyLockoutDuration); found {
+		err = setConfigValue(err, kernel.AuthService, kernel.AuthLockoutDuration, val)
+	}
 
 	err = setConfigValue(
 		err, kernel.BoxService, kernel.BoxDefaultDirType,
 		cfg.GetDefault(keyDefaultDirBoxType, kernel.BoxDirTypeNotify))
+	if val, found := cfg.Get(keyIndexWorkers); found {
+		err = setConfigValue(err, kernel.BoxService, kernel.BoxIndexWorkers, val)
+	}
 	err = setConfigValue(err, kernel.BoxService, kernel.BoxURIs+"1", "dir:./zettel")
 	for i := 1; ; i++ {
 		key := kernel.BoxURIs + strconv.Itoa(i)
@@ -228,6 +259,8 @@ func setServiceConfig(cfg *meta.Meta, command Command) bool {
 		err, kernel.WebService, kernel.WebListenAddress, cfg.GetDefault(keyListenAddr, "127.0.0.1:23123"))
 	err = setConfigValue(err, kernel.WebService, kernel.WebLoopbackIdent, cfg.GetDefault(keyLoopbackIdent, ""))
 	err = setConfigValue(err, kernel.WebService, kernel.WebLoopbackZid, cfg.GetDefault(keyLoopbackZid, ""))
+	err = setConfigValue(err, kernel.WebService, kernel.WebProxyHeader, cfg.GetDefault(keyProxyHeader, ""))
+	err = setConfigValue(err, kernel.WebService, kernel.WebProxyAddresses, cfg.GetDefault(keyProxyAddresses, ""))
 	if val, found := cfg.Get(keyBaseURL); found {
 		err = setConfigValue(err, kernel.WebService, kernel.WebBaseURL, val)
 	}
@@ -277,32 +310,64 @@ func executeCommand(name string, args ...string) int {
 		return 2
 	}
 
+	secret := cfg.GetDefault("secret", "")
+	if len(secret) < 16 && cfg.GetDefault(keyOwner, "") != "" {
+		fmt.Fprintf(os.Stderr, "secret must have at least length 16 when authentication is enabled, but is %q\n", secret)
+		return 2
+	}
+	cfg.Delete("secret")
+	secretHash := fmt.Sprintf("%x", sha256.Sum256([]byte(string(secret))))
+	oidcCfg, err := getOIDCConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	cfg.Delete(keyOIDCClientSecret)
+
 	kern := kernel.Main
+	var auditLog *audit.Log
+	if auditFile, found := cfg.Get(keyAuditLog); found && command.Boxes {
+		if auditLog, err = audit.Open(string(auditFile)); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to open audit log %q: %v\n", auditFile, err)
+			return 2
+		}
+		defer func() { _ = auditLog.Close() }()
+		kern.SetAuditLog(auditLog)
+	}
 	var createManager kernel.CreateBoxManagerFunc
+	var dispatcher *webhook.Dispatcher
 	if command.Boxes {
+		webhookState := string(cfg.GetDefault(keyWebhookState, ""))
 		createManager = func(boxURIs []*url.URL, authManager auth.Manager, rtConfig config.Config) (box.Manager, error) {
 			compbox.Setup(cfg)
-			return manager.New(boxURIs, authManager, rtConfig)
+			mgr, err := manager.New(boxURIs, authManager, rtConfig, []byte(secret))
+			if err != nil {
+				return nil, err
+			}
+			mgr.SetAuditLog(auditLog)
+			compbox.SetupAuditLog(auditLog)
+			if dispatcher != nil {
+				dispatcher.Stop()
+			}
+			dispatcher = webhook.NewDispatcher(
+				kern.GetLogger(kernel.BoxService).With("box", "webhook"), mgr, authManager.Owner(), webhookState)
+			compbox.SetupWebhooks(dispatcher)
+			compbox.SetupLockout(authManager)
+			return mgr, nil
 		}
 	} else {
 		createManager = func([]*url.URL, auth.Manager, config.Config) (box.Manager, error) { return nil, nil }
 	}
 
-	secret := cfg.GetDefault("secret", "")
-	if len(secret) < 16 && cfg.GetDefault(keyOwner, "") != "" {
-		fmt.Fprintf(os.Stderr, "secret must have at least length 16 when authentication is enabled, but is %q\n", secret)
-		return 2
-	}
-	cfg.Delete("secret")
-	secretHash := fmt.Sprintf("%x", sha256.Sum256([]byte(string(secret))))
-
 	kern.SetCreators(
-		func(readonly bool, owner id.Zid, refresh bool) (auth.Manager, error) {
-			return impl.New(readonly, owner, secretHash, refresh), nil
+		func(
+			readonly bool, owner id.Zid, refresh bool, lockoutThreshold int, lockoutDuration time.Duration,
+		) (auth.Manager, error) {
+			return impl.New(readonly, owner, secretHash, refresh, lockoutThreshold, lockoutDuration), nil
 		},
 		createManager,
 		func(srv server.Server, plMgr box.Manager, authMgr auth.Manager, rtConfig config.Config) error {
-			setupRouting(srv, plMgr, authMgr, rtConfig)
+			setupRouting(srv, plMgr, authMgr, rtConfig, auditLog, oidcCfg)
 			return nil
 		},
 	)
@@ -313,9 +378,64 @@ func executeCommand(name string, args ...string) int {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
 	}
 	kern.Shutdown(true)
+	if dispatcher != nil {
+		dispatcher.Stop()
+	}
 	return exitCode
 }
 
+// oidcConfig contains all data to allow a login via an OpenID Connect provider.
+type oidcConfig struct {
+	oidc.Config
+	opts usecase.AuthenticateOIDCOptions
+}
+
+// getOIDCConfig returns the configuration for a login via an OpenID Connect
+// provider, or nil if no provider is configured.
+func getOIDCConfig(cfg *meta.Meta) (*oidcConfig, error) {
+	issuer := cfg.GetDefault(keyOIDCIssuer, "")
+	if issuer == "" {
+		return nil, nil
+	}
+	clientID := cfg.GetDefault(keyOIDCClientID, "")
+	if clientID == "" {
+		return nil, fmt.Errorf("%s must be set, if %s is set", keyOIDCClientID, keyOIDCIssuer)
+	}
+	result := oidcConfig{
+		Config: oidc.Config{
+			Issuer:       string(issuer),
+			ClientID:     string(clientID),
+			ClientSecret: string(cfg.GetDefault(keyOIDCClientSecret, "")),
+			Scopes:       strings.Fields(string(cfg.GetDefault(keyOIDCScopes, "profile"))),
+			IdentClaim:   string(cfg.GetDefault(keyOIDCIdentClaim, oidc.DefaultIdentClaim)),
+			NameClaim:    string(cfg.GetDefault(keyOIDCNameClaim, oidc.DefaultNameClaim)),
+			RoleClaim:    string(cfg.GetDefault(keyOIDCRoleClaim, "")),
+		},
+		opts: usecase.AuthenticateOIDCOptions{
+			CreateUsers: cfg.GetBool(keyOIDCCreateUsers),
+			AllowOwner:  cfg.GetBool(keyOIDCAllowOwner),
+			SkipTOTP:    cfg.GetBool(keyOIDCSkipTOTP),
+		},
+	}
+	for mapping := range strings.FieldsSeq(string(cfg.GetDefault(keyOIDCRoles, ""))) {
+		pos := strings.LastIndexByte(mapping, ':')
+		if pos <= 0 {
+			return nil, fmt.Errorf("%s: mapping %q must have the form VALUE:ROLE", keyOIDCRoles, mapping)
+		}
+		value, role := mapping[:pos], mapping[pos+1:]
+		switch meta.Value(role).AsUserRole() {
+		case meta.UserRoleReader, meta.UserRoleCreator, meta.UserRoleWriter:
+		default:
+			return nil, fmt.Errorf("%s: invalid user role %q in mapping %q", keyOIDCRoles, role, mapping)
+		}
+		result.Roles = append(result.Roles, oidc.RoleMapping{Value: value, Role: role})
+	}
+	if result.RoleClaim != "" && len(result.Roles) == 0 {
+		return nil, fmt.Errorf("%s must be set, if %s is set", keyOIDCRoles, keyOIDCRoleClaim)
+	}
+	return &result, nil
+}
+
 // runSimple is called, when the user just starts the software via a double click
 // or via a simple call “./zettelstore“ on the command line.
 func runSimple() int {
@@ -15,9 +15,11 @@ package cmd
 
 // Mention all needed boxes to have them registered.
 import (
-	_ "zettelstore.de/z/internal/box/compbox"  // Make computed box available
-	_ "zettelstore.de/z/internal/box/constbox" // Make box with constant zettel available
-	_ "zettelstore.de/z/internal/box/dirbox"   // Standard zettel box
-	_ "zettelstore.de/z/internal/box/filebox"  // File-based box, for example zip file
-	_ "zettelstore.de/z/internal/box/membox"   // In-memory box
+	_ "zettelstore.de/z/internal/box/compbox"    // Make computed box available
+	_ "zettelstore.de/z/internal/box/constbox"   // Make box with constant zettel available
+	_ "zettelstore.de/z/internal/box/dirbox"     // Standard zettel box
+	_ "zettelstore.de/z/internal/box/filebox"    // File-based box, for example zip file
+	_ "zettelstore.de/z/internal/box/membox"     // In-memory box
+	_ "zettelstore.de/z/internal/box/overlaybox" // Copy-on-write combination of two boxes
+	_ "zettelstore.de/z/internal/box/vaultbox"   // Read-only folder of Markdown notes
 )
@@ -0,0 +1,263 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+// Package audit provides a durable, append-only log of all changes.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+)
+
+// Source specifies where a change originated.
+type Source string
+
+// Values for Source.
+const (
+	SourceInternal Source = "internal" // Zettelstore itself, e.g. when it starts
+	SourceWebUI    Source = "webui"
+	SourceAPI      Source = "api"
+	SourceConsole  Source = "console"
+)
+
+// ctxKeySource is just an additional type to make context value retrieval unambiguous.
+type ctxKeySource struct{}
+
+// WithSource returns a context that states the source of all changes.
+func WithSource(ctx context.Context, src Source) context.Context {
+	return context.WithValue(ctx, ctxKeySource{}, src)
+}
+
+// GetSource returns the source of changes, as stated in the context.
+func GetSource(ctx context.Context) Source {
+	if src, ok := ctx.Value(ctxKeySource{}).(Source); ok {
+		return src
+	}
+	return SourceInternal
+}
+
+// Op specifies the operation that was logged.
+type Op string
+
+// Values for Op.
+const (
+	OpCreate      Op = "create"
+	OpUpdate      Op = "update"
+	OpDelete      Op = "delete"
+	OpSetConfig   Op = "set-config"
+	OpUnlock      Op = "unlock"
+	OpRepairIndex Op = "repair-index"
+)
+
+// Record is one entry of the audit log.
+type Record struct {
+	Time    time.Time
+	Op      Op
+	Zid     id.Zid // Changed zettel, if any
+	User    string // User identification, if any
+	UserZid id.Zid
+	Source  Source
+	Before  string // Version of the zettel before the change, if any
+	After   string // Version of the zettel after the change, if any
+	Detail  string // Additional data, e.g. of console operations
+}
+
+// String returns a human-readable form of the record.
+func (rec *Record) String() string {
+	var sb strings.Builder
+	sb.WriteString(rec.Time.Local().Format(time.DateTime))
+	fmt.Fprintf(&sb, " %s %s", rec.Source, rec.Op)
+	if rec.Zid.IsValid() {
+		fmt.Fprintf(&sb, " zid=%v", rec.Zid)
+	}
+	if rec.User != "" {
+		fmt.Fprintf(&sb, " user=%s", rec.User)
+	}
+	if rec.Before != "" {
+		fmt.Fprintf(&sb, " before=%s", rec.Before)
+	}
+	if rec.After != "" {
+		fmt.Fprintf(&sb, " after=%s", rec.After)
+	}
+	if rec.Detail != "" {
+		fmt.Fprintf(&sb, " detail=%q", rec.Detail)
+	}
+	return sb.String()
+}
+
+// record is the stored form of a Record. Every record is stored as one line.
+type record struct {
+	Time    string `json:"time"`
+	Op      string `json:"op"`
+	Zid     string `json:"zid,omitempty"`
+	User    string `json:"user,omitempty"`
+	UserZid string `json:"user-zid,omitempty"`
+	Source  string `json:"source"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func zidString(zid id.Zid) string {
+	if zid.IsValid() {
+		return zid.String()
+	}
+	return ""
+}
+
+func (rec *Record) encode() ([]byte, error) {
+	data, err := json.Marshal(record{
+		Time:    rec.Time.UTC().Format(time.RFC3339Nano),
+		Op:      string(rec.Op),
+		Zid:     zidString(rec.Zid),
+		User:    rec.User,
+		UserZid: zidString(rec.UserZid),
+		Source:  string(rec.Source),
+		Before:  rec.Before,
+		After:   rec.After,
+		Detail:  rec.Detail,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func decode(line []byte) (Record, bool) {
+	var r record
+	if err := json.Unmarshal(line, &r); err != nil {
+		return Record{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, r.Time)
+	if err != nil {
+		return Record{}, false
+	}
+	zid, _ := id.Parse(r.Zid)
+	userZid, _ := id.Parse(r.UserZid)
+	return Record{
+		Time:    t,
+		Op:      Op(r.Op),
+		Zid:     zid,
+		User:    r.User,
+		UserZid: userZid,
+		Source:  Source(r.Source),
+		Before:  r.Before,
+		After:   r.After,
+		Detail:  r.Detail,
+	}, true
+}
+
+// Log is an audit log, stored in a file. All methods may be called on a nil
+// log, which does not store anything.
+type Log struct {
+	mx       sync.Mutex
+	filename string
+	file     *os.File
+	last     time.Time
+}
+
+// Open opens the audit log stored in the given file. The file is created, if
+// it does not exist. Records are only appended.
+func Open(filename string) (*Log, error) {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{filename: filename, file: file}
+	if fi, err2 := file.Stat(); err2 == nil && fi.Size() > 0 {
+		l.last = fi.ModTime()
+	}
+	return l, nil
+}
+
+// Close closes the audit log.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.file.Close()
+}
+
+// Append stores a new record. The record is written to stable storage before
+// this method returns. If no time is given, the current time is used.
+func (l *Log) Append(rec Record) error {
+	if l == nil {
+		return nil
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	data, err := rec.encode()
+	if err != nil {
+		return err
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	if _, err = l.file.Write(data); err != nil {
+		return err
+	}
+	l.last = rec.Time
+	return l.file.Sync()
+}
+
+// LastChange returns the time of the last record, or the zero time if the
+// log is empty.
+func (l *Log) LastChange() time.Time {
+	if l == nil {
+		return time.Time{}
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.last
+}
+
+// Records returns all records that match the given predicate, oldest first.
+// A nil predicate matches all records. Lines that cannot be decoded are
+// ignored.
+func (l *Log) Records(match func(*Record) bool) ([]Record, error) {
+	if l == nil {
+		return nil, nil
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	file, err := os.Open(l.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var result []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if rec, ok := decode(line); ok && (match == nil || match(&rec)) {
+			result = append(result, rec)
+		}
+	}
+	return result, scanner.Err()
+}
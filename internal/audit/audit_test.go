@@ -0,0 +1,110 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+)
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: now, Op: OpCreate, Zid: 20261019120000, User: "owner", UserZid: 20260101000000, Source: SourceWebUI, After: "a1"},
+		{Time: now.Add(time.Minute), Op: OpUpdate, Zid: 20261019120000, Source: SourceAPI, Before: "a1", After: "b2"},
+		{Time: now.Add(2 * time.Minute), Op: OpUnlock, Source: SourceConsole, Detail: "user"},
+	}
+	for _, rec := range records {
+		if err = l.Append(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Records must survive a restart, and corrupt lines must be ignored.
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("{broken\n")
+	_ = f.Close()
+	if l, err = Open(filename); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	if l.LastChange().IsZero() {
+		t.Error("time of last change is missing")
+	}
+
+	got, err := l.Records(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("expected %d records, but got %d: %v", len(records), len(got), got)
+	}
+	for i, rec := range records {
+		if !got[i].Time.Equal(rec.Time) {
+			t.Errorf("%d: expected time %v, but got %v", i, rec.Time, got[i].Time)
+		}
+		got[i].Time = rec.Time
+		if got[i] != rec {
+			t.Errorf("%d: expected %v, but got %v", i, rec, got[i])
+		}
+	}
+
+	zid := id.Zid(20261019120000)
+	got, err = l.Records(func(rec *Record) bool { return rec.Zid == zid })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 records for zettel %v, but got %v", zid, got)
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	t.Parallel()
+	var l *Log
+	if err := l.Append(Record{Op: OpDelete}); err != nil {
+		t.Error(err)
+	}
+	if got, err := l.Records(nil); got != nil || err != nil {
+		t.Errorf("nil log returned records %v, %v", got, err)
+	}
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	if got := GetSource(ctx); got != SourceInternal {
+		t.Errorf("expected source %q, but got %q", SourceInternal, got)
+	}
+	if got := GetSource(WithSource(ctx, SourceAPI)); got != SourceAPI {
+		t.Errorf("expected source %q, but got %q", SourceAPI, got)
+	}
+}
@@ -0,0 +1,45 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+import (
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+)
+
+// Metadata keys for access control lists. Their values are the zettel
+// identifiers of users and groups. A group is a zettel that lists the
+// identifiers of its members.
+const (
+	KeyReadACL      = "read-acl"    // Users and groups that may read a zettel
+	KeyWriteACL     = "write-acl"   // Users and groups that may change a zettel
+	KeyGroupMembers = "member-zids" // Users that are members of a group zettel
+)
+
+// GetACL returns the identifiers of users and groups of the given access
+// control list. If the zettel has no such list, false is returned. Invalid
+// identifiers are ignored, so that a list with only invalid values denies
+// access to all users.
+func GetACL(m *meta.Meta, key string) ([]id.Zid, bool) {
+	if _, found := m.Get(key); !found {
+		return nil, false
+	}
+	var result []id.Zid
+	for val := range m.GetFields(key) {
+		if zid, err := id.Parse(val); err == nil {
+			result = append(result, zid)
+		}
+	}
+	return result, true
+}
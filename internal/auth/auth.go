@@ -33,9 +33,12 @@ type BaseManager interface {
 // TokenManager provides methods to create authentication
 type TokenManager interface {
 
-	// GetToken produces a authentication token.
+	// GetToken produces a authentication token for a new session.
 	GetToken(ident *meta.Meta, d time.Duration, kind TokenKind) ([]byte, error)
 
+	// RenewToken produces a authentication token for an existing session.
+	RenewToken(ident *meta.Meta, d time.Duration, kind TokenKind, session string) ([]byte, error)
+
 	// CheckToken checks the validity of the token and returns relevant data.
 	CheckToken(token []byte, k TokenKind) (TokenData, error)
 }
@@ -53,11 +56,13 @@ const (
 // TokenData contains some important elements from a token.
 type TokenData struct {
 	Token   []byte
+	Kind    TokenKind
 	Now     time.Time
 	Issued  time.Time
 	Expires time.Time
 	Ident   string
 	Zid     id.Zid
+	Session string
 }
 
 // AuthzManager provides methods for authorization.
@@ -80,12 +85,33 @@ type AuthzManager interface {
 	GetUserRole(user *meta.Meta) meta.UserRole
 }
 
+// SessionManager keeps track of the sessions, which are started by
+// authenticating a user and which end when their token expires.
+type SessionManager interface {
+	// Sessions returns all sessions that are known to be active.
+	Sessions() []SessionData
+
+	// RevokeSession ends the given session before its token expires.
+	RevokeSession(session string, expires time.Time)
+
+	// RevokeUserSessions ends all sessions of the given user, which were
+	// started not after the given time.
+	RevokeUserSessions(zid id.Zid, issued time.Time)
+}
+
 // Manager is the main interface for providing the service.
 type Manager interface {
 	TokenManager
 	AuthzManager
+	SessionManager
+	LockoutManager
+	ShareManager
 
 	BoxWithPolicy(unprotectedBox box.Box, rtConfig config.Config) (box.Box, Policy)
+
+	// GroupSubject notifies its observers after the members of a group zettel
+	// were updated. Observers of the box may be notified before that.
+	GroupSubject() box.Subject
 }
 
 // Policy is an interface for checking access authorization.
@@ -104,4 +130,7 @@ type Policy interface {
 
 	// User is allowed to refresh box data.
 	CanRefresh(user *meta.Meta) bool
+
+	// User is allowed to share zettel with people without an account.
+	CanShare(user, m *meta.Meta) bool
 }
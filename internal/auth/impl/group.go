@@ -0,0 +1,157 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/id/idset"
+	"t73f.de/r/zsc/domain/meta"
+	"t73f.de/r/zsc/webapi"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/kernel"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/query"
+)
+
+// groupRegistry stores the members of all group zettel.
+//
+// A policy is asked while the box holds some locks, so it must not retrieve
+// zettel. Therefore, the registry observes all changes and retrieves changed
+// group zettel in the background. Since other observers of the box may be
+// notified before the registry is updated, the registry notifies its own
+// observers after an update.
+type groupRegistry struct {
+	mx      sync.RWMutex
+	members map[id.Zid]*idset.Set // group -> users
+
+	mxUpdate  sync.Mutex // Only one update at a time
+	logger    *slog.Logger
+	box       box.Box
+	observers []box.UpdateFunc
+}
+
+func newGroupRegistry() *groupRegistry {
+	return &groupRegistry{members: map[id.Zid]*idset.Set{}}
+}
+
+// setBox starts to observe the given box, which must not check access rights.
+func (gr *groupRegistry) setBox(b box.Box) {
+	gr.mxUpdate.Lock()
+	gr.logger = kernel.Main.GetLogger(kernel.AuthService)
+	gr.box = b
+	gr.mxUpdate.Unlock()
+	if subject, ok := b.(box.Subject); ok {
+		subject.RegisterObserver(gr.observe)
+	}
+	gr.observe(box.UpdateInfo{Box: b, Reason: box.OnReload})
+}
+
+// RegisterObserver registers an observer that will be notified after the
+// members of a group were updated.
+func (gr *groupRegistry) RegisterObserver(f box.UpdateFunc) {
+	if f != nil {
+		gr.mxUpdate.Lock()
+		gr.observers = append(gr.observers, f)
+		gr.mxUpdate.Unlock()
+	}
+}
+
+func (gr *groupRegistry) notifyObservers(ci box.UpdateInfo) {
+	// Must only be called if gr.mxUpdate is locked!
+	for _, ob := range gr.observers {
+		ob(ci)
+	}
+}
+
+func (gr *groupRegistry) observe(ci box.UpdateInfo) {
+	if ci.Reason == box.OnZettel || ci.Reason == box.OnDelete {
+		go gr.update(ci.Zid)
+	} else {
+		go gr.reload()
+	}
+}
+
+func (gr *groupRegistry) reload() {
+	gr.mxUpdate.Lock()
+	defer gr.mxUpdate.Unlock()
+	if gr.box == nil {
+		return
+	}
+	q := query.Parse(auth.KeyGroupMembers + webapi.ExistOperator)
+	metaList, err := gr.box.SelectMeta(box.NoEnrichContext(context.Background()), nil, q)
+	if err != nil {
+		// Box is not started yet. It will notify when it is ready.
+		gr.logger.Debug("unable to retrieve groups", logging.Err(err))
+		return
+	}
+	members := make(map[id.Zid]*idset.Set, len(metaList))
+	for _, m := range metaList {
+		members[m.Zid] = getMembers(m)
+	}
+	gr.mx.Lock()
+	gr.members = members
+	gr.mx.Unlock()
+	gr.notifyObservers(box.UpdateInfo{Box: gr.box, Reason: box.OnReload, Zid: id.Invalid})
+}
+
+func (gr *groupRegistry) update(zid id.Zid) {
+	gr.mxUpdate.Lock()
+	defer gr.mxUpdate.Unlock()
+	if gr.box == nil {
+		return
+	}
+	m, err := gr.box.GetMeta(box.NoEnrichContext(context.Background()), zid)
+	gr.mx.Lock()
+	_, wasGroup := gr.members[zid]
+	isGroup := false
+	if err == nil {
+		if _, isGroup = m.Get(auth.KeyGroupMembers); isGroup {
+			gr.members[zid] = getMembers(m)
+		}
+	}
+	if !isGroup {
+		delete(gr.members, zid)
+	}
+	gr.mx.Unlock()
+	if wasGroup || isGroup {
+		gr.notifyObservers(box.UpdateInfo{Box: gr.box, Reason: box.OnZettel, Zid: zid})
+	}
+}
+
+func getMembers(m *meta.Meta) *idset.Set {
+	var result *idset.Set
+	for val := range m.GetFields(auth.KeyGroupMembers) {
+		if zid, err := id.Parse(val); err == nil {
+			result = result.Add(zid)
+		}
+	}
+	return result
+}
+
+// isPrincipal returns true, if the user is the given principal, or if the
+// principal is a group that contains the user.
+func (gr *groupRegistry) isPrincipal(user, principal id.Zid) bool {
+	if user == principal {
+		return true
+	}
+	gr.mx.RLock()
+	defer gr.mx.RUnlock()
+	return gr.members[principal].Contains(user)
+}
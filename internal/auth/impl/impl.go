@@ -37,15 +37,23 @@ type myAuth struct {
 	secret   []byte
 	readonly bool
 	refresh  bool
+	sessions *sessionRegistry
+	groups   *groupRegistry
+	lockout  *lockoutRegistry
 }
 
-// New creates a new auth object.
-func New(readonly bool, owner id.Zid, extSecret string, refresh bool) auth.Manager {
+// New creates a new auth object. After lockoutThreshold failed login attempts,
+// an account or a remote address is locked for lockoutDuration.
+func New(readonly bool, owner id.Zid, extSecret string, refresh bool, lockoutThreshold int, lockoutDuration time.Duration) auth.Manager {
 	return &myAuth{
 		owner:    owner,
 		secret:   calcSecret(extSecret),
 		readonly: readonly,
 		refresh:  refresh,
+		sessions: newSessionRegistry(),
+		groups:   newGroupRegistry(),
+		lockout: newLockoutRegistry(
+			lockoutThreshold, lockoutDuration, kernel.Main.GetLogger(kernel.AuthService)),
 	}
 }
 
@@ -84,22 +92,53 @@ var ErrOtherKind = errors.New("auth: wrong token kind")
 // ErrNoZid signals that the 'zid' key is missing.
 var ErrNoZid = errors.New("auth: missing zettel id")
 
+// ErrNoSession signals that the session identifier is missing.
+var ErrNoSession = errors.New("auth: missing session")
+
 // GetToken returns a token to be used for authentification.
 func (a *myAuth) GetToken(ident *meta.Meta, d time.Duration, kind auth.TokenKind) ([]byte, error) {
+	return a.RenewToken(ident, d, kind, newSessionID())
+}
+
+// RenewToken returns a token to be used for authentification, for an already
+// existing session.
+func (a *myAuth) RenewToken(ident *meta.Meta, d time.Duration, kind auth.TokenKind, session string) ([]byte, error) {
 	subject, ok := ident.Get(meta.KeyUserID)
 	if !ok || subject == "" {
 		return nil, ErrNoIdent
 	}
+	if session == "" {
+		return nil, ErrNoSession
+	}
 
-	now := time.Now().Round(time.Second)
+	// Truncate, so that the time of issue is never after the real time. Otherwise
+	// a token issued shortly before all sessions were revoked would still be valid.
+	now := time.Now().Truncate(time.Second)
 	sClaim := sx.MakeList(
 		sx.Int64(kind),
 		sx.MakeString(string(subject)),
 		sx.Int64(now.Unix()),
 		sx.Int64(now.Add(d).Unix()),
 		sx.Int64(ident.Zid),
+		sx.MakeString(session),
 	)
-	return sign(sClaim, a.secret)
+	token, err := sign(sClaim, a.secret)
+	if err != nil {
+		return nil, err
+	}
+	tokenData := auth.TokenData{
+		Token:   token,
+		Now:     now,
+		Issued:  now,
+		Expires: now.Add(d),
+		Ident:   string(subject),
+		Zid:     ident.Zid,
+		Session: session,
+	}
+	if err = a.sessions.see(&tokenData, kind); err != nil {
+		return nil, err
+	}
+	return token, nil
 }
 
 // ErrTokenExpired signals an exired token
@@ -115,12 +154,15 @@ func (a *myAuth) CheckToken(tok []byte, k auth.TokenKind) (auth.TokenData, error
 	}
 
 	tokenData.Token = tok
-	err = setupTokenData(obj, k, &tokenData)
+	if err = setupTokenData(obj, k, &tokenData); err != nil {
+		return tokenData, err
+	}
+	err = a.sessions.see(&tokenData, k)
 	return tokenData, err
 }
 
 func setupTokenData(obj sx.Object, k auth.TokenKind, tokenData *auth.TokenData) error {
-	vals, err := sexp.ParseList(obj, "isiii")
+	vals, err := sexp.ParseList(obj, "isiiis")
 	if err != nil {
 		return ErrMalformedToken
 	}
@@ -141,15 +183,37 @@ func setupTokenData(obj sx.Object, k auth.TokenKind, tokenData *auth.TokenData)
 	if !zid.IsValid() {
 		return ErrNoZid
 	}
+	session := vals[5].(sx.String).GetValue()
+	if session == "" {
+		return ErrNoSession
+	}
 
+	tokenData.Kind = k
 	tokenData.Ident = string(ident)
 	tokenData.Issued = issued
 	tokenData.Now = now
 	tokenData.Expires = expires
 	tokenData.Zid = zid
+	tokenData.Session = session
 	return nil
 }
 
+// Sessions returns all sessions that are known to be active.
+func (a *myAuth) Sessions() []auth.SessionData {
+	return a.sessions.sessions(time.Now())
+}
+
+// RevokeSession ends the given session before its token expires.
+func (a *myAuth) RevokeSession(session string, expires time.Time) {
+	a.sessions.revoke(session, expires, time.Now())
+}
+
+// RevokeUserSessions ends all sessions of the given user, which were started
+// not after the given time.
+func (a *myAuth) RevokeUserSessions(zid id.Zid, issued time.Time) {
+	a.sessions.revokeUser(zid, issued, time.Now())
+}
+
 func (a *myAuth) Owner() id.Zid { return a.owner }
 
 func (a *myAuth) IsOwner(zid id.Zid) bool {
@@ -178,7 +242,13 @@ func (a *myAuth) GetUserRole(user *meta.Meta) meta.UserRole {
 	return meta.UserRoleReader
 }
 
+func (a *myAuth) GroupSubject() box.Subject { return a.groups }
+
 func (a *myAuth) BoxWithPolicy(unprotectedBox box.Box, rtConfig config.Config) (box.Box, auth.Policy) {
-	pol := newPolicy(a, rtConfig)
-	return newBox(unprotectedBox, pol), pol
+	if a.WithAuth() {
+		a.groups.setBox(unprotectedBox)
+	}
+	boxes, _ := unprotectedBox.(box.AccessProvider)
+	pol := newPolicy(a, rtConfig, a.groups, boxes)
+	return newBox(unprotectedBox, pol, a.WithAuth()), pol
 }
@@ -0,0 +1,211 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"cmp"
+	"log/slog"
+	"maps"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/logging"
+)
+
+// Delays between failed login attempts: the first failure delays the next
+// attempt by lockoutBaseDelay, every further failure doubles the delay, up
+// to lockoutMaxDelay.
+const (
+	lockoutBaseDelay = 1 * time.Second
+	lockoutMaxDelay  = 5 * time.Minute
+)
+
+type lockoutKey struct {
+	kind auth.LockoutKind
+	key  string
+}
+
+type lockoutEntry struct {
+	failures    int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// lockoutRegistry stores failed login attempts in memory. An attacker
+// cannot use a restart of the software to their advantage, because a
+// restart needs much more time than an attacker would save.
+type lockoutRegistry struct {
+	mx        sync.Mutex
+	entries   map[lockoutKey]*lockoutEntry
+	threshold int           // Number of failures that lock; zero disables locking
+	duration  time.Duration // Time of a lock
+	logger    *slog.Logger
+}
+
+func newLockoutRegistry(threshold int, duration time.Duration, logger *slog.Logger) *lockoutRegistry {
+	return &lockoutRegistry{
+		entries:   map[lockoutKey]*lockoutEntry{},
+		threshold: threshold,
+		duration:  duration,
+		logger:    logger,
+	}
+}
+
+func lockoutKeys(ident, remote string) []lockoutKey {
+	keys := make([]lockoutKey, 0, 2)
+	if ident != "" {
+		keys = append(keys, lockoutKey{auth.LockoutAccount, ident})
+	}
+	if remote = lockoutAddr(remote); remote != "" {
+		keys = append(keys, lockoutKey{auth.LockoutAddress, remote})
+	}
+	return keys
+}
+
+// lockoutAddr returns the host of a remote address without a port, so that
+// a client cannot escape a lock by using another port.
+func lockoutAddr(remote string) string {
+	if ap, err := netip.ParseAddrPort(remote); err == nil {
+		return ap.Addr().Unmap().String()
+	}
+	if addr, err := netip.ParseAddr(remote); err == nil {
+		return addr.Unmap().String()
+	}
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
+
+func (lr *lockoutRegistry) check(ident, remote string, now time.Time) time.Duration {
+	lr.mx.Lock()
+	defer lr.mx.Unlock()
+	lr.cleanup(now)
+	var wait time.Duration
+	for _, key := range lockoutKeys(ident, remote) {
+		if e, found := lr.entries[key]; found {
+			wait = max(wait, e.lockedUntil.Sub(now), e.last.Add(backoffDelay(e.failures)).Sub(now))
+		}
+	}
+	return wait
+}
+
+func backoffDelay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := lockoutBaseDelay
+	for range failures - 1 {
+		if delay >= lockoutMaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, lockoutMaxDelay)
+}
+
+func (lr *lockoutRegistry) failed(ident, remote string, now time.Time) {
+	lr.mx.Lock()
+	defer lr.mx.Unlock()
+	for _, key := range lockoutKeys(ident, remote) {
+		e, found := lr.entries[key]
+		if !found {
+			e = &lockoutEntry{}
+			lr.entries[key] = e
+		}
+		e.failures++
+		e.last = now
+		if lr.threshold > 0 && e.failures >= lr.threshold && !e.lockedUntil.After(now) {
+			e.lockedUntil = now.Add(lr.duration)
+			lr.logger.Warn("Locked after failed logins", string(key.kind), key.key,
+				"failures", e.failures, "until", e.lockedUntil.Local().Format(time.DateTime))
+		}
+	}
+}
+
+func (lr *lockoutRegistry) succeeded(ident string) {
+	lr.mx.Lock()
+	defer lr.mx.Unlock()
+	for _, key := range lockoutKeys(ident, "") {
+		delete(lr.entries, key)
+	}
+}
+
+func (lr *lockoutRegistry) lockouts(now time.Time) []auth.LockoutData {
+	lr.mx.Lock()
+	defer lr.mx.Unlock()
+	lr.cleanup(now)
+	var result []auth.LockoutData
+	for key, e := range lr.entries {
+		if e.lockedUntil.After(now) {
+			result = append(result, auth.LockoutData{
+				Kind:        key.kind,
+				Key:         key.key,
+				Failures:    e.failures,
+				LastFailure: e.last,
+				LockedUntil: e.lockedUntil,
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b auth.LockoutData) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Key, b.Key))
+	})
+	return result
+}
+
+func (lr *lockoutRegistry) unlock(key string) bool {
+	lr.mx.Lock()
+	defer lr.mx.Unlock()
+	found := false
+	for _, kind := range []auth.LockoutKind{auth.LockoutAccount, auth.LockoutAddress} {
+		lk := lockoutKey{kind, key}
+		if _, ok := lr.entries[lk]; ok {
+			delete(lr.entries, lk)
+			found = true
+		}
+	}
+	if found {
+		logging.LogMandatory(lr.logger, "Unlocked", "key", key)
+	}
+	return found
+}
+
+// cleanup removes all entries that are not locked and whose last failure is
+// older than the time of a lock. The lock must be held.
+func (lr *lockoutRegistry) cleanup(now time.Time) {
+	maps.DeleteFunc(lr.entries, func(_ lockoutKey, e *lockoutEntry) bool {
+		return !e.lockedUntil.After(now) && e.last.Add(max(lr.duration, backoffDelay(e.failures))).Before(now)
+	})
+}
+
+// CheckLogin returns the time to wait until a login attempt is allowed.
+func (a *myAuth) CheckLogin(ident, remote string) time.Duration {
+	return a.lockout.check(ident, remote, time.Now())
+}
+
+// LoginFailed registers a failed login attempt.
+func (a *myAuth) LoginFailed(ident, remote string) { a.lockout.failed(ident, remote, time.Now()) }
+
+// LoginSucceeded forgets all failed login attempts of the account.
+func (a *myAuth) LoginSucceeded(ident string) { a.lockout.succeeded(ident) }
+
+// Lockouts returns all locked accounts and remote addresses.
+func (a *myAuth) Lockouts() []auth.LockoutData { return a.lockout.lockouts(time.Now()) }
+
+// Unlock removes the lock of an account or a remote address.
+func (a *myAuth) Unlock(key string) bool { return a.lockout.unlock(key) }
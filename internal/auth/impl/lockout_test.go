@@ -0,0 +1,150 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLockoutRegistry(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(time.Second)
+	lr := newLockoutRegistry(3, 15*time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if wait := lr.check("user", "192.0.2.1", now); wait != 0 {
+		t.Errorf("first attempt must be allowed, but must wait %v", wait)
+	}
+	lr.failed("user", "192.0.2.1", now)
+	if wait := lr.check("user", "192.0.2.1", now); wait != lockoutBaseDelay {
+		t.Errorf("expected to wait %v, but got %v", lockoutBaseDelay, wait)
+	}
+	if wait := lr.check("other", "192.0.2.2", now); wait != 0 {
+		t.Errorf("other account from other address must not wait, but got %v", wait)
+	}
+	if wait := lr.check("other", "192.0.2.1", now); wait != lockoutBaseDelay {
+		t.Errorf("other account from same address must wait %v, but got %v", lockoutBaseDelay, wait)
+	}
+
+	now = now.Add(time.Minute)
+	lr.failed("user", "192.0.2.2", now)
+	if wait := lr.check("user", "", now); wait != 2*lockoutBaseDelay {
+		t.Errorf("expected to wait %v after second failure, but got %v", 2*lockoutBaseDelay, wait)
+	}
+	if got := lr.lockouts(now); len(got) != 0 {
+		t.Errorf("nothing should be locked, but got %v", got)
+	}
+
+	now = now.Add(time.Minute)
+	lr.failed("user", "192.0.2.3", now)
+	lockouts := lr.lockouts(now)
+	if len(lockouts) != 1 || lockouts[0].Key != "user" || lockouts[0].Failures != 3 {
+		t.Fatalf("expected account user to be locked, but got %v", lockouts)
+	}
+	if wait := lr.check("user", "", now.Add(10*time.Minute)); wait != 5*time.Minute {
+		t.Errorf("expected to wait 5m until lock ends, but got %v", wait)
+	}
+
+	if !lr.unlock("user") {
+		t.Error("account user was not unlocked")
+	}
+	if wait := lr.check("user", "", now); wait != 0 {
+		t.Errorf("unlocked account must not wait, but got %v", wait)
+	}
+	if lr.unlock("user") {
+		t.Error("account user was unlocked twice")
+	}
+
+	lr.failed("user", "192.0.2.1", now)
+	lr.succeeded("user")
+	if wait := lr.check("user", "", now); wait != 0 {
+		t.Errorf("account must not wait after successful login, but got %v", wait)
+	}
+	if wait := lr.check("other", "192.0.2.1", now); wait == 0 {
+		t.Error("address must still wait after successful login")
+	}
+	if wait := lr.check("", "192.0.2.2", now.Add(time.Hour)); wait != 0 {
+		t.Errorf("failures must be forgotten after some time, but got %v", wait)
+	}
+}
+
+func TestLockoutAddressPort(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(time.Second)
+	lr := newLockoutRegistry(3, 15*time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	lr.failed("alice", "192.0.2.1:40001", now)
+	lr.failed("bob", "192.0.2.1:40002", now)
+	lr.failed("carol", "[::ffff:192.0.2.1]:40003", now)
+	lockouts := lr.lockouts(now)
+	if len(lockouts) != 1 || lockouts[0].Key != "192.0.2.1" || lockouts[0].Failures != 3 {
+		t.Fatalf("expected address 192.0.2.1 to be locked, but got %v", lockouts)
+	}
+	for _, remote := range []string{"192.0.2.1:40004", "192.0.2.1", "[::ffff:192.0.2.1]:40005"} {
+		if wait := lr.check("dave", remote, now); wait != 15*time.Minute {
+			t.Errorf("address %q must wait until lock ends, but got %v", remote, wait)
+		}
+	}
+	if wait := lr.check("dave", "192.0.2.2:40001", now); wait != 0 {
+		t.Errorf("other address must not wait, but got %v", wait)
+	}
+
+	lr.failed("erin", "[2001:db8::1]:40001", now)
+	if wait := lr.check("", "[2001:db8::1]:40002", now); wait != lockoutBaseDelay {
+		t.Errorf("IPv6 address with other port must wait %v, but got %v", lockoutBaseDelay, wait)
+	}
+}
+
+func TestLockoutAddr(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		remote string
+		exp    string
+	}{
+		{"192.0.2.1:1234", "192.0.2.1"},
+		{"192.0.2.1", "192.0.2.1"},
+		{"[2001:db8::1]:1234", "2001:db8::1"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"[::ffff:192.0.2.1]:1234", "192.0.2.1"},
+		{"localhost:1234", "localhost"},
+		{"", ""},
+	}
+	for _, tc := range testcases {
+		if got := lockoutAddr(tc.remote); got != tc.exp {
+			t.Errorf("lockoutAddr(%q): expected %q, but got %q", tc.remote, tc.exp, got)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		failures int
+		exp      time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{9, 256 * time.Second},
+		{10, lockoutMaxDelay},
+		{1000, lockoutMaxDelay},
+	}
+	for _, tc := range testcases {
+		if got := backoffDelay(tc.failures); got != tc.exp {
+			t.Errorf("backoffDelay(%d): expected %v, but got %v", tc.failures, tc.exp, got)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// NewPersonalTokenSecret returns a new random token identifier and a new
+// random secret for a personal access token.
+func NewPersonalTokenSecret() (string, string) {
+	var idBuf [4]byte
+	_, _ = rand.Read(idBuf[:])
+	var secretBuf [32]byte
+	_, _ = rand.Read(secretBuf[:])
+	return hex.EncodeToString(idBuf[:]), encoding.EncodeToString(secretBuf[:])
+}
+
+// HashPersonalTokenSecret returns the hash of the secret of a personal access
+// token. Since the secret is random and long, a fast hash function is
+// sufficient.
+func HashPersonalTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ComparePersonalTokenSecret checks, whether the given secret matches the hash.
+func ComparePersonalTokenSecret(hash, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(HashPersonalTokenSecret(secret))) == 1
+}
@@ -51,6 +51,10 @@ func (ap *anonPolicy) CanRefresh(user *meta.Meta) bool {
 	return ap.pre.CanRefresh(user)
 }
 
+// CanShare is always false, because without authentication there is no user
+// who could be responsible for a share link.
+func (*anonPolicy) CanShare(_, _ *meta.Meta) bool { return false }
+
 func (ap *anonPolicy) checkVisibility(m *meta.Meta) bool {
 	if ap.authConfig.GetVisibility(m) == meta.VisibilityExpert {
 		return ap.authConfig.IsExpertMode()
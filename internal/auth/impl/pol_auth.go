@@ -17,15 +17,21 @@ package impl
 // user will have a nil value for the "user" metadata.
 
 import (
+	"strings"
+
 	"t73f.de/r/zsc/domain/meta"
 
 	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/config"
+	"zettelstore.de/z/internal/webhook"
 )
 
 type authPolicy struct {
 	manager    auth.AuthzManager
 	authConfig config.AuthConfig
+	groups     *groupRegistry
+	boxes      box.AccessProvider // may be nil
 	pre        auth.Policy
 }
 
@@ -33,7 +39,10 @@ func (o *authPolicy) CanCreate(user, newMeta *meta.Meta) bool {
 	if user == nil || !o.pre.CanCreate(user, newMeta) {
 		return false
 	}
-	return o.userIsOwner(user) || o.userCanCreate(user, newMeta)
+	if o.userIsOwner(user) {
+		return true
+	}
+	return o.listAllows(user, o.boxAccess(nil), auth.KeyWriteACL) && o.userCanCreate(user, newMeta)
 }
 
 func (o *authPolicy) userCanCreate(user, newMeta *meta.Meta) bool {
@@ -43,13 +52,21 @@ func (o *authPolicy) userCanCreate(user, newMeta *meta.Meta) bool {
 	if _, ok := newMeta.Get(meta.KeyUserID); ok {
 		return false
 	}
+	if _, ok := newMeta.Get(webhook.KeyURL); ok {
+		// Only the owner may define webhook targets
+		return false
+	}
+	if _, ok := newMeta.Get(auth.KeyGroupMembers); ok {
+		// Only the owner may define groups
+		return false
+	}
 	return true
 }
 
 func (o *authPolicy) CanRead(user, m *meta.Meta) bool {
 	// No need to call o.pre.CanRead(user, meta), because it will always return true.
 	// Both the default and the readonly policy allow reading a zettel.
-	vis := o.authConfig.GetVisibility(m)
+	vis := o.getVisibility(m)
 	if res, ok := o.checkVisibility(user, vis); ok {
 		return res
 	}
@@ -57,18 +74,27 @@ func (o *authPolicy) CanRead(user, m *meta.Meta) bool {
 }
 
 func (o *authPolicy) userCanRead(user, m *meta.Meta, vis meta.Visibility) bool {
-	switch vis {
-	case meta.VisibilityOwner, meta.VisibilityExpert:
+	if !o.aclAllows(user, m, auth.KeyReadACL) {
 		return false
-	case meta.VisibilityPublic:
-		return true
 	}
-	if user == nil {
+	if vis == meta.VisibilityOwner || vis == meta.VisibilityExpert {
 		return false
 	}
+	// User zettel and webhook targets are never public, even if the
+	// visibility of the zettel or of its box says so.
 	if _, ok := m.Get(meta.KeyUserID); ok {
 		// Only the user can read its own zettel
-		return user.Zid == m.Zid
+		return user != nil && user.Zid == m.Zid
+	}
+	if _, ok := m.Get(webhook.KeyURL); ok {
+		// A webhook target may contain a secret
+		return false
+	}
+	if vis == meta.VisibilityPublic {
+		return true
+	}
+	if user == nil {
+		return false
 	}
 	switch o.manager.GetUserRole(user) {
 	case meta.UserRoleReader, meta.UserRoleWriter, meta.UserRoleOwner:
@@ -85,20 +111,56 @@ var noChangeUser = []string{
 	meta.KeyRole,
 	meta.KeyUserID,
 	meta.KeyUserRole,
+	auth.KeyTOTPSecret,
+	auth.KeyTOTPRecovery,
+	auth.KeyOIDCIssuer,
+	auth.KeyOIDCSubject,
+	auth.KeyGroupMembers,
+	auth.KeySessionsRevoked,
+	webhook.KeyURL,
+	webhook.KeyQuery,
+	webhook.KeyEvents,
+	webhook.KeySecret,
+	webhook.KeyWriter,
+}
+
+// noChangeUserPrefix contains the prefixes of keys that a user is not allowed
+// to change in its user zettel.
+var noChangeUserPrefix = []string{
+	auth.KeyPrefixPersonalToken,
+	auth.KeyPrefixRevokedSession,
+}
+
+// prefixChanged returns true, if a key with the given prefix was added,
+// removed, or changed.
+func prefixChanged(oldMeta, newMeta *meta.Meta, prefix string) bool {
+	for key, val := range oldMeta.All() {
+		if strings.HasPrefix(key, prefix) && newMeta.GetDefault(key, "") != val {
+			return true
+		}
+	}
+	for key := range newMeta.All() {
+		if strings.HasPrefix(key, prefix) {
+			if _, found := oldMeta.Get(key); !found {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (o *authPolicy) CanWrite(user, oldMeta, newMeta *meta.Meta) bool {
 	if user == nil || !o.pre.CanWrite(user, oldMeta, newMeta) {
 		return false
 	}
-	vis := o.authConfig.GetVisibility(oldMeta)
+	vis := o.getVisibility(oldMeta)
 	if res, ok := o.checkVisibility(user, vis); ok {
 		return res
 	}
 	if o.userIsOwner(user) {
 		return true
 	}
-	if !o.userCanRead(user, oldMeta, vis) {
+	if !o.userCanRead(user, oldMeta, vis) || !o.aclAllows(user, oldMeta, auth.KeyWriteACL) {
 		return false
 	}
 	if _, ok := oldMeta.Get(meta.KeyUserID); ok {
@@ -109,12 +171,21 @@ func (o *authPolicy) CanWrite(user, oldMeta, newMeta *meta.Meta) bool {
 				return false
 			}
 		}
+		for _, prefix := range noChangeUserPrefix {
+			if prefixChanged(oldMeta, newMeta, prefix) {
+				return false
+			}
+		}
 		return true
 	}
 	switch userRole := o.manager.GetUserRole(user); userRole {
 	case meta.UserRoleReader, meta.UserRoleCreator:
 		return false
 	}
+	if _, ok := oldMeta.Get(auth.KeyGroupMembers); ok {
+		// Only the owner may change the members of a group
+		return false
+	}
 	return o.userCanCreate(user, newMeta)
 }
 
@@ -122,7 +193,7 @@ func (o *authPolicy) CanDelete(user, m *meta.Meta) bool {
 	if user == nil || !o.pre.CanDelete(user, m) {
 		return false
 	}
-	if res, ok := o.checkVisibility(user, o.authConfig.GetVisibility(m)); ok {
+	if res, ok := o.checkVisibility(user, o.getVisibility(m)); ok {
 		return res
 	}
 	return o.userIsOwner(user)
@@ -138,6 +209,87 @@ func (o *authPolicy) CanRefresh(user *meta.Meta) bool {
 	return true
 }
 
+func (o *authPolicy) CanShare(user, m *meta.Meta) bool {
+	if user == nil || !o.pre.CanShare(user, m) {
+		return false
+	}
+	if _, ok := m.Get(meta.KeyUserID); ok {
+		// A user zettel contains credentials
+		return false
+	}
+	switch o.manager.GetUserRole(user) {
+	case meta.UserRoleWriter, meta.UserRoleOwner:
+		return o.CanRead(user, m)
+	}
+	return false
+}
+
+// visibilityRank orders the visibility values, from the least restrictive to
+// the most restrictive one.
+var visibilityRank = map[meta.Visibility]int{
+	meta.VisibilityPublic:  1,
+	meta.VisibilityCreator: 2,
+	meta.VisibilityLogin:   3,
+	meta.VisibilityOwner:   4,
+	meta.VisibilityExpert:  5,
+}
+
+// getVisibility returns the visibility of the zettel. If the access settings
+// of its box specify a visibility, it replaces the default visibility. If the
+// zettel specifies its own visibility too, the more restrictive one is
+// returned.
+func (o *authPolicy) getVisibility(m *meta.Meta) meta.Visibility {
+	vis := o.authConfig.GetVisibility(m)
+	if bm := o.boxAccess(m); bm != nil {
+		if val, found := bm.Get(meta.KeyVisibility); found {
+			boxVis := val.AsVisibility()
+			if _, hasOwn := m.Get(meta.KeyVisibility); !hasOwn || visibilityRank[boxVis] > visibilityRank[vis] {
+				return boxVis
+			}
+		}
+	}
+	return vis
+}
+
+// boxAccess returns the access settings of the box that stores the zettel.
+// If m is nil or has no box name, it is the box that stores new zettel.
+func (o *authPolicy) boxAccess(m *meta.Meta) *meta.Meta {
+	if o.boxes == nil {
+		return nil
+	}
+	var boxName meta.Value
+	if m != nil {
+		boxName, _ = m.Get(meta.KeyBoxName)
+	}
+	return o.boxes.BoxAccess(string(boxName))
+}
+
+// aclAllows checks the access control lists of the zettel and of its box.
+func (o *authPolicy) aclAllows(user, m *meta.Meta, key string) bool {
+	return o.listAllows(user, m, key) && o.listAllows(user, o.boxAccess(m), key)
+}
+
+// listAllows checks the access control list, if there is one. The user must
+// be listed there, directly or as a member of a listed group.
+func (o *authPolicy) listAllows(user, m *meta.Meta, key string) bool {
+	if m == nil {
+		return true
+	}
+	principals, found := auth.GetACL(m, key)
+	if !found {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	for _, principal := range principals {
+		if o.groups.isPrincipal(user.Zid, principal) {
+			return true
+		}
+	}
+	return false
+}
+
 func (o *authPolicy) checkVisibility(user *meta.Meta, vis meta.Visibility) (bool, bool) {
 	if vis == meta.VisibilityExpert {
 		return o.userIsOwner(user) && o.authConfig.IsExpertMode(), true
@@ -15,6 +15,7 @@ package impl
 
 import (
 	"context"
+	"strings"
 
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/id/idset"
@@ -26,15 +27,19 @@ import (
 
 // polBox implements a policy box.
 type polBox struct {
-	box    box.Box
-	policy auth.Policy
+	box         box.Box
+	policy      auth.Policy
+	filterLinks bool
 }
 
-// newBox creates a new policy box.
-func newBox(box box.Box, policy auth.Policy) box.Box {
+// newBox creates a new policy box. If filterLinks is true, identifiers of
+// zettel that the user is not allowed to read are removed from properties
+// like back links.
+func newBox(box box.Box, policy auth.Policy, filterLinks bool) box.Box {
 	return &polBox{
-		box:    box,
-		policy: policy,
+		box:         box,
+		policy:      policy,
+		filterLinks: filterLinks,
 	}
 }
 
@@ -60,13 +65,18 @@ func (pp *polBox) GetZettel(ctx context.Context, zid id.Zid) (box.Zettel, error)
 	}
 	user := auth.GetCurrentUser(ctx)
 	if pp.policy.CanRead(user, z.Meta) {
+		z.Meta = pp.removeHiddenLinks(ctx, user, removeSecretKeys(z.Meta), nil)
 		return z, nil
 	}
 	return box.Zettel{}, box.NewErrNotAllowed("GetZettel", user, zid)
 }
 
 func (pp *polBox) GetAllZettel(ctx context.Context, zid id.Zid) ([]box.Zettel, error) {
-	return pp.box.GetAllZettel(ctx, zid)
+	result, err := pp.box.GetAllZettel(ctx, zid)
+	for i, z := range result {
+		result[i].Meta = removeSecretKeys(z.Meta)
+	}
+	return result, err
 }
 
 func (pp *polBox) FetchZids(ctx context.Context) (*idset.Set, error) {
@@ -80,7 +90,7 @@ func (pp *polBox) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
 	}
 	user := auth.GetCurrentUser(ctx)
 	if pp.policy.CanRead(user, m) {
-		return m, nil
+		return pp.removeHiddenLinks(ctx, user, removeSecretKeys(m), nil), nil
 	}
 	return nil, box.NewErrNotAllowed("GetMeta", user, zid)
 }
@@ -89,7 +99,101 @@ func (pp *polBox) SelectMeta(ctx context.Context, metaSeq []*meta.Meta, q *box.Q
 	user := auth.GetCurrentUser(ctx)
 	canRead := pp.policy.CanRead
 	q = q.SetPreMatch(func(m *meta.Meta) bool { return canRead(user, m) })
-	return pp.box.SelectMeta(ctx, metaSeq, q)
+	result, err := pp.box.SelectMeta(ctx, metaSeq, q)
+	if err != nil {
+		return nil, err
+	}
+	readable := map[id.Zid]bool{}
+	for i, m := range result {
+		result[i] = pp.removeHiddenLinks(ctx, user, removeSecretKeys(m), readable)
+	}
+	return result, nil
+}
+
+// secretKeys are the metadata keys, whose values must never be shown to
+// anybody, because they store a secret. Hashed values are not secret.
+var secretKeys = []string{auth.KeyTOTPSecret}
+
+// removeSecretKeys returns the metadata without the values of secretKeys.
+func removeSecretKeys(m *meta.Meta) *meta.Meta {
+	result := m
+	for _, key := range secretKeys {
+		if _, found := m.Get(key); found {
+			if result == m {
+				result = m.Clone()
+			}
+			result.Delete(key)
+		}
+	}
+	return result
+}
+
+// restoreSecretKeys returns the new metadata with the values of secretKeys of
+// the old metadata, because they were removed when the zettel was read.
+func restoreSecretKeys(newMeta, oldMeta *meta.Meta) *meta.Meta {
+	result := newMeta
+	for _, key := range secretKeys {
+		if _, found := newMeta.Get(key); found {
+			continue
+		}
+		if val, found := oldMeta.Get(key); found {
+			if result == newMeta {
+				result = newMeta.Clone()
+			}
+			result.Set(key, val)
+		}
+	}
+	return result
+}
+
+// removeHiddenLinks removes the identifiers of zettel that the user is not
+// allowed to read from all properties, like back links. Otherwise, a zettel
+// would reveal the existence of zettel that link to it. The map readable
+// caches the decision for zettel identifiers, it may be nil.
+func (pp *polBox) removeHiddenLinks(ctx context.Context, user, m *meta.Meta, readable map[id.Zid]bool) *meta.Meta {
+	if !pp.filterLinks {
+		return m
+	}
+	if readable == nil {
+		readable = map[id.Zid]bool{}
+	}
+	canRead := func(zid id.Zid) bool {
+		if ok, found := readable[zid]; found {
+			return ok
+		}
+		lm, err := pp.box.GetMeta(box.NoEnrichContext(ctx), zid)
+		ok := err == nil && pp.policy.CanRead(user, lm)
+		readable[zid] = ok
+		return ok
+	}
+
+	result := m
+	for key := range m.All() {
+		if dt := meta.Type(key); !meta.IsProperty(key) || (dt != meta.TypeID && dt != meta.TypeIDSet) {
+			continue
+		}
+		var kept []string
+		changed := false
+		for val := range m.GetFields(key) {
+			if zid, err := id.Parse(val); err == nil && !canRead(zid) {
+				changed = true
+				continue
+			}
+			kept = append(kept, val)
+		}
+		if !changed {
+			continue
+		}
+		if result == m {
+			result = m.Clone()
+		}
+		if len(kept) == 0 {
+			result.Delete(key)
+		} else {
+			result.Set(key, meta.Value(strings.Join(kept, " ")))
+		}
+	}
+	return result
 }
 
 func (pp *polBox) CanUpdateZettel(ctx context.Context, zettel box.Zettel) bool {
@@ -107,6 +211,7 @@ func (pp *polBox) UpdateZettel(ctx context.Context, zettel box.Zettel) error {
 	if err != nil {
 		return err
 	}
+	zettel.Meta = restoreSecretKeys(zettel.Meta, oldZettel.Meta)
 	if pp.policy.CanWrite(user, oldZettel.Meta, zettel.Meta) {
 		return pp.box.UpdateZettel(ctx, zettel)
 	}
@@ -144,3 +249,11 @@ func (pp *polBox) ReIndex(ctx context.Context, zid id.Zid) error {
 	}
 	return box.NewErrNotAllowed("ReIndex", user, zid)
 }
+
+func (pp *polBox) CheckIndex(ctx context.Context, repair bool) ([]box.IndexProblem, error) {
+	user := auth.GetCurrentUser(ctx)
+	if pp.policy.CanRefresh(user) {
+		return pp.box.CheckIndex(ctx, repair)
+	}
+	return nil, box.NewErrNotAllowed("CheckIndex", user, id.Invalid)
+}
@@ -0,0 +1,60 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"testing"
+
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+func TestSecretKeys(t *testing.T) {
+	t.Parallel()
+	m := meta.New(13)
+	m.Set(meta.KeyUserID, "user")
+	m.Set(auth.KeyTOTPSecret, "SECRET")
+	m.Set(auth.KeyTOTPRecovery, "HASH")
+
+	shown := removeSecretKeys(m)
+	if _, found := shown.Get(auth.KeyTOTPSecret); found {
+		t.Error("secret must be removed")
+	}
+	if _, found := shown.Get(auth.KeyTOTPRecovery); !found {
+		t.Error("hash values must not be removed")
+	}
+	if _, found := m.Get(auth.KeyTOTPSecret); !found {
+		t.Error("original metadata must not be changed")
+	}
+	if removeSecretKeys(shown) != shown {
+		t.Error("metadata without secrets must not be copied")
+	}
+
+	changed := shown.Clone()
+	changed.Set(meta.KeyTitle, "changed")
+	restored := restoreSecretKeys(changed, m)
+	if val, found := restored.Get(auth.KeyTOTPSecret); !found || val != "SECRET" {
+		t.Errorf("secret was not restored: %q", val)
+	}
+	if _, found := changed.Get(auth.KeyTOTPSecret); found {
+		t.Error("new metadata must not be changed")
+	}
+
+	other := m.Clone()
+	other.Set(auth.KeyTOTPSecret, "OTHER")
+	if val, _ := restoreSecretKeys(other, m).Get(auth.KeyTOTPSecret); val != "OTHER" {
+		t.Errorf("given secret must not be overwritten, but got %q", val)
+	}
+}
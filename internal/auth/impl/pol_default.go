@@ -30,6 +30,7 @@ func (*roPolicy) CanRead(_, _ *meta.Meta) bool     { return true }
 func (*roPolicy) CanWrite(_, _, _ *meta.Meta) bool { return false }
 func (*roPolicy) CanDelete(_, _ *meta.Meta) bool   { return false }
 func (*roPolicy) CanRefresh(user *meta.Meta) bool  { return user != nil }
+func (*roPolicy) CanShare(_, _ *meta.Meta) bool    { return true }
 
 // Policy in use when zettel can be read, created, and written.
 
@@ -45,6 +46,7 @@ func (d *crudPolicy) CanWrite(user, oldMeta, _ *meta.Meta) bool {
 func (d *crudPolicy) CanDelete(user, m *meta.Meta) bool { return d.canChange(user, m) }
 
 func (*crudPolicy) CanRefresh(user *meta.Meta) bool { return user != nil }
+func (*crudPolicy) CanShare(_, _ *meta.Meta) bool   { return true }
 
 func (d *crudPolicy) canChange(user, m *meta.Meta) bool {
 	metaRo, ok := m.Get(meta.KeyReadOnly)
@@ -0,0 +1,83 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+// Restrictions of a user that was authenticated with a personal access token.
+
+import (
+	"slices"
+
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+type scopePolicy struct {
+	pre auth.Policy
+}
+
+func (p *scopePolicy) CanCreate(user, newMeta *meta.Meta) bool {
+	return canWriteScoped(user, newMeta) && p.pre.CanCreate(user, newMeta)
+}
+
+func (p *scopePolicy) CanRead(user, m *meta.Meta) bool {
+	return hasScopedRole(user, m) && p.pre.CanRead(user, m)
+}
+
+func (p *scopePolicy) CanWrite(user, oldMeta, newMeta *meta.Meta) bool {
+	return canWriteScoped(user, oldMeta) && hasScopedRole(user, newMeta) &&
+		p.pre.CanWrite(user, oldMeta, newMeta)
+}
+
+func (p *scopePolicy) CanDelete(user, m *meta.Meta) bool {
+	return canWriteScoped(user, m) && p.pre.CanDelete(user, m)
+}
+
+func (p *scopePolicy) CanRefresh(user *meta.Meta) bool {
+	return !isReadScoped(user) && p.pre.CanRefresh(user)
+}
+
+func (p *scopePolicy) CanShare(user, m *meta.Meta) bool {
+	return !isReadScoped(user) && hasScopedRole(user, m) && p.pre.CanShare(user, m)
+}
+
+func isReadScoped(user *meta.Meta) bool {
+	return user != nil && user.GetDefault(auth.KeyTokenScope, auth.TokenScopeWrite) == auth.TokenScopeRead
+}
+
+func canWriteScoped(user, m *meta.Meta) bool {
+	if !auth.IsTokenUser(user) {
+		return true
+	}
+	if isReadScoped(user) {
+		return false
+	}
+	if _, isUser := m.Get(meta.KeyUserID); isUser {
+		// Personal access tokens must not be used to change credentials or tokens
+		return false
+	}
+	return hasScopedRole(user, m)
+}
+
+func hasScopedRole(user, m *meta.Meta) bool {
+	if user == nil || m == nil {
+		return true
+	}
+	roles, found := user.Get(auth.KeyTokenRoles)
+	if !found {
+		return true
+	}
+	role, _ := m.Get(meta.KeyRole)
+	return slices.Contains(slices.Collect(roles.Fields()), string(role))
+}
@@ -17,11 +17,12 @@ import (
 	"t73f.de/r/zsc/domain/meta"
 
 	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/config"
 )
 
 // newPolicy creates a policy based on given constraints.
-func newPolicy(manager auth.AuthzManager, authConfig config.AuthConfig) auth.Policy {
+func newPolicy(manager auth.AuthzManager, authConfig config.AuthConfig, groups *groupRegistry, boxes box.AccessProvider) auth.Policy {
 	var pol auth.Policy
 	if manager.IsReadonly() {
 		pol = &roPolicy{}
@@ -29,10 +30,14 @@ func newPolicy(manager auth.AuthzManager, authConfig config.AuthConfig) auth.Pol
 		pol = &crudPolicy{manager}
 	}
 	if manager.WithAuth() {
-		pol = &authPolicy{
-			manager:    manager,
-			authConfig: authConfig,
-			pre:        pol,
+		pol = &scopePolicy{
+			pre: &authPolicy{
+				manager:    manager,
+				authConfig: authConfig,
+				groups:     groups,
+				boxes:      boxes,
+				pre:        pol,
+			},
 		}
 	} else {
 		pol = &anonPolicy{
@@ -68,3 +73,7 @@ func (p *prePolicy) CanDelete(user, m *meta.Meta) bool {
 func (p *prePolicy) CanRefresh(user *meta.Meta) bool {
 	return p.post.CanRefresh(user)
 }
+
+func (p *prePolicy) CanShare(user, m *meta.Meta) bool {
+	return m != nil && p.post.CanShare(user, m)
+}
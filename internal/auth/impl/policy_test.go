@@ -18,9 +18,11 @@ import (
 	"testing"
 
 	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/id/idset"
 	"t73f.de/r/zsc/domain/meta"
 
 	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/webhook"
 )
 
 func TestPolicies(t *testing.T) {
@@ -40,6 +42,8 @@ func TestPolicies(t *testing.T) {
 		pol := newPolicy(
 			&testAuthzManager{readOnly: readonly, withAuth: withAuth, refresh: refresh},
 			&authConfig{simple: simple, expert: expert},
+			newGroupRegistry(),
+			nil,
 		)
 		name := fmt.Sprintf("readonly=%v/withauth=%v/expert=%v/simple=%v/refresh=%v",
 			readonly, withAuth, expert, simple, refresh)
@@ -49,10 +53,210 @@ func TestPolicies(t *testing.T) {
 			testWrite(tt, pol, withAuth, readonly, expert)
 			testDelete(tt, pol, withAuth, readonly, expert)
 			testRefresh(tt, pol, withAuth, simple, refresh)
+			testShare(tt, pol, withAuth)
 		})
 	}
 }
 
+func TestScopePolicy(t *testing.T) {
+	t.Parallel()
+	pol := newPolicy(&testAuthzManager{withAuth: true}, &authConfig{}, newGroupRegistry(), nil)
+	writer := newWriter()
+	readToken := auth.ScopeUser(writer, &auth.PersonalToken{ReadOnly: true})
+	writeToken := auth.ScopeUser(writer, &auth.PersonalToken{})
+	roleToken := auth.ScopeUser(writer, &auth.PersonalToken{Roles: []string{"project"}})
+	zettel := newZettel()
+	projectZettel := newZettel()
+	projectZettel.Set(meta.KeyRole, "project")
+	userZettel := newWriter()
+
+	testCases := []struct {
+		name                                string
+		user, m                             *meta.Meta
+		read, write, delete, refresh, share bool
+	}{
+		{"login", writer, zettel, true, true, false, true, true},
+		{"read", readToken, zettel, true, false, false, false, false},
+		{"write", writeToken, zettel, true, true, false, true, true},
+		{"write/user", writeToken, userZettel, true, false, false, true, false},
+		{"role/other", roleToken, zettel, false, false, false, true, false},
+		{"role/project", roleToken, projectZettel, true, true, false, true, true},
+	}
+	for _, tc := range testCases {
+		if got := pol.CanRead(tc.user, tc.m); got != tc.read {
+			t.Errorf("%v: CanRead should be %v, but got %v", tc.name, tc.read, got)
+		}
+		if got := pol.CanWrite(tc.user, tc.m, tc.m); got != tc.write {
+			t.Errorf("%v: CanWrite should be %v, but got %v", tc.name, tc.write, got)
+		}
+		if got := pol.CanCreate(tc.user, tc.m); got != tc.write && tc.m != userZettel {
+			t.Errorf("%v: CanCreate should be %v, but got %v", tc.name, tc.write, got)
+		}
+		if got := pol.CanDelete(tc.user, tc.m); got != tc.delete {
+			t.Errorf("%v: CanDelete should be %v, but got %v", tc.name, tc.delete, got)
+		}
+		if got := pol.CanRefresh(tc.user); got != tc.refresh {
+			t.Errorf("%v: CanRefresh should be %v, but got %v", tc.name, tc.refresh, got)
+		}
+		if got := pol.CanShare(tc.user, tc.m); got != tc.share {
+			t.Errorf("%v: CanShare should be %v, but got %v", tc.name, tc.share, got)
+		}
+	}
+}
+
+func TestACLPolicy(t *testing.T) {
+	t.Parallel()
+	groups := newGroupRegistry()
+	groups.members[groupZid] = idset.New(readerZid)
+	pol := newPolicy(&testAuthzManager{withAuth: true}, &authConfig{}, groups, nil)
+	reader, writer, owner := newReader(), newWriter(), newOwner()
+	readACL := newZettel()
+	readACL.Set(auth.KeyReadACL, meta.Value(groupZid.String()))
+	writeACL := newZettel()
+	writeACL.Set(auth.KeyWriteACL, meta.Value(readerZid.String()))
+	invalidACL := newPublicZettel()
+	invalidACL.Set(auth.KeyReadACL, "nobody")
+	group := newZettel()
+	group.Set(auth.KeyGroupMembers, meta.Value(writerZid.String()))
+
+	testCases := []struct {
+		name        string
+		user, m     *meta.Meta
+		read, write bool
+	}{
+		{"read/anon", nil, readACL, false, false},
+		{"read/reader", reader, readACL, true, false},
+		{"read/writer", writer, readACL, false, false},
+		{"read/owner", owner, readACL, true, true},
+		{"write/reader", reader, writeACL, true, false},
+		{"write/writer", writer, writeACL, true, false},
+		{"write/owner", owner, writeACL, true, true},
+		{"invalid/anon", nil, invalidACL, false, false},
+		{"invalid/writer", writer, invalidACL, false, false},
+		{"group/writer", writer, group, true, false},
+		{"group/owner", owner, group, true, true},
+	}
+	for _, tc := range testCases {
+		if got := pol.CanRead(tc.user, tc.m); got != tc.read {
+			t.Errorf("%v: CanRead should be %v, but got %v", tc.name, tc.read, got)
+		}
+		if got := pol.CanWrite(tc.user, tc.m, tc.m); got != tc.write {
+			t.Errorf("%v: CanWrite should be %v, but got %v", tc.name, tc.write, got)
+		}
+	}
+	if pol.CanCreate(writer, group) {
+		t.Error("writer must not create a group")
+	}
+}
+
+func TestUserZettelPolicy(t *testing.T) {
+	t.Parallel()
+	pol := newPolicy(&testAuthzManager{withAuth: true}, &authConfig{}, newGroupRegistry(), nil)
+	writer, owner := newWriter(), newOwner()
+	const tokenKey = auth.KeyPrefixPersonalToken + "1a2b3c4d"
+	writer.Set(tokenKey, "HASH 20270101120000 read project CI job")
+	const sessionKey = auth.KeyPrefixRevokedSession + "1a2b3c4d5e6f7a8b"
+	writer.Set(sessionKey, "20270101120000")
+	writer.Set(auth.KeySessionsRevoked, "20261018220000")
+	changed := func(keyVals ...string) *meta.Meta {
+		m := writer.Clone()
+		for i := 0; i < len(keyVals); i += 2 {
+			m.Set(keyVals[i], meta.Value(keyVals[i+1]))
+		}
+		return m
+	}
+	testCases := []struct {
+		name string
+		user *meta.Meta
+		new  *meta.Meta
+		exp  bool
+	}{
+		{"title/writer", writer, changed(meta.KeyTitle, "Changed"), true},
+		{"webhook-url/writer", writer, changed(webhook.KeyURL, "http://localhost/"), false},
+		{"webhook-query/writer", writer, changed(webhook.KeyQuery, "role:secret"), false},
+		{"webhook-events/writer", writer, changed(webhook.KeyEvents, "update"), false},
+		{"webhook-secret/writer", writer, changed(webhook.KeySecret, "secret"), false},
+		{"webhook-writer/writer", writer, changed(webhook.KeyWriter, ownerZid.String()), false},
+		{"webhook-url/owner", owner, changed(webhook.KeyURL, "http://localhost/"), true},
+		{"token-expiry/writer", writer, changed(tokenKey, "HASH 20990101120000 read project CI job"), false},
+		{"token-scope/writer", writer, changed(tokenKey, "HASH 20270101120000 write project CI job"), false},
+		{"token-add/writer", writer, changed(auth.KeyPrefixPersonalToken+"5e6f7a8b", "HASH 20990101120000 write"), false},
+		{"token-owner/owner", owner, changed(tokenKey, "HASH 20990101120000 read project CI job"), true},
+		{"sessions-revoked/writer", writer, changed(auth.KeySessionsRevoked, "20261018210000"), false},
+		{"revoked-session/writer", writer, changed(sessionKey, "20261018210000"), false},
+		{"sessions-revoked/owner", owner, changed(auth.KeySessionsRevoked, "20261018210000"), true},
+	}
+	for _, tc := range testCases {
+		if got := pol.CanWrite(tc.user, writer, tc.new); got != tc.exp {
+			t.Errorf("%v: CanWrite should be %v, but got %v", tc.name, tc.exp, got)
+		}
+	}
+	for _, key := range []string{tokenKey, sessionKey, auth.KeySessionsRevoked} {
+		removed := writer.Clone()
+		removed.Delete(key)
+		if pol.CanWrite(writer, writer, removed) {
+			t.Errorf("writer must not remove key %q directly", key)
+		}
+	}
+}
+
+func TestBoxAccessPolicy(t *testing.T) {
+	t.Parallel()
+	private := meta.New(id.Invalid)
+	private.Set(meta.KeyVisibility, meta.ValueVisibilityOwner)
+	public := meta.New(id.Invalid)
+	public.Set(meta.KeyVisibility, meta.ValueVisibilityPublic)
+	team := meta.New(id.Invalid)
+	team.Set(auth.KeyWriteACL, meta.Value(writerZid.String()))
+	boxes := testAccessProvider{"private": private, "public": public, "team": team}
+	pol := newPolicy(&testAuthzManager{withAuth: true}, &authConfig{}, newGroupRegistry(), boxes)
+	mkZettel := func(boxName string, keyVals ...string) *meta.Meta {
+		m := newZettel()
+		m.Set(meta.KeyBoxName, meta.Value(boxName))
+		for i := 0; i < len(keyVals); i += 2 {
+			m.Set(keyVals[i], meta.Value(keyVals[i+1]))
+		}
+		return m
+	}
+	reader, writer, owner := newReader(), newWriter(), newOwner()
+	otherWriter := meta.New(otherZid)
+	otherWriter.Set(meta.KeyUserID, "other")
+	otherWriter.Set(meta.KeyUserRole, meta.ValueUserRoleWriter)
+
+	testCases := []struct {
+		name        string
+		user, m     *meta.Meta
+		read, write bool
+	}{
+		{"other/anon", nil, mkZettel("other"), false, false},
+		{"other/writer", writer, mkZettel("other"), true, true},
+		{"private/writer", writer, mkZettel("private"), false, false},
+		{"private/owner", owner, mkZettel("private"), true, true},
+		{"public/anon", nil, mkZettel("public"), true, false},
+		{"public/reader", reader, mkZettel("public"), true, false},
+		{"public/private-anon", nil, mkZettel("public", meta.KeyVisibility, meta.ValueVisibilityOwner), false, false},
+		{"public/private-writer", writer, mkZettel("public", meta.KeyVisibility, meta.ValueVisibilityOwner), false, false},
+		{"public/login-anon", nil, mkZettel("public", meta.KeyVisibility, meta.ValueVisibilityLogin), false, false},
+		{"public/user-anon", nil, mkZettel("public", meta.KeyUserID, "user"), false, false},
+		{"public/webhook-anon", nil, mkZettel("public", webhook.KeyURL, "https://example.com/hook"), false, false},
+		{"private/public-writer", writer, mkZettel("private", meta.KeyVisibility, meta.ValueVisibilityPublic), false, false},
+		{"team/writer", writer, mkZettel("team"), true, true},
+		{"team/other", otherWriter, mkZettel("team"), true, false},
+	}
+	for _, tc := range testCases {
+		if got := pol.CanRead(tc.user, tc.m); got != tc.read {
+			t.Errorf("%v: CanRead should be %v, but got %v", tc.name, tc.read, got)
+		}
+		if got := pol.CanWrite(tc.user, tc.m, tc.m); got != tc.write {
+			t.Errorf("%v: CanWrite should be %v, but got %v", tc.name, tc.write, got)
+		}
+	}
+}
+
+type testAccessProvider map[string]*meta.Meta
+
+func (ap testAccessProvider) BoxAccess(name string) *meta.Meta { return ap[name] }
+
 type testAuthzManager struct {
 	readOnly bool
 	withAuth bool
@@ -86,8 +290,9 @@ func (a *testAuthzManager) GetUserRole(user *meta.Meta) meta.UserRole {
 
 type authConfig struct{ simple, expert bool }
 
-func (ac *authConfig) IsSimpleMode() bool { return ac.simple }
-func (ac *authConfig) IsExpertMode() bool { return ac.expert }
+func (ac *authConfig) IsSimpleMode() bool             { return ac.simple }
+func (ac *authConfig) IsExpertMode() bool             { return ac.expert }
+func (*authConfig) IsTOTPRequired(meta.UserRole) bool { return false }
 
 func (*authConfig) GetVisibility(m *meta.Meta) meta.Visibility {
 	if val, ok := m.Get(meta.KeyVisibility); ok {
@@ -501,6 +706,30 @@ func testRefresh(t *testing.T, pol auth.Policy, withAuth, simple, refresh bool)
 	}
 }
 
+func testShare(t *testing.T, pol auth.Policy, withAuth bool) {
+	t.Helper()
+	zettel := newZettel()
+	testCases := []struct {
+		user *meta.Meta
+		exp  bool
+	}{
+		{newAnon(), false},
+		{newCreator(), false},
+		{newReader(), false},
+		{newWriter(), withAuth},
+		{newOwner(), withAuth},
+		{newOwner2(), withAuth},
+	}
+	for _, tc := range testCases {
+		t.Run("Share", func(tt *testing.T) {
+			got := pol.CanShare(tc.user, zettel)
+			if tc.exp != got {
+				tt.Errorf("exp=%v, but got=%v", tc.exp, got)
+			}
+		})
+	}
+}
+
 const (
 	creatorZid = id.Zid(1013)
 	readerZid  = id.Zid(1013)
@@ -510,6 +739,8 @@ const (
 	zettelZid  = id.Zid(1021)
 	visZid     = id.Zid(1023)
 	userZid    = id.Zid(1025)
+	groupZid   = id.Zid(1027)
+	otherZid   = id.Zid(1029)
 )
 
 func newAnon() *meta.Meta { return nil }
@@ -0,0 +1,122 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+// ErrSessionRevoked signals that the session of a token was revoked.
+var ErrSessionRevoked = errors.New("auth: session revoked")
+
+// sessionRegistry stores all sessions that are known to be active, and all
+// sessions that were revoked since the start of the software. Sessions that
+// were started before are registered when their token is checked.
+type sessionRegistry struct {
+	mx           sync.Mutex
+	active       map[string]auth.SessionData
+	revoked      map[string]time.Time // session -> time of expiry
+	revokedUsers map[id.Zid]time.Time // user -> sessions issued not after are revoked
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{
+		active:       map[string]auth.SessionData{},
+		revoked:      map[string]time.Time{},
+		revokedUsers: map[id.Zid]time.Time{},
+	}
+}
+
+func newSessionID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// see registers the session of the given token, if it was not revoked.
+func (sr *sessionRegistry) see(tokenData *auth.TokenData, k auth.TokenKind) error {
+	sr.mx.Lock()
+	defer sr.mx.Unlock()
+	if _, revoked := sr.revoked[tokenData.Session]; revoked {
+		return ErrSessionRevoked
+	}
+	if revoked, found := sr.revokedUsers[tokenData.Zid]; found && !tokenData.Issued.After(revoked) {
+		return ErrSessionRevoked
+	}
+	sd, found := sr.active[tokenData.Session]
+	if !found {
+		sd = auth.SessionData{
+			Session: tokenData.Session,
+			Kind:    k,
+			Ident:   tokenData.Ident,
+			Zid:     tokenData.Zid,
+			Issued:  tokenData.Issued,
+		}
+	}
+	if sd.Expires.Before(tokenData.Expires) {
+		// Token was renewed
+		sd.Expires = tokenData.Expires
+	}
+	sd.LastSeen = tokenData.Now
+	sr.active[tokenData.Session] = sd
+	return nil
+}
+
+func (sr *sessionRegistry) sessions(now time.Time) []auth.SessionData {
+	sr.mx.Lock()
+	defer sr.mx.Unlock()
+	sr.cleanup(now)
+	result := slices.Collect(maps.Values(sr.active))
+	slices.SortFunc(result, func(a, b auth.SessionData) int {
+		return cmp.Or(cmp.Compare(a.Ident, b.Ident), b.LastSeen.Compare(a.LastSeen), cmp.Compare(a.Session, b.Session))
+	})
+	return result
+}
+
+func (sr *sessionRegistry) revoke(session string, expires, now time.Time) {
+	sr.mx.Lock()
+	defer sr.mx.Unlock()
+	delete(sr.active, session)
+	sr.revoked[session] = expires
+	sr.cleanup(now)
+}
+
+func (sr *sessionRegistry) revokeUser(zid id.Zid, issued, now time.Time) {
+	sr.mx.Lock()
+	defer sr.mx.Unlock()
+	for session, sd := range sr.active {
+		if sd.Zid == zid && !sd.Issued.After(issued) {
+			delete(sr.active, session)
+		}
+	}
+	sr.revokedUsers[zid] = issued
+	sr.cleanup(now)
+}
+
+// cleanup removes all data about expired sessions. The lock must be held.
+func (sr *sessionRegistry) cleanup(now time.Time) {
+	maps.DeleteFunc(sr.active, func(_ string, sd auth.SessionData) bool { return sd.Expires.Before(now) })
+	maps.DeleteFunc(sr.revoked, func(_ string, expires time.Time) bool { return expires.Before(now) })
+}
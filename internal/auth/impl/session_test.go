@@ -0,0 +1,80 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+func TestSessionRegistry(t *testing.T) {
+	t.Parallel()
+	now := time.Now().Round(time.Second)
+	sr := newSessionRegistry()
+	mkToken := func(session string, zid id.Zid, issued time.Time) *auth.TokenData {
+		return &auth.TokenData{
+			Now:     now,
+			Issued:  issued,
+			Expires: issued.Add(time.Hour),
+			Ident:   "user",
+			Zid:     zid,
+			Session: session,
+		}
+	}
+
+	tdA := mkToken("a", 7, now.Add(-time.Minute))
+	tdB := mkToken("b", 7, now)
+	tdC := mkToken("c", 8, now)
+	for _, td := range []*auth.TokenData{tdA, tdB, tdC} {
+		if err := sr.see(td, auth.KindwebUI); err != nil {
+			t.Fatalf("session %q: unexpected error %v", td.Session, err)
+		}
+	}
+	if got := len(sr.sessions(now)); got != 3 {
+		t.Errorf("expected 3 sessions, but got %d", got)
+	}
+
+	sr.revoke("c", tdC.Expires, now)
+	if err := sr.see(tdC, auth.KindwebUI); err != ErrSessionRevoked {
+		t.Errorf("revoked session c is still valid: %v", err)
+	}
+
+	sr.revokeUser(7, now.Add(-time.Second), now)
+	if err := sr.see(tdA, auth.KindwebUI); err != ErrSessionRevoked {
+		t.Errorf("session a of revoked user is still valid: %v", err)
+	}
+	if err := sr.see(tdB, auth.KindwebUI); err != nil {
+		t.Errorf("session b, started after revocation, is not valid: %v", err)
+	}
+	if sessions := sr.sessions(now); len(sessions) != 1 || sessions[0].Session != "b" {
+		t.Errorf("expected only session b, but got %v", sessions)
+	}
+
+	// A session issued within the second of revocation is revoked too.
+	sr.revokeUser(7, now, now)
+	if err := sr.see(tdB, auth.KindwebUI); err != ErrSessionRevoked {
+		t.Errorf("session b, issued within the second of revocation, is still valid: %v", err)
+	}
+	if err := sr.see(mkToken("d", 7, now.Add(time.Second)), auth.KindwebUI); err != nil {
+		t.Errorf("session d, issued after the second of revocation, is not valid: %v", err)
+	}
+
+	if sessions := sr.sessions(now.Add(2 * time.Hour)); len(sessions) != 0 {
+		t.Errorf("expired sessions are still active: %v", sessions)
+	}
+}
@@ -0,0 +1,74 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"time"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/sexp"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+// shareMarker starts the claim of every share token. Since the claim of an
+// authentication token starts with a number, one cannot be used for the other.
+const shareMarker = "share"
+
+// GetShareToken produces a signed token for the given share data.
+func (a *myAuth) GetShareToken(sd auth.ShareData) ([]byte, error) {
+	if !sd.Zid.IsValid() || !sd.Issuer.IsValid() {
+		return nil, ErrNoZid
+	}
+	transclusions := sx.Int64(0)
+	if sd.Transclusions {
+		transclusions = 1
+	}
+	sClaim := sx.MakeList(
+		sx.MakeString(shareMarker),
+		sx.Int64(sd.Zid),
+		sx.Int64(sd.Issuer),
+		sx.Int64(sd.Issued.Unix()),
+		sx.Int64(sd.Expires.Unix()),
+		transclusions,
+	)
+	return sign(sClaim, a.secret)
+}
+
+// CheckShareToken checks the validity of the token and returns its data.
+func (a *myAuth) CheckShareToken(token []byte) (auth.ShareData, error) {
+	obj, err := check(token, a.secret)
+	if err != nil {
+		return auth.ShareData{}, err
+	}
+	vals, err := sexp.ParseList(obj, "siiiii")
+	if err != nil || vals[0].(sx.String).GetValue() != shareMarker {
+		return auth.ShareData{}, ErrMalformedToken
+	}
+	sd := auth.ShareData{
+		Zid:           id.Zid(vals[1].(sx.Int64)),
+		Issuer:        id.Zid(vals[2].(sx.Int64)),
+		Issued:        time.Unix(int64(vals[3].(sx.Int64)), 0),
+		Expires:       time.Unix(int64(vals[4].(sx.Int64)), 0),
+		Transclusions: vals[5].(sx.Int64) != 0,
+	}
+	if !sd.Zid.IsValid() || !sd.Issuer.IsValid() {
+		return auth.ShareData{}, ErrNoZid
+	}
+	if sd.Expires.Before(time.Now().Round(time.Second)) {
+		return auth.ShareData{}, ErrTokenExpired
+	}
+	return sd, nil
+}
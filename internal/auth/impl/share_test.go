@@ -0,0 +1,73 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+func TestShareToken(t *testing.T) {
+	t.Parallel()
+	a := &myAuth{secret: []byte("secret"), sessions: newSessionRegistry()}
+	now := time.Now().Round(time.Second)
+	sd := auth.ShareData{
+		Zid:           zettelZid,
+		Issuer:        writerZid,
+		Issued:        now,
+		Expires:       now.Add(time.Hour),
+		Transclusions: true,
+	}
+	token, err := a.GetShareToken(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := a.CheckShareToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != sd {
+		t.Errorf("expected share data %v, but got %v", sd, got)
+	}
+
+	other := &myAuth{secret: []byte("other")}
+	if _, err = other.CheckShareToken(token); err == nil {
+		t.Error("share token with other secret is valid")
+	}
+
+	sd.Expires = now.Add(-time.Minute)
+	if token, err = a.GetShareToken(sd); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.CheckShareToken(token); err != ErrTokenExpired {
+		t.Errorf("expired share token: expected %v, but got %v", ErrTokenExpired, err)
+	}
+
+	user := meta.New(writerZid)
+	user.Set(meta.KeyUserID, "writer")
+	authToken, err := a.GetToken(user, time.Hour, auth.KindwebUI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.CheckShareToken(authToken); err != ErrMalformedToken {
+		t.Errorf("authentication token used as share token: expected %v, but got %v", ErrMalformedToken, err)
+	}
+	if _, err = a.CheckToken(token, auth.KindwebUI); err == nil {
+		t.Error("share token used as authentication token is valid")
+	}
+}
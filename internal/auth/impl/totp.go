@@ -0,0 +1,105 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+// Time-based one-time passwords, as specified in RFC 6238, with the default
+// parameters: HMAC-SHA1, time steps of 30 seconds, and six digits.
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpWindow = 1 // Number of time steps before and after the current one
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a new random secret for one-time passwords, encoded
+// as base32, as expected by most authenticator apps.
+func NewTOTPSecret() string {
+	var buf [20]byte
+	_, _ = rand.Read(buf[:])
+	return totpEncoding.EncodeToString(buf[:])
+}
+
+// TOTPURI returns an URI that allows authenticator apps to import the secret.
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	vals := url.Values{}
+	vals.Set("secret", secret)
+	vals.Set("issuer", issuer)
+	return "otpauth://totp/" + label + "?" + vals.Encode()
+}
+
+// CheckTOTP checks the given code against the secret. It returns the time
+// step of the code, to allow to reject a code that was already used.
+func CheckTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(key) == 0 {
+		return 0, false
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpWindow; step <= current+totpWindow; step++ {
+		if hmac.Equal([]byte(code), []byte(totpCode(key, step))) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+// NewRecoveryCodes returns some new random recovery codes, which can be used
+// once instead of a one-time password.
+func NewRecoveryCodes() []string {
+	codes := make([]string, 10)
+	for i := range codes {
+		var buf [5]byte
+		_, _ = rand.Read(buf[:])
+		code := strings.ToLower(totpEncoding.EncodeToString(buf[:]))
+		codes[i] = code[:4] + "-" + code[4:]
+	}
+	return codes
+}
+
+// HashRecoveryCode returns the hash of the given recovery code. The code is
+// normalized before, so that the user does not need to enter it exactly.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashPersonalTokenSecret(code)
+}
@@ -0,0 +1,59 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package impl
+
+import (
+	"testing"
+	"time"
+)
+
+// Test vectors from RFC 6238, appendix B, for SHA1, truncated to six digits.
+func TestCheckTOTP(t *testing.T) {
+	t.Parallel()
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // "12345678901234567890"
+	testcases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range testcases {
+		now := time.Unix(tc.unix, 0)
+		step, ok := CheckTOTP(secret, tc.code, now)
+		if !ok {
+			t.Errorf("code %q at %d not accepted", tc.code, tc.unix)
+			continue
+		}
+		if exp := tc.unix / totpPeriod; step != exp {
+			t.Errorf("code %q at %d: expected step %d, but got %d", tc.code, tc.unix, exp, step)
+		}
+		if _, ok = CheckTOTP(secret, tc.code, now.Add(5*time.Minute)); ok {
+			t.Errorf("code %q accepted five minutes later", tc.code)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	t.Parallel()
+	codes := NewRecoveryCodes()
+	for _, code := range codes {
+		if got, exp := HashRecoveryCode(" "+code[:4]+code[5:]+" "), HashRecoveryCode(code); got != exp {
+			t.Errorf("code %q not normalized", code)
+		}
+	}
+}
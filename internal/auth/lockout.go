@@ -0,0 +1,60 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+import "time"
+
+// LockoutManager tracks failed login attempts per account and per remote
+// address. After a failed attempt, the next attempt is delayed exponentially.
+// After too many failed attempts, the account or address is locked for some
+// time.
+type LockoutManager interface {
+	// CheckLogin returns the time to wait until a login attempt for the given
+	// account from the given remote address is allowed. A zero value allows
+	// the attempt.
+	CheckLogin(ident, remote string) time.Duration
+
+	// LoginFailed registers a failed login attempt.
+	LoginFailed(ident, remote string)
+
+	// LoginSucceeded forgets all failed login attempts of the account. Failed
+	// attempts of the remote address are kept, because they may be directed
+	// to other accounts.
+	LoginSucceeded(ident string)
+
+	// Lockouts returns all accounts and remote addresses that are locked.
+	Lockouts() []LockoutData
+
+	// Unlock removes the lock and all failed attempts of the given account or
+	// remote address. It returns false, if nothing was found.
+	Unlock(key string) bool
+}
+
+// LockoutKind specifies whether an account or a remote address is locked.
+type LockoutKind string
+
+// Values for LockoutKind.
+const (
+	LockoutAccount LockoutKind = "account"
+	LockoutAddress LockoutKind = "address"
+)
+
+// LockoutData describes a locked account or remote address.
+type LockoutData struct {
+	Kind        LockoutKind
+	Key         string // Ident of account, or remote address
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
@@ -0,0 +1,340 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+// Package oidc implements the authorization code flow of OpenID Connect,
+// secured by PKCE, to authenticate users by an external identity provider.
+//
+// Only the standard library is used. The provider is discovered via its
+// well-known configuration document, ID tokens must be signed with RS256 or
+// ES256.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config contains all data to access an OpenID Connect provider.
+type Config struct {
+	Issuer       string   // URL of the provider, without "/.well-known/..."
+	ClientID     string   // Client identifier, as registered at the provider
+	ClientSecret string   // Client secret, empty for public clients
+	RedirectURL  string   // URL the provider redirects to after login
+	Scopes       []string // Additional scopes, "openid" is always requested
+	IdentClaim   string   // Claim that contains the user identification
+	NameClaim    string   // Claim that contains the name of the user
+	RoleClaim    string   // Claim that contains the roles / groups, may be empty
+	Roles        []RoleMapping
+}
+
+// RoleMapping maps a value of the role claim to a user role.
+type RoleMapping struct {
+	Value string
+	Role  string
+}
+
+// Default values of the configuration.
+const (
+	DefaultIdentClaim = "sub"
+	DefaultNameClaim  = "name"
+)
+
+// User contains the data about an authenticated user, as claimed by the
+// provider. Only the issuer and the subject identify the user reliably,
+// because the provider never reassigns them.
+type User struct {
+	Issuer  string // Issuer of the ID token
+	Subject string // Identification of the user at the issuer
+	Ident   string // User identification, used for a new user zettel
+	Name    string // Name of the user, may be empty
+	Role    string // Mapped user role, empty if no role claim is configured
+}
+
+// Errors returned by the client.
+var (
+	ErrUnknownState = errors.New("unknown or expired login state")
+	ErrNoIdent      = errors.New("no user identification claimed")
+	ErrNoRole       = errors.New("no user role claimed")
+	ErrTooManyLogin = errors.New("too many pending logins")
+)
+
+// Client performs the authorization code flow with an OpenID Connect provider.
+type Client struct {
+	cfg    Config
+	client *http.Client
+
+	mxProvider sync.Mutex
+	provider   *providerData
+	keys       map[string]any
+	keysTime   time.Time
+
+	mxPending sync.Mutex
+	pending   map[string]pendingLogin
+}
+
+// Some limits of the client.
+const (
+	requestTimeout   = 10 * time.Second
+	pendingLifetime  = 10 * time.Minute
+	maxPendingLogins = 4096
+	minKeysRefresh   = time.Minute
+	maxResponseSize  = 1 << 20
+)
+
+// NewClient creates a new client for the given configuration. If the HTTP
+// client is nil, a client with a request timeout is used.
+func NewClient(cfg Config, client *http.Client) *Client {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if cfg.IdentClaim == "" {
+		cfg.IdentClaim = DefaultIdentClaim
+	}
+	if cfg.NameClaim == "" {
+		cfg.NameClaim = DefaultNameClaim
+	}
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+	return &Client{
+		cfg:     cfg,
+		client:  client,
+		pending: map[string]pendingLogin{},
+	}
+}
+
+// providerData is the relevant part of the discovery document.
+type providerData struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// pendingLogin stores the secrets of a login that was started, but was not
+// completed yet.
+type pendingLogin struct {
+	nonce    string
+	verifier string
+	created  time.Time
+}
+
+// AuthCodeURL starts a new login. It returns the URL of the provider the user
+// must be redirected to, and the state value that identifies the login. The
+// state value should be bound to the user agent, e.g. by a cookie.
+func (c *Client) AuthCodeURL(ctx context.Context) (string, string, error) {
+	pd, err := c.getProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	state, nonce, verifier := randomString(), randomString(), randomString()
+	if err = c.addPending(state, pendingLogin{nonce: nonce, verifier: verifier, created: time.Now()}); err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	scopes := []string{"openid"}
+	for _, scope := range c.cfg.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	u, err := url.Parse(pd.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), state, nil
+}
+
+func (c *Client) addPending(state string, pl pendingLogin) error {
+	c.mxPending.Lock()
+	defer c.mxPending.Unlock()
+	for s, p := range c.pending {
+		if time.Since(p.created) > pendingLifetime {
+			delete(c.pending, s)
+		}
+	}
+	if len(c.pending) >= maxPendingLogins {
+		return ErrTooManyLogin
+	}
+	c.pending[state] = pl
+	return nil
+}
+
+func (c *Client) removePending(state string) (pendingLogin, bool) {
+	c.mxPending.Lock()
+	defer c.mxPending.Unlock()
+	pl, found := c.pending[state]
+	if !found {
+		return pendingLogin{}, false
+	}
+	delete(c.pending, state)
+	return pl, time.Since(pl.created) <= pendingLifetime
+}
+
+// Exchange completes the login identified by the state value. The code was
+// sent by the provider. It returns the user data claimed by the provider.
+func (c *Client) Exchange(ctx context.Context, state, code string) (User, error) {
+	pl, ok := c.removePending(state)
+	if !ok {
+		return User{}, ErrUnknownState
+	}
+	pd, err := c.getProvider(ctx)
+	if err != nil {
+		return User{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("code_verifier", pl.verifier)
+	if c.cfg.ClientSecret == "" {
+		form.Set("client_id", c.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pd.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return User{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = c.doJSON(req, &tokenResp); err != nil {
+		return User{}, fmt.Errorf("token request: %w", err)
+	}
+	if tokenResp.IDToken == "" {
+		return User{}, fmt.Errorf("%w: no id_token received", ErrInvalidToken)
+	}
+	claims, err := c.verifyIDToken(ctx, pd, tokenResp.IDToken, pl.nonce, time.Now())
+	if err != nil {
+		return User{}, err
+	}
+	return c.mapClaims(claims)
+}
+
+// mapClaims maps the claims of an ID token to user data.
+func (c *Client) mapClaims(claims map[string]any) (User, error) {
+	issuer, _ := claims["iss"].(string)
+	subject, _ := claims["sub"].(string)
+	ident, _ := claims[c.cfg.IdentClaim].(string)
+	if subject == "" || ident == "" {
+		return User{}, ErrNoIdent
+	}
+	name, _ := claims[c.cfg.NameClaim].(string)
+	user := User{Issuer: issuer, Subject: subject, Ident: ident, Name: name}
+	if c.cfg.RoleClaim == "" {
+		return user, nil
+	}
+	values := claimValues(claims[c.cfg.RoleClaim])
+	for _, rm := range c.cfg.Roles {
+		if slices.Contains(values, rm.Value) {
+			user.Role = rm.Role
+			return user, nil
+		}
+	}
+	return User{}, ErrNoRole
+}
+
+// claimValues returns the string values of a claim, which is either a string
+// or an array of strings.
+func claimValues(claim any) []string {
+	switch val := claim.(type) {
+	case string:
+		return strings.Fields(val)
+	case []any:
+		result := make([]string, 0, len(val))
+		for _, elem := range val {
+			if s, ok := elem.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// getProvider returns the data of the discovery document. It is retrieved
+// on first use.
+func (c *Client) getProvider(ctx context.Context) (*providerData, error) {
+	c.mxProvider.Lock()
+	defer c.mxProvider.Unlock()
+	if c.provider != nil {
+		return c.provider, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var pd providerData
+	if err = c.doJSON(req, &pd); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if pd.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("discovery: issuer %q does not match %q", pd.Issuer, c.cfg.Issuer)
+	}
+	if pd.AuthorizationEndpoint == "" || pd.TokenEndpoint == "" || pd.JWKSURI == "" {
+		return nil, errors.New("discovery: missing endpoint")
+	}
+	c.provider = &pd
+	return c.provider, nil
+}
+
+// doJSON executes the request and decodes the JSON response into the value.
+func (c *Client) doJSON(req *http.Request, v any) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", req.URL.Redacted(), resp.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// randomString returns a string of 32 random bytes, suitable for the state,
+// nonce, and PKCE code verifier.
+func randomString() string {
+	var buf [32]byte
+	_, _ = rand.Read(buf[:]) // Never returns an error
+	return base64.RawURLEncoding.EncodeToString(buf[:])
+}
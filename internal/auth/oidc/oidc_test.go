@@ -0,0 +1,311 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package oidc_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"zettelstore.de/z/internal/auth/oidc"
+)
+
+// testProvider is a minimal OpenID Connect provider.
+type testProvider struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    crypto.Signer
+	alg    string
+	claims map[string]any // Additional claims of the next ID token
+
+	mx    sync.Mutex
+	codes map[string]authRequest
+}
+
+type authRequest struct {
+	challenge string
+	nonce     string
+}
+
+const (
+	testClientID = "zettelstore"
+	testRedirect = "https://zettel.example/i"
+)
+
+func newTestProvider(t *testing.T, alg string) *testProvider {
+	t.Helper()
+	p := &testProvider{t: t, alg: alg, codes: map[string]authRequest{}}
+	var err error
+	switch alg {
+	case "RS256":
+		p.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		p.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /keys", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+func (p *testProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 p.srv.URL,
+		"authorization_endpoint": p.srv.URL + "/authorize",
+		"token_endpoint":         p.srv.URL + "/token",
+		"jwks_uri":               p.srv.URL + "/keys",
+	})
+}
+
+func (p *testProvider) jwks(w http.ResponseWriter, _ *http.Request) {
+	enc := base64.RawURLEncoding.EncodeToString
+	var jwk map[string]string
+	switch pub := p.key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk = map[string]string{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": enc(pub.N.Bytes()), "e": enc(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		data, _ := pub.Bytes()
+		jwk = map[string]string{
+			"kty": "EC", "kid": "k1", "crv": "P-256",
+			"x": enc(data[1:33]), "y": enc(data[33:]),
+		}
+	}
+	writeJSON(w, map[string]any{"keys": []any{jwk}})
+}
+
+// authorize simulates the login of a user at the provider. It returns the
+// code that is sent to the client.
+func (p *testProvider) authorize(authURL string) (state, code string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	q := u.Query()
+	if got := q.Get("code_challenge_method"); got != "S256" {
+		p.t.Errorf("code_challenge_method = %q", got)
+	}
+	if got := q.Get("client_id"); got != testClientID {
+		p.t.Errorf("client_id = %q", got)
+	}
+	if got := q.Get("redirect_uri"); got != testRedirect {
+		p.t.Errorf("redirect_uri = %q", got)
+	}
+	code = rand.Text()
+	p.mx.Lock()
+	p.codes[code] = authRequest{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	p.mx.Unlock()
+	return q.Get("state"), code
+}
+
+func (p *testProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mx.Lock()
+	ar, found := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mx.Unlock()
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !found || base64.RawURLEncoding.EncodeToString(challenge[:]) != ar.challenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	claims := map[string]any{
+		"iss":                p.srv.URL,
+		"aud":                testClientID,
+		"sub":                "1234",
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              ar.nonce,
+		"preferred_username": "alice",
+		"name":               "Alice Example",
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	writeJSON(w, map[string]string{"id_token": p.sign(claims), "token_type": "Bearer"})
+}
+
+func (p *testProvider) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": p.alg, "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	enc := base64.RawURLEncoding.EncodeToString
+	signed := enc(header) + "." + enc(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	var err error
+	switch key := p.key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		if r, s, err = ecdsa.Sign(rand.Reader, key, digest[:]); err == nil {
+			sig = make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+		}
+	}
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return signed + "." + enc(sig)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (p *testProvider) newClient(cfg oidc.Config) *oidc.Client {
+	cfg.Issuer = p.srv.URL
+	cfg.ClientID = testClientID
+	cfg.RedirectURL = testRedirect
+	return oidc.NewClient(cfg, p.srv.Client())
+}
+
+func (p *testProvider) login(client *oidc.Client) (oidc.User, error) {
+	ctx := context.Background()
+	authURL, state, err := client.AuthCodeURL(ctx)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	gotState, code := p.authorize(authURL)
+	if gotState != state {
+		p.t.Fatalf("state = %q, but AuthCodeURL returned %q", gotState, state)
+	}
+	return client.Exchange(ctx, state, code)
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+	for _, alg := range []string{"RS256", "ES256"} {
+		t.Run(alg, func(t *testing.T) {
+			t.Parallel()
+			p := newTestProvider(t, alg)
+			user, err := p.login(p.newClient(oidc.Config{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp := oidc.User{Issuer: p.srv.URL, Subject: "1234", Ident: "1234", Name: "Alice Example"}
+			if user != exp {
+				t.Errorf("user = %v, but expected %v", user, exp)
+			}
+		})
+	}
+}
+
+func TestStateUsedOnce(t *testing.T) {
+	t.Parallel()
+	p := newTestProvider(t, "ES256")
+	client := p.newClient(oidc.Config{})
+	ctx := context.Background()
+	authURL, state, err := client.AuthCodeURL(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, code := p.authorize(authURL)
+	if _, err = client.Exchange(ctx, "other", code); !errors.Is(err, oidc.ErrUnknownState) {
+		t.Errorf("unknown state: got error %v", err)
+	}
+	if _, err = client.Exchange(ctx, state, code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Exchange(ctx, state, code); !errors.Is(err, oidc.ErrUnknownState) {
+		t.Errorf("second use of state: got error %v", err)
+	}
+}
+
+func TestInvalidClaims(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name   string
+		claims map[string]any
+		err    error
+	}{
+		{"audience", map[string]any{"aud": "other"}, oidc.ErrInvalidToken},
+		{"issuer", map[string]any{"iss": "https://evil.example"}, oidc.ErrInvalidToken},
+		{"expired", map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}, oidc.ErrInvalidToken},
+		{"nonce", map[string]any{"nonce": "replayed"}, oidc.ErrInvalidToken},
+		{"subject", map[string]any{"sub": ""}, oidc.ErrNoIdent},
+		{"subject-type", map[string]any{"sub": 1234}, oidc.ErrNoIdent},
+	}
+	p := newTestProvider(t, "ES256")
+	client := p.newClient(oidc.Config{})
+	for _, tc := range testcases {
+		p.claims = tc.claims
+		if _, err := p.login(client); !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v, but got %v", tc.name, tc.err, err)
+		}
+	}
+	p.claims = map[string]any{"preferred_username": ""}
+	if _, err := p.login(p.newClient(oidc.Config{IdentClaim: "preferred_username"})); !errors.Is(err, oidc.ErrNoIdent) {
+		t.Errorf("ident: expected error %v, but got %v", oidc.ErrNoIdent, err)
+	}
+}
+
+func TestRoleMapping(t *testing.T) {
+	t.Parallel()
+	p := newTestProvider(t, "ES256")
+	client := p.newClient(oidc.Config{
+		IdentClaim: "email",
+		RoleClaim:  "groups",
+		Roles: []oidc.RoleMapping{
+			{Value: "zs-writer", Role: "writer"},
+			{Value: "staff", Role: "reader"},
+		},
+	})
+	testcases := []struct {
+		groups any
+		role   string
+		err    error
+	}{
+		{[]string{"staff", "zs-writer"}, "writer", nil},
+		{[]string{"staff"}, "reader", nil},
+		{"staff other", "reader", nil},
+		{[]string{"other"}, "", oidc.ErrNoRole},
+		{nil, "", oidc.ErrNoRole},
+	}
+	for _, tc := range testcases {
+		p.claims = map[string]any{"email": "alice@example.com", "groups": tc.groups}
+		user, err := p.login(client)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%v: expected error %v, but got %v", tc.groups, tc.err, err)
+			continue
+		}
+		if err == nil && (user.Subject != "1234" || user.Ident != "alice@example.com" || user.Role != tc.role) {
+			t.Errorf("%v: got user %v, but expected role %q", tc.groups, user, tc.role)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+// Metadata keys of a user zettel to identify a user that is authenticated by
+// an OpenID Connect provider. A user is not allowed to change them.
+const (
+	KeyOIDCIssuer  = "oidc-issuer"  // URL of the provider
+	KeyOIDCSubject = "oidc-subject" // Identification of the user at the provider
+)
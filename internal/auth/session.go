@@ -0,0 +1,89 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+)
+
+// Revoked sessions are stored in the user zettel, so that a revocation
+// survives a restart of the software. Every revoked session is stored with
+// the time its token expires. After that time, the entry is not needed any
+// more. If all sessions of a user were revoked, the time of revocation is
+// stored:
+//
+//	revoked-session-1a2b3c4d5e6f7a8b: 20261018230000
+//	sessions-revoked: 20261018220000
+
+// KeyPrefixRevokedSession is the prefix of all metadata keys that store a
+// revoked session.
+const KeyPrefixRevokedSession = "revoked-session-"
+
+// KeySessionsRevoked stores the time, when all sessions of a user were
+// revoked.
+const KeySessionsRevoked = "sessions-revoked"
+
+// SessionData describes an active session.
+type SessionData struct {
+	Session  string
+	Kind     TokenKind
+	Ident    string
+	Zid      id.Zid
+	Issued   time.Time
+	Expires  time.Time
+	LastSeen time.Time
+}
+
+// IsSessionRevoked returns true, if the session of the given token was
+// revoked, according to the data stored in the user zettel.
+func IsSessionRevoked(user *meta.Meta, tokenData *TokenData) bool {
+	if val, found := user.Get(KeySessionsRevoked); found {
+		if revoked, ok := val.AsTime(); ok && !tokenData.Issued.After(revoked) {
+			return true
+		}
+	}
+	_, found := user.Get(KeyPrefixRevokedSession + tokenData.Session)
+	return found
+}
+
+// RevokeSession stores the revocation of a session, which expires at the
+// given time, in the user zettel metadata. Revocations of already expired
+// sessions are removed.
+func RevokeSession(m *meta.Meta, session string, expires, now time.Time) {
+	for key, val := range m.Clone().All() {
+		if strings.HasPrefix(key, KeyPrefixRevokedSession) {
+			if t, ok := val.AsTime(); !ok || !t.After(now) {
+				m.Delete(key)
+			}
+		}
+	}
+	if expires.After(now) {
+		m.Set(KeyPrefixRevokedSession+session, meta.Value(expires.Local().Format(id.TimestampLayout)))
+	}
+}
+
+// RevokeAllSessions stores the revocation of all sessions, which were issued
+// not after the given time, in the user zettel metadata.
+func RevokeAllSessions(m *meta.Meta, now time.Time) {
+	for key := range m.Clone().All() {
+		if strings.HasPrefix(key, KeyPrefixRevokedSession) {
+			m.Delete(key)
+		}
+	}
+	m.Set(KeySessionsRevoked, meta.Value(now.Local().Format(id.TimestampLayout)))
+}
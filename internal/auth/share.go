@@ -0,0 +1,39 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+import (
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+)
+
+// ShareManager creates and checks share tokens. A share token allows to read
+// one zettel without an account, until the token expires.
+type ShareManager interface {
+	// GetShareToken produces a signed token for the given share data.
+	GetShareToken(sd ShareData) ([]byte, error)
+
+	// CheckShareToken checks the validity of the token and returns its data.
+	CheckShareToken(token []byte) (ShareData, error)
+}
+
+// ShareData contains all data of a share token.
+type ShareData struct {
+	Zid           id.Zid // Shared zettel
+	Issuer        id.Zid // User who created the token
+	Issued        time.Time
+	Expires       time.Time
+	Transclusions bool // Transcluded zettel are shown too
+}
@@ -0,0 +1,199 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+)
+
+// Personal access tokens are stored in the user zettel, one metadata key for
+// every token. The value contains the hash of the token secret, the time of
+// expiry, the scope, the allowed roles ("-" if all roles are allowed), and the
+// name of the token:
+//
+//	personal-token-1a2b3c4d: HASH 20270101120000 read project,literature CI job
+
+// KeyPrefixPersonalToken is the prefix of all metadata keys that store a
+// personal access token.
+const KeyPrefixPersonalToken = "personal-token-"
+
+// Metadata keys that restrict the current user, if it was authenticated with a
+// personal access token. They are only set for the user metadata stored in the
+// context, never in a user zettel.
+const (
+	KeyTokenScope = "token-scope" // Value is TokenScopeRead or TokenScopeWrite
+	KeyTokenRoles = "token-roles" // Only zettel with one of these roles are accessible
+)
+
+// Values for KeyTokenScope.
+const (
+	TokenScopeRead  = "read"
+	TokenScopeWrite = "write"
+)
+
+// personalTokenPrefix starts every personal access token, to distinguish it
+// from a token produced by the TokenManager.
+const personalTokenPrefix = "zspat_"
+
+// PersonalToken describes a personal access token.
+type PersonalToken struct {
+	ID       string // Identifies the token within the user zettel
+	Name     string
+	Hash     string // Hash of the secret part of the token
+	Expires  time.Time
+	ReadOnly bool
+	Roles    []string // If not empty, only zettel with these roles are accessible
+}
+
+// Key returns the metadata key to store the token.
+func (pt *PersonalToken) Key() string { return KeyPrefixPersonalToken + pt.ID }
+
+// Value returns the metadata value to store the token.
+func (pt *PersonalToken) Value() meta.Value {
+	scope := TokenScopeWrite
+	if pt.ReadOnly {
+		scope = TokenScopeRead
+	}
+	roles := "-"
+	if len(pt.Roles) > 0 {
+		roles = strings.Join(pt.Roles, ",")
+	}
+	return meta.Value(strings.Join([]string{
+		pt.Hash, pt.Expires.Local().Format(id.TimestampLayout), scope, roles, pt.Name}, " "))
+}
+
+// IsExpired returns true, if the token cannot be used anymore.
+func (pt *PersonalToken) IsExpired(now time.Time) bool { return !now.Before(pt.Expires) }
+
+// GetPersonalToken returns the personal access token with the given
+// identifier, stored in the given user zettel metadata.
+func GetPersonalToken(m *meta.Meta, tokenID string) (PersonalToken, bool) {
+	val, found := m.Get(KeyPrefixPersonalToken + tokenID)
+	if !found {
+		return PersonalToken{}, false
+	}
+	return parsePersonalToken(tokenID, val)
+}
+
+// GetPersonalTokens returns all personal access tokens stored in the given
+// user zettel metadata, sorted by their time of expiry.
+func GetPersonalTokens(m *meta.Meta) []PersonalToken {
+	var result []PersonalToken
+	for key, val := range m.All() {
+		if tokenID, isToken := strings.CutPrefix(key, KeyPrefixPersonalToken); isToken {
+			if pt, ok := parsePersonalToken(tokenID, val); ok {
+				result = append(result, pt)
+			}
+		}
+	}
+	slices.SortFunc(result, func(a, b PersonalToken) int {
+		return cmp.Or(a.Expires.Compare(b.Expires), strings.Compare(a.ID, b.ID))
+	})
+	return result
+}
+
+func parsePersonalToken(tokenID string, val meta.Value) (PersonalToken, bool) {
+	fields := strings.SplitN(string(val), " ", 5)
+	if len(fields) < 4 || fields[0] == "" {
+		return PersonalToken{}, false
+	}
+	expires, ok := meta.Value(fields[1]).AsTime()
+	if !ok {
+		return PersonalToken{}, false
+	}
+	pt := PersonalToken{ID: tokenID, Hash: fields[0], Expires: expires}
+	switch fields[2] {
+	case TokenScopeRead:
+		pt.ReadOnly = true
+	case TokenScopeWrite:
+	default:
+		return PersonalToken{}, false
+	}
+	if roles := fields[3]; roles != "-" {
+		pt.Roles = strings.Split(roles, ",")
+	}
+	if len(fields) > 4 {
+		pt.Name = fields[4]
+	}
+	return pt, true
+}
+
+// MakePersonalToken returns the token that is given to the user. It contains
+// the zettel identifier of the user zettel, the token identifier, and the
+// secret.
+func MakePersonalToken(zid id.Zid, tokenID, secret string) []byte {
+	return []byte(personalTokenPrefix + zid.String() + "_" + tokenID + "_" + secret)
+}
+
+// IsPersonalToken returns true, if the given token is a personal access token.
+func IsPersonalToken(token []byte) bool {
+	return bytes.HasPrefix(token, []byte(personalTokenPrefix))
+}
+
+// SplitPersonalToken returns the zettel identifier of the user zettel, the
+// token identifier, and the secret of a personal access token.
+func SplitPersonalToken(token []byte) (id.Zid, string, string, bool) {
+	rest, found := bytes.CutPrefix(token, []byte(personalTokenPrefix))
+	if !found {
+		return id.Invalid, "", "", false
+	}
+	fields := strings.SplitN(string(rest), "_", 3)
+	if len(fields) != 3 || fields[1] == "" || fields[2] == "" {
+		return id.Invalid, "", "", false
+	}
+	zid, err := id.Parse(fields[0])
+	if err != nil {
+		return id.Invalid, "", "", false
+	}
+	return zid, fields[1], fields[2], true
+}
+
+// ScopeUser returns a copy of the user metadata, restricted by the scope of
+// the given personal access token.
+func ScopeUser(user *meta.Meta, pt *PersonalToken) *meta.Meta {
+	m := user.Clone()
+	for key := range user.All() {
+		if strings.HasPrefix(key, KeyPrefixPersonalToken) {
+			m.Delete(key)
+		}
+	}
+	if pt.ReadOnly {
+		m.Set(KeyTokenScope, TokenScopeRead)
+	} else {
+		m.Set(KeyTokenScope, TokenScopeWrite)
+	}
+	if len(pt.Roles) > 0 {
+		m.Set(KeyTokenRoles, meta.Value(strings.Join(pt.Roles, " ")))
+	} else {
+		m.Delete(KeyTokenRoles)
+	}
+	return m
+}
+
+// IsTokenUser returns true, if the user was authenticated with a personal
+// access token.
+func IsTokenUser(user *meta.Meta) bool {
+	if user == nil {
+		return false
+	}
+	_, found := user.Get(KeyTokenScope)
+	return found
+}
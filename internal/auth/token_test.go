@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+)
+
+func TestParsePersonalToken(t *testing.T) {
+	t.Parallel()
+	expires := time.Date(2027, 1, 1, 12, 0, 0, 0, time.Local)
+	testcases := []struct {
+		val meta.Value
+		ok  bool
+		exp PersonalToken
+	}{
+		{"HASH 20270101120000 read project,literature CI job", true,
+			PersonalToken{Name: "CI job", Hash: "HASH", Expires: expires, ReadOnly: true, Roles: []string{"project", "literature"}}},
+		{"HASH 20270101120000 write -", true, PersonalToken{Hash: "HASH", Expires: expires}},
+		{"HASH 20270101120000 write - name", true, PersonalToken{Name: "name", Hash: "HASH", Expires: expires}},
+		{"", false, PersonalToken{}},
+		{"HASH 20270101120000 read", false, PersonalToken{}},
+		{" 20270101120000 read - name", false, PersonalToken{}},
+		{"HASH 2027 read - name", false, PersonalToken{}},
+		{"HASH expires read - name", false, PersonalToken{}},
+		{"HASH 20270101120000 admin - name", false, PersonalToken{}},
+	}
+	for _, tc := range testcases {
+		t.Run(string(tc.val), func(t *testing.T) {
+			got, ok := parsePersonalToken("1a2b", tc.val)
+			if ok != tc.ok {
+				t.Fatalf("expected ok=%v, but got %v (%v)", tc.ok, ok, got)
+			}
+			if !ok {
+				return
+			}
+			tc.exp.ID = "1a2b"
+			if got.ID != tc.exp.ID || got.Name != tc.exp.Name || got.Hash != tc.exp.Hash ||
+				!got.Expires.Equal(tc.exp.Expires) || got.ReadOnly != tc.exp.ReadOnly || !slices.Equal(got.Roles, tc.exp.Roles) {
+				t.Errorf("expected %v, but got %v", tc.exp, got)
+			}
+			if val := got.Value(); val != tc.val {
+				t.Errorf("token value %q differs from %q", val, tc.val)
+			}
+		})
+	}
+}
+
+func TestSplitPersonalToken(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		token   string
+		ok      bool
+		zid     id.Zid
+		tokenID string
+		secret  string
+	}{
+		{"zspat_00000000000013_1a2b_SECRET", true, 13, "1a2b", "SECRET"},
+		{"zspat_00000000000013_1a2b_SEC_RET", true, 13, "1a2b", "SEC_RET"},
+		{"", false, id.Invalid, "", ""},
+		{"00000000000013_1a2b_SECRET", false, id.Invalid, "", ""},
+		{"zspat_00000000000013_1a2b", false, id.Invalid, "", ""},
+		{"zspat_00000000000013__SECRET", false, id.Invalid, "", ""},
+		{"zspat_00000000000013_1a2b_", false, id.Invalid, "", ""},
+		{"zspat_13x_1a2b_SECRET", false, id.Invalid, "", ""},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.token, func(t *testing.T) {
+			zid, tokenID, secret, ok := SplitPersonalToken([]byte(tc.token))
+			if ok != tc.ok || zid != tc.zid || tokenID != tc.tokenID || secret != tc.secret {
+				t.Errorf("expected (%v, %q, %q, %v), but got (%v, %q, %q, %v)",
+					tc.zid, tc.tokenID, tc.secret, tc.ok, zid, tokenID, secret, ok)
+			}
+			if ok {
+				if got := string(MakePersonalToken(zid, tokenID, secret)); got != tc.token {
+					t.Errorf("made token %q differs from %q", got, tc.token)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package auth
+
+import "t73f.de/r/zsc/domain/meta"
+
+// Metadata keys of a user zettel to store the data for time-based one-time
+// passwords (TOTP). A user is not allowed to change them directly.
+const (
+	KeyTOTPSecret   = "totp-secret"   // Base32 encoded secret
+	KeyTOTPRecovery = "totp-recovery" // Hash values of the unused recovery codes
+)
+
+// HasTOTP returns true, if the user must authenticate with a one-time password.
+func HasTOTP(user *meta.Meta) bool {
+	secret, found := user.Get(KeyTOTPSecret)
+	return found && secret != ""
+}
@@ -24,9 +24,11 @@ import (
 type UserData struct {
 	User    *meta.Meta
 	Token   []byte
+	Kind    TokenKind
 	Now     time.Time
 	Issued  time.Time
 	Expires time.Time
+	Session string
 }
 
 // GetAuthData returns the full authentication data from the context.
@@ -61,8 +63,30 @@ func UpdateContext(ctx context.Context, user *meta.Meta, data *TokenData) contex
 		&UserData{
 			User:    user,
 			Token:   data.Token,
+			Kind:    data.Kind,
 			Now:     data.Now,
 			Issued:  data.Issued,
 			Expires: data.Expires,
+			Session: data.Session,
 		})
 }
+
+// ctxKeyTypeRemote is just an additional type to make context value retrieval unambiguous.
+type ctxKeyTypeRemote struct{}
+
+// UpdateContextRemote enriches the given context with the address of the
+// client. Only the web server knows, whether forwarding headers are trusted.
+func UpdateContextRemote(ctx context.Context, remote string) context.Context {
+	return context.WithValue(ctx, ctxKeyTypeRemote{}, remote)
+}
+
+// GetRemoteAddr returns the address of the client, as stored in the context,
+// or the empty string.
+func GetRemoteAddr(ctx context.Context) string {
+	if ctx != nil {
+		if remote, ok := ctx.Value(ctxKeyTypeRemote{}).(string); ok {
+			return remote
+		}
+	}
+	return ""
+}
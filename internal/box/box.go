@@ -142,6 +142,13 @@ type StartStopper interface {
 	Stop(ctx context.Context)
 }
 
+// LayeredBox is a box that may store more than one version of a zettel.
+type LayeredBox interface {
+	// GetAllZettel retrieves all versions of a zettel, starting with the
+	// visible one. The metadata of each version is already enriched.
+	GetAllZettel(ctx context.Context, zid id.Zid) []Zettel
+}
+
 // Refresher allow refreshing their internal data.
 type Refresher interface {
 	// Refresh the box data.
@@ -173,6 +180,29 @@ type Box interface {
 
 	// ReIndex one zettel to update its index data.
 	ReIndex(context.Context, id.Zid) error
+
+	// CheckIndex compares the index data with the zettel of all boxes and
+	// returns all inconsistencies. If repair is true, the index data of the
+	// inconsistent zettel is updated.
+	CheckIndex(ctx context.Context, repair bool) ([]IndexProblem, error)
+}
+
+// IndexProblemKind specifies the kind of an inconsistency of the index.
+type IndexProblemKind string
+
+// Values for IndexProblemKind
+const (
+	IndexMissing  IndexProblemKind = "missing"  // Zettel is not indexed
+	IndexStale    IndexProblemKind = "stale"    // Index data does not match the zettel
+	IndexDangling IndexProblemKind = "dangling" // Reference is only stored on one side
+	IndexInverse  IndexProblemKind = "inverse"  // Inverse metadata does not match
+)
+
+// IndexProblem describes one inconsistency of the index data of a zettel.
+type IndexProblem struct {
+	Kind   IndexProblemKind
+	Zid    id.Zid
+	Detail string
 }
 
 // Stats record stattistics about a box.
@@ -218,6 +248,10 @@ type Manager interface {
 	// ReadStats populates st with box statistics
 	ReadStats(st *Stats)
 
+	// IsStoredInFirstBox returns true, if the zettel is stored in the box that
+	// stores new and updated zettel, and not only in one of the other boxes.
+	IsStoredInFirstBox(ctx context.Context, zid id.Zid) bool
+
 	// Dump internal data to a Writer.
 	Dump(w io.Writer)
 }
@@ -232,13 +266,15 @@ const (
 	OnReload              // Box was reloaded
 	OnZettel              // Something with an existing zettel happened
 	OnDelete              // A zettel was deleted
+	OnCreate              // A zettel was created; observers get OnZettel with Created set
 )
 
 // UpdateInfo contains all the data about a changed zettel.
 type UpdateInfo struct {
-	Box    BaseBox
-	Reason UpdateReason
-	Zid    id.Zid
+	Box     BaseBox
+	Reason  UpdateReason
+	Zid     id.Zid
+	Created bool // Reason is OnZettel, because the zettel was created
 }
 
 // UpdateFunc is a function to be called when a change is detected.
@@ -254,6 +290,14 @@ type Subject interface {
 	RegisterObserver(UpdateFunc)
 }
 
+// AccessProvider is a box that knows the access settings of its boxes. The
+// settings are stored as metadata, with keys like "visibility".
+type AccessProvider interface {
+	// BoxAccess returns the access settings of the named box, or nil if
+	// there are none. An empty name denotes the box that stores new zettel.
+	BoxAccess(name string) *meta.Meta
+}
+
 // Enricher is used to update metadata by adding new properties.
 type Enricher interface {
 	// Enrich computes additional properties and updates the given metadata.
@@ -350,9 +394,11 @@ func (err ErrInvalidZid) Error() string { return "invalid Zettel id: " + err.Zid
 
 // Scheme values for boxes.
 const (
-	SchemeCompBox   = "comp"
-	SchemeConstBox  = "const"
-	SchemeDirBox    = "dir"
-	SchemeFileBox   = "file"
-	SchemeMemoryBox = "mem"
+	SchemeCompBox    = "comp"
+	SchemeConstBox   = "const"
+	SchemeDirBox     = "dir"
+	SchemeFileBox    = "file"
+	SchemeMemoryBox  = "mem"
+	SchemeOverlayBox = "overlay"
+	SchemeVaultBox   = "vault"
 )
@@ -0,0 +1,58 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package compbox
+
+import (
+	"bytes"
+	"context"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/audit"
+)
+
+// maxAuditRecords is the number of the most recent audit records shown.
+const maxAuditRecords = 100
+
+var myAuditLog *audit.Log
+
+// SetupAuditLog remembers the audit log, to show its most recent records.
+func SetupAuditLog(l *audit.Log) { myAuditLog = l }
+
+func genAuditLogM(zid id.Zid) *meta.Meta {
+	if myAuditLog == nil {
+		return nil
+	}
+	m := getTitledMeta(zid, "Zettelstore Audit Log")
+	m.Set(meta.KeySyntax, meta.ValueSyntaxText)
+	m.Set(meta.KeyVisibility, meta.ValueVisibilityOwner)
+	if last := myAuditLog.LastChange(); !last.IsZero() {
+		m.Set(meta.KeyModified, meta.Value(last.Local().Format(id.TimestampLayout)))
+	}
+	return m
+}
+
+func genAuditLogC(_ context.Context, cb *compBox) []byte {
+	records, err := myAuditLog.Records(nil)
+	if err != nil {
+		cb.logger.Error("Unable to read audit log", "err", err)
+	}
+	var buf bytes.Buffer
+	for i := len(records) - 1; i >= max(0, len(records)-maxAuditRecords); i-- {
+		buf.WriteString(records[i].String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
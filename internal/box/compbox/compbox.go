@@ -42,6 +42,13 @@ type compBox struct {
 	enricher box.Enricher
 }
 
+// Identifier of computed zettel that are not defined in package id.
+const (
+	zidWebhooks = id.Zid(50)
+	zidLockout  = id.Zid(51)
+	zidAuditLog = id.Zid(52)
+)
+
 var myConfig *meta.Meta
 var myZettel = map[id.Zid]struct {
 	meta    func(id.Zid) *meta.Meta
@@ -64,6 +71,9 @@ var myZettel = map[id.Zid]struct {
 	id.ZidMetadataKey:          {genKeysM, genKeysC},
 	id.ZidParser:               {genParserM, genParserC},
 	id.ZidStartupConfiguration: {genConfigZettelM, genConfigZettelC},
+	zidWebhooks:                {genWebhooksM, genWebhooksC},
+	zidLockout:                 {genLockoutM, genLockoutC},
+	zidAuditLog:                {genAuditLogM, genAuditLogC},
 }
 
 // Get returns the one program box.
@@ -0,0 +1,63 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package compbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+var myAuth auth.Manager
+
+// SetupLockout remembers the auth manager, to show locked accounts.
+func SetupLockout(am auth.Manager) { myAuth = am }
+
+func genLockoutM(zid id.Zid) *meta.Meta {
+	if myAuth == nil || !myAuth.WithAuth() {
+		return nil
+	}
+	m := getTitledMeta(zid, "Zettelstore Locked Accounts")
+	m.Set(meta.KeySyntax, meta.ValueSyntaxText)
+	m.Set(meta.KeyVisibility, meta.ValueVisibilityOwner)
+	var last time.Time
+	for _, ld := range myAuth.Lockouts() {
+		if ld.LastFailure.After(last) {
+			last = ld.LastFailure
+		}
+	}
+	if !last.IsZero() {
+		m.Set(meta.KeyModified, meta.Value(last.Local().Format(id.TimestampLayout)))
+	}
+	return m
+}
+
+func genLockoutC(context.Context, *compBox) []byte {
+	if myAuth == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, ld := range myAuth.Lockouts() {
+		fmt.Fprintf(&buf, "%s %s=%s failures=%d until=%s\n",
+			ld.LastFailure.Local().Format(time.DateTime), ld.Kind, ld.Key, ld.Failures,
+			ld.LockedUntil.Local().Format(time.DateTime))
+	}
+	return buf.Bytes()
+}
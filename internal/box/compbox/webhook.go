@@ -0,0 +1,55 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package compbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/webhook"
+)
+
+var myWebhooks *webhook.Dispatcher
+
+// SetupWebhooks remembers the webhook dispatcher, to show its dead letters.
+func SetupWebhooks(d *webhook.Dispatcher) { myWebhooks = d }
+
+func genWebhooksM(zid id.Zid) *meta.Meta {
+	if myWebhooks == nil {
+		return nil
+	}
+	m := getTitledMeta(zid, "Zettelstore Webhook Dead Letters")
+	m.Set(meta.KeySyntax, meta.ValueSyntaxText)
+	if _, last := myWebhooks.DeadLetters(); !last.IsZero() {
+		m.Set(meta.KeyModified, meta.Value(last.Local().Format(id.TimestampLayout)))
+	}
+	return m
+}
+
+func genWebhooksC(context.Context, *compBox) []byte {
+	if myWebhooks == nil {
+		return nil
+	}
+	dead, _ := myWebhooks.DeadLetters()
+	var buf bytes.Buffer
+	for _, dl := range dead {
+		fmt.Fprintf(&buf, "%s target=%v event=%s zid=%v attempts=%d url=%s error=%s\n",
+			dl.Time.Local().Format("2006-01-02 15:04:05"), dl.Target, dl.Event, dl.Zid, dl.Attempts, dl.URL, dl.Err)
+	}
+	return buf.Bytes()
+}
@@ -35,3 +35,26 @@
         };
     }
 })();
+
+// Reload the page when the shown zettel was changed, if "auto-reload" is enabled.
+(function() {
+    function zsWatch() {
+        var elem = document.querySelector('[data-watch-url]');
+        if (!elem || !window.EventSource) {
+            return;
+        }
+        var source = new EventSource(elem.getAttribute('data-watch-url'));
+        var reload = function() {
+            source.close();
+            window.location.reload();
+        };
+        source.addEventListener('zettel', reload);
+        source.addEventListener('delete', reload);
+        source.addEventListener('reload', reload);
+    }
+    if (document.readyState === 'loading') {
+        document.addEventListener('DOMContentLoaded', zsWatch);
+    } else {
+        zsWatch();
+    }
+})();
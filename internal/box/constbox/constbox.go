@@ -101,6 +101,17 @@ func (cb *constBox) ReadStats(st *box.ManagedBoxStats) {
 	logging.LogTrace(cb.logger, "ReadStats", "zettel", st.Zettel)
 }
 
+// Identifier of constant zettel that are not defined in package id.
+const (
+	ZidPersonalTokenTemplate = id.Zid(10201)
+	ZidSessionsTemplate      = id.Zid(10202)
+	ZidTOTPTemplate          = id.Zid(10203)
+	ZidShareTemplate         = id.Zid(10204)
+	ZidSharedZettelTemplate  = id.Zid(10205)
+	ZidBulkMetaTemplate      = id.Zid(10301)
+	ZidMergeTemplate         = id.Zid(10406)
+)
+
 var constZettelMap = map[id.Zid]constZettel{
 	id.ZidConfiguration: {
 		constHeader{
@@ -152,7 +163,7 @@ var constZettelMap = map[id.Zid]constZettel{
 			meta.KeyRole:       meta.ValueRoleConfiguration,
 			meta.KeySyntax:     meta.ValueSyntaxSxn,
 			meta.KeyCreated:    "20230510155100",
-			meta.KeyModified:   "20260203125600",
+			meta.KeyModified:   "20261018233000",
 			meta.KeyVisibility: meta.ValueVisibilityExpert,
 		},
 		zettel.NewContent(contentBaseSxn)},
@@ -162,17 +173,62 @@ var constZettelMap = map[id.Zid]constZettel{
 			meta.KeyRole:       meta.ValueRoleConfiguration,
 			meta.KeySyntax:     meta.ValueSyntaxSxn,
 			meta.KeyCreated:    "20200804111624",
-			meta.KeyModified:   "20250806182600",
+			meta.KeyModified:   "20261019070000",
 			meta.KeyVisibility: meta.ValueVisibilityExpert,
 		},
 		zettel.NewContent(contentLoginSxn)},
+	ZidPersonalTokenTemplate: {
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Personal Access Tokens HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeySyntax:     meta.ValueSyntaxSxn,
+			meta.KeyCreated:    "20261018220000",
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+		},
+		zettel.NewContent(contentPersonalTokenSxn)},
+	ZidSessionsTemplate: {
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Sessions HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeySyntax:     meta.ValueSyntaxSxn,
+			meta.KeyCreated:    "20261018230000",
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+		},
+		zettel.NewContent(contentSessionsSxn)},
+	ZidTOTPTemplate: {
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Two-Factor Authentication HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeySyntax:     meta.ValueSyntaxSxn,
+			meta.KeyCreated:    "20261018233000",
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+		},
+		zettel.NewContent(contentTOTPSxn)},
+	ZidShareTemplate: {
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Share Link HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeySyntax:     meta.ValueSyntaxSxn,
+			meta.KeyCreated:    "20261019040000",
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+		},
+		zettel.NewContent(contentShareSxn)},
+	ZidSharedZettelTemplate: {
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Shared Zettel HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeySyntax:     meta.ValueSyntaxSxn,
+			meta.KeyCreated:    "20261019040000",
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+		},
+		zettel.NewContent(contentSharedZettelSxn)},
 	id.ZidZettelTemplate: {
 		constHeader{
 			meta.KeyTitle:      "Zettelstore Zettel HTML Template",
 			meta.KeyRole:       meta.ValueRoleConfiguration,
 			meta.KeySyntax:     meta.ValueSyntaxSxn,
 			meta.KeyCreated:    "20230510155300",
-			meta.KeyModified:   "20260203141400",
+			meta.KeyModified:   "20261018150000",
 			meta.KeyVisibility: meta.ValueVisibilityExpert,
 		},
 		zettel.NewContent(contentZettelSxn)},
@@ -182,7 +238,7 @@ var constZettelMap = map[id.Zid]constZettel{
 			meta.KeyRole:       meta.ValueRoleConfiguration,
 			meta.KeySyntax:     meta.ValueSyntaxSxn,
 			meta.KeyCreated:    "20200804111624",
-			meta.KeyModified:   "20260203141600",
+			meta.KeyModified:   "20261019040000",
 			meta.KeyVisibility: meta.ValueVisibilityExpert,
 		},
 		zettel.NewContent(contentInfoSxn)},
@@ -192,7 +248,7 @@ var constZettelMap = map[id.Zid]constZettel{
 			meta.KeyRole:       meta.ValueRoleConfiguration,
 			meta.KeySyntax:     meta.ValueSyntaxSxn,
 			meta.KeyCreated:    "20200804111624",
-			meta.KeyModified:   "20250806182500",
+			meta.KeyModified:   "20261018133000",
 			meta.KeyVisibility: meta.ValueVisibilityExpert,
 		},
 		zettel.NewContent(contentFormSxn)},
@@ -206,16 +262,34 @@ var constZettelMap = map[id.Zid]constZettel{
 			meta.KeyVisibility: meta.ValueVisibilityExpert,
 		},
 		zettel.NewContent(contentDeleteSxn)},
+	ZidMergeTemplate: {
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Merge HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeySyntax:     meta.ValueSyntaxSxn,
+			meta.KeyCreated:    "20261018180000",
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+		},
+		zettel.NewContent(contentMergeSxn)},
 	id.ZidListTemplate: {
 		constHeader{
 			meta.KeyTitle:      "Zettelstore List Zettel HTML Template",
 			meta.KeyRole:       meta.ValueRoleConfiguration,
 			meta.KeySyntax:     meta.ValueSyntaxSxn,
 			meta.KeyCreated:    "20230704122100",
-			meta.KeyModified:   "20250806182600",
+			meta.KeyModified:   "20261018190000",
 			meta.KeyVisibility: meta.ValueVisibilityExpert,
 		},
 		zettel.NewContent(contentListZettelSxn)},
+	ZidBulkMetaTemplate: {
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Bulk Metadata HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeySyntax:     meta.ValueSyntaxSxn,
+			meta.KeyCreated:    "20261018190000",
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+		},
+		zettel.NewContent(contentBulkMetaSxn)},
 	id.ZidErrorTemplate: {
 		constHeader{
 			meta.KeyTitle:      "Zettelstore Error HTML Template",
@@ -273,7 +347,7 @@ var constZettelMap = map[id.Zid]constZettel{
 			meta.KeyRole:       meta.ValueRoleConfiguration,
 			meta.KeySyntax:     meta.ValueSyntaxJS,
 			meta.KeyCreated:    "20260202123100",
-			meta.KeyModified:   "20260206134600",
+			meta.KeyModified:   "20261018150000",
 			meta.KeyVisibility: meta.ValueVisibilityPublic,
 		},
 		zettel.NewContent(contentBaseJS)},
@@ -451,9 +525,30 @@ var contentFormSxn []byte
 //go:embed delete.sxn
 var contentDeleteSxn []byte
 
+//go:embed merge.sxn
+var contentMergeSxn []byte
+
 //go:embed listzettel.sxn
 var contentListZettelSxn []byte
 
+//go:embed tokens.sxn
+var contentPersonalTokenSxn []byte
+
+//go:embed sessions.sxn
+var contentSessionsSxn []byte
+
+//go:embed totp.sxn
+var contentTOTPSxn []byte
+
+//go:embed share.sxn
+var contentShareSxn []byte
+
+//go:embed shared.sxn
+var contentSharedZettelSxn []byte
+
+//go:embed bulkmeta.sxn
+var contentBulkMetaSxn []byte
+
 //go:embed error.sxn
 var contentErrorSxn []byte
 
@@ -0,0 +1,124 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package dirbox
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	"t73f.de/r/zsc/domain/id"
+)
+
+// fileCipher encrypts and decrypts the files of a directory box.
+//
+// A nil *fileCipher is valid and stores all files as plain text.
+type fileCipher struct {
+	aead cipher.AEAD
+}
+
+// cryptMagic starts every encrypted file, so that a plain file will not be
+// mistaken as an encrypted one.
+var cryptMagic = []byte("ZSC1")
+
+// minSecretLength is the minimum length of the startup secret, the same as
+// it is needed for authentication.
+const minSecretLength = 16
+
+// errShortSecret is returned if encryption is requested, but no startup secret
+// or a too short one was given.
+var errShortSecret = fmt.Errorf("encrypted directory box needs a startup secret of at least length %d", minSecretLength)
+
+// fileKind states whether a file stores metadata or content. It is
+// authenticated too, so that the meta file and the content file of a zettel
+// cannot be swapped without notice.
+type fileKind byte
+
+// Values for fileKind
+const (
+	fileMeta    fileKind = 'm'
+	fileContent fileKind = 'c'
+)
+
+// additionalData returns the data that is authenticated, but not encrypted.
+func additionalData(zid id.Zid, kind fileKind) []byte {
+	return append(zid.Bytes(), byte(kind))
+}
+
+// newFileCipher derives the key for a box from the startup secret. If the
+// secret changes, all encrypted files become unreadable.
+func newFileCipher(secret []byte) (*fileCipher, error) {
+	if len(secret) < minSecretLength {
+		return nil, errShortSecret
+	}
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte("zettelstore-dirbox-crypt"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &fileCipher{aead: aead}, nil
+}
+
+// seal encrypts the given data. The zettel identifier and the kind of file
+// are authenticated too, so that a file cannot be moved to another zettel or
+// to another file of the same zettel without notice.
+func (fc *fileCipher) seal(zid id.Zid, kind fileKind, data []byte) []byte {
+	if fc == nil {
+		return data
+	}
+	nonceSize := fc.aead.NonceSize()
+	result := make([]byte, len(cryptMagic)+nonceSize, len(cryptMagic)+nonceSize+len(data)+fc.aead.Overhead())
+	copy(result, cryptMagic)
+	nonce := result[len(cryptMagic):]
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err) // crypto/rand never returns an error
+	}
+	return fc.aead.Seal(result, nonce, data, additionalData(zid, kind))
+}
+
+// open decrypts data that was encrypted by seal.
+func (fc *fileCipher) open(zid id.Zid, kind fileKind, data []byte) ([]byte, error) {
+	if fc == nil {
+		return data, nil
+	}
+	nonceSize := fc.aead.NonceSize()
+	if !bytes.HasPrefix(data, cryptMagic) || len(data) < len(cryptMagic)+nonceSize {
+		return nil, fmt.Errorf("zettel %v: file is not encrypted", zid)
+	}
+	data = data[len(cryptMagic):]
+	result, err := fc.aead.Open(nil, data[:nonceSize], data[nonceSize:], additionalData(zid, kind))
+	if err != nil {
+		return nil, fmt.Errorf("zettel %v: unable to decrypt file: %w", zid, err)
+	}
+	return result, nil
+}
+
+// readFile reads the file with the given path and decrypts its content.
+func (fc *fileCipher) readFile(zid id.Zid, kind fileKind, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return fc.open(zid, kind, data)
+}
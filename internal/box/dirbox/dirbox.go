@@ -21,7 +21,9 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"unicode"
 
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
@@ -49,6 +51,14 @@ func init() {
 			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
 				return nil, err
 			}
+			var fc *fileCipher
+			if box.GetQueryBool(u, queryCrypt) {
+				var err error
+				if fc, err = newFileCipher(cdata.Secret); err != nil {
+					return nil, err
+				}
+			}
+			subDirKey := q.Get(querySubDir)
 			dp := dirBox{
 				logger:     logger,
 				name:       name,
@@ -58,11 +68,25 @@ func init() {
 				dir:        path,
 				notifySpec: getDirSrvInfo(logger, q.Get("type")),
 				fSrvs:      makePrime(uint32(box.GetQueryInt(u, "worker", 1, 7, 1499))),
+				cipher:     fc,
+				recursive:  subDirKey != "" || box.GetQueryBool(u, queryRecursive),
+				subDirKey:  subDirKey,
 			}
 			return &dp, nil
 		})
 }
 
+// Constants for query parameter
+const (
+	queryCrypt     = "crypt"     // encrypt all files of the box
+	queryRecursive = "recursive" // read zettel from sub-directories too
+	querySubDir    = "subdir"    // metadata key to place new zettel in sub-directories
+)
+
+// subDirYear is a special value for querySubDir: the sub-directory is named
+// by the year of the zettel identifier.
+const subDirYear = "year"
+
 func makePrime(n uint32) uint32 {
 	for !isPrime(n) {
 		n++
@@ -132,6 +156,9 @@ type dirBox struct {
 	fSrvs      uint32
 	fCmds      []chan fileCmd
 	mxCmds     sync.RWMutex
+	cipher     *fileCipher // nil, if files are not encrypted
+	recursive  bool
+	subDirKey  string // empty, if new zettel are stored in the box directory
 }
 
 func (dp *dirBox) Name() string     { return dp.name }
@@ -161,7 +188,7 @@ func (dp *dirBox) Start(context.Context) error {
 	dp.fCmds = make([]chan fileCmd, 0, dp.fSrvs)
 	for i := range dp.fSrvs {
 		cc := make(chan fileCmd)
-		go fileService(i, dp.logger.With("sub", "file", "fn", i), dp.dir, cc)
+		go fileService(i, dp.logger.With("sub", "file", "fn", i), dp.dir, dp.cipher, cc)
 		dp.fCmds = append(dp.fCmds, cc)
 	}
 
@@ -169,9 +196,9 @@ func (dp *dirBox) Start(context.Context) error {
 	var err error
 	switch dp.notifySpec {
 	case dirNotifySimple:
-		notifier, err = notify.NewSimpleDirNotifier(dp.logger.With("notify", "simple"), dp.dir)
+		notifier, err = notify.NewSimpleDirNotifier(dp.logger.With("notify", "simple"), dp.dir, dp.recursive)
 	default:
-		notifier, err = notify.NewFSDirNotifier(dp.logger.With("notify", "fs"), dp.dir)
+		notifier, err = notify.NewFSDirNotifier(dp.logger.With("notify", "fs"), dp.dir, dp.recursive)
 	}
 	if err != nil {
 		dp.logger.Error("Unable to create directory supervisor", "err", err)
@@ -249,7 +276,7 @@ func (dp *dirBox) CreateZettel(ctx context.Context, zettel box.Zettel) (id.Zid,
 	if err == nil {
 		err = dp.dirSrv.UpdateDirEntry(&entry)
 	}
-	dp.notifyChanged(meta.Zid, box.OnZettel)
+	dp.notifyChanged(meta.Zid, box.OnCreate)
 	logging.LogTrace(dp.logger, "CreateZettel", logging.Err(err), "zid", meta.Zid)
 	return meta.Zid, err
 }
@@ -331,7 +358,37 @@ func (dp *dirBox) UpdateZettel(ctx context.Context, zettel box.Zettel) error {
 }
 
 func (dp *dirBox) updateEntryFromMetaContent(entry *notify.DirEntry, m *meta.Meta, content zettel.Content) {
-	entry.SetupFromMetaContent(m, content, dp.cdata.Config.IsZettelFileSyntax)
+	entry.SetupFromMetaContent(m, content, dp.calcSubDir(m), dp.cdata.Config.IsZettelFileSyntax)
+}
+
+// calcSubDir returns the sub-directory, where a new zettel file should be
+// placed. Existing files are never moved.
+func (dp *dirBox) calcSubDir(m *meta.Meta) string {
+	switch dp.subDirKey {
+	case "":
+		return ""
+	case subDirYear:
+		return m.Zid.String()[:4]
+	}
+	val, found := m.Get(dp.subDirKey)
+	if !found {
+		return ""
+	}
+	return cleanSubDir(string(val))
+}
+
+// cleanSubDir transforms the given value into a valid directory name, so that
+// a metadata value is never able to escape the box directory.
+func cleanSubDir(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('-')
+		}
+	}
+	return strings.Trim(sb.String(), "-")
 }
 
 func (dp *dirBox) CanDeleteZettel(_ context.Context, zid id.Zid) bool {
@@ -13,7 +13,12 @@
 
 package dirbox
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+)
 
 func TestIsPrime(t *testing.T) {
 	testcases := []struct {
@@ -51,3 +56,62 @@ func TestMakePrime(t *testing.T) {
 		}
 	}
 }
+
+func TestCleanSubDir(t *testing.T) {
+	for _, tc := range []struct{ in, exp string }{
+		{"", ""},
+		{"zettel", "zettel"},
+		{" manual ", "manual"},
+		{"../etc", "etc"},
+		{"a/b c", "a-b-c"},
+		{"Straße_1", "Straße_1"},
+		{"///", ""},
+	} {
+		if got := cleanSubDir(tc.in); got != tc.exp {
+			t.Errorf("cleanSubDir(%q) == %q, but got %q", tc.in, tc.exp, got)
+		}
+	}
+}
+
+func TestFileCipher(t *testing.T) {
+	if _, err := newFileCipher(nil); err == nil {
+		t.Error("cipher without secret must fail")
+	}
+	if _, err := newFileCipher([]byte("123456789012345")); err == nil {
+		t.Error("cipher with short secret must fail")
+	}
+	fc, err := newFileCipher([]byte("1234567890123456"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zid, otherZid := id.Zid(20260101120000), id.Zid(20260101120001)
+	plain := []byte("id: 20260101120000\ntitle: Secret\n\nCustomer data")
+	sealed := fc.seal(zid, fileContent, plain)
+	if bytes.Contains(sealed, []byte("Customer")) {
+		t.Errorf("sealed data contains plain text: %q", sealed)
+	}
+	got, err := fc.open(zid, fileContent, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("expected %q, but got %q", plain, got)
+	}
+	if _, err = fc.open(otherZid, fileContent, sealed); err == nil {
+		t.Error("data of one zettel must not be readable as another zettel")
+	}
+	if _, err = fc.open(zid, fileMeta, sealed); err == nil {
+		t.Error("content file must not be readable as meta file")
+	}
+	if _, err = fc.open(zid, fileMeta, fc.seal(zid, fileMeta, plain)); err != nil {
+		t.Errorf("meta file must be readable: %v", err)
+	}
+	if _, err = fc.open(zid, fileContent, plain); err == nil {
+		t.Error("plain data must not be accepted")
+	}
+
+	var noCipher *fileCipher
+	if got = noCipher.seal(zid, fileMeta, plain); !bytes.Equal(got, plain) {
+		t.Errorf("nil cipher must not change data, but got %q", got)
+	}
+}
@@ -14,6 +14,7 @@
 package dirbox
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -33,24 +34,24 @@ import (
 	"zettelstore.de/z/internal/kernel"
 )
 
-func fileService(i uint32, logger *slog.Logger, dirPath string, cmds <-chan fileCmd) {
+func fileService(i uint32, logger *slog.Logger, dirPath string, fc *fileCipher, cmds <-chan fileCmd) {
 	// Something may panic. Ensure a running service.
 	defer func() {
 		if ri := recover(); ri != nil {
 			kernel.Main.LogRecover("FileService", ri)
-			go fileService(i, logger, dirPath, cmds)
+			go fileService(i, logger, dirPath, fc, cmds)
 		}
 	}()
 
 	logger.Debug("File service started", "i", i, "dirpath", dirPath)
 	for cmd := range cmds {
-		cmd.run(dirPath)
+		cmd.run(dirPath, fc)
 	}
 	logger.Debug("File service stopped", "i", i, "dirpath", dirPath)
 }
 
 type fileCmd interface {
-	run(string)
+	run(string, *fileCipher)
 }
 
 const serviceTimeout = 5 * time.Second // must be shorter than the web servers timeout values for reading+writing.
@@ -81,7 +82,7 @@ type resGetMeta struct {
 	err  error
 }
 
-func (cmd *fileGetMeta) run(dirPath string) {
+func (cmd *fileGetMeta) run(dirPath string, fc *fileCipher) {
 	var m *meta.Meta
 	var err error
 
@@ -94,12 +95,12 @@ func (cmd *fileGetMeta) run(dirPath string) {
 		case contentName == "", contentExt == "":
 			err = fmt.Errorf("no meta, no content in getMeta, zid=%v", zid)
 		case entry.HasMetaInContent():
-			m, _, err = parseMetaContentFile(zid, filepath.Join(dirPath, contentName))
+			m, _, err = parseMetaContentFile(zid, filepath.Join(dirPath, contentName), fc)
 		default:
 			m = filebox.CalcDefaultMeta(zid, contentExt)
 		}
 	} else {
-		m, err = parseMetaFile(zid, filepath.Join(dirPath, metaName))
+		m, err = parseMetaFile(zid, filepath.Join(dirPath, metaName), fc)
 	}
 	if err == nil {
 		cmdCleanupMeta(m, entry)
@@ -134,7 +135,7 @@ type resGetMetaContent struct {
 	err     error
 }
 
-func (cmd *fileGetMetaContent) run(dirPath string) {
+func (cmd *fileGetMetaContent) run(dirPath string, fc *fileCipher) {
 	var m *meta.Meta
 	var content []byte
 	var err error
@@ -149,16 +150,16 @@ func (cmd *fileGetMetaContent) run(dirPath string) {
 		case contentName == "", contentExt == "":
 			err = fmt.Errorf("no meta, no content in getMetaContent, zid=%v", zid)
 		case entry.HasMetaInContent():
-			m, content, err = parseMetaContentFile(zid, contentPath)
+			m, content, err = parseMetaContentFile(zid, contentPath, fc)
 		default:
 			m = filebox.CalcDefaultMeta(zid, contentExt)
-			content, err = os.ReadFile(contentPath)
+			content, err = fc.readFile(zid, fileContent, contentPath)
 		}
 	} else {
-		m, err = parseMetaFile(zid, filepath.Join(dirPath, metaName))
+		m, err = parseMetaFile(zid, filepath.Join(dirPath, metaName), fc)
 		if contentName != "" {
 			var err1 error
-			content, err1 = os.ReadFile(contentPath)
+			content, err1 = fc.readFile(zid, fileContent, contentPath)
 			if err == nil {
 				err = err1
 			}
@@ -194,7 +195,7 @@ type fileSetZettel struct {
 }
 type resSetZettel = error
 
-func (cmd *fileSetZettel) run(dirPath string) {
+func (cmd *fileSetZettel) run(dirPath string, fc *fileCipher) {
 	var err error
 	entry := cmd.entry
 	zid := entry.Zid
@@ -202,51 +203,59 @@ func (cmd *fileSetZettel) run(dirPath string) {
 	m := cmd.zettel.Meta
 	content := cmd.zettel.Content.AsBytes()
 	metaName := entry.MetaName
+	if err = ensureSubDir(dirPath, metaName, contentName); err != nil {
+		cmd.rc <- err
+		return
+	}
 	if metaName == "" {
 		if contentName == "" {
 			err = fmt.Errorf("no meta, no content in setZettel, zid=%v", zid)
 		} else {
 			contentPath := filepath.Join(dirPath, contentName)
 			if entry.HasMetaInContent() {
-				err = writeZettelFile(contentPath, m, content)
+				err = writeZettelFile(contentPath, m, content, fc)
 				cmd.rc <- err
 				return
 			}
-			err = writeFileContent(contentPath, makeTempPrefix(zid), content)
+			err = writeFileContent(contentPath, makeTempPrefix(zid), fc.seal(zid, fileContent, content))
 		}
 		cmd.rc <- err
 		return
 	}
 
-	err = writeMetaFile(filepath.Join(dirPath, metaName), m)
+	err = writeMetaFile(filepath.Join(dirPath, metaName), m, fc)
 	if err == nil && contentName != "" {
-		err = writeFileContent(filepath.Join(dirPath, contentName), makeTempPrefix(zid), content)
+		err = writeFileContent(filepath.Join(dirPath, contentName), makeTempPrefix(zid), fc.seal(zid, fileContent, content))
 	}
 	cmd.rc <- err
 }
 
+// ensureSubDir creates the sub-directories of the given file names, if needed.
+func ensureSubDir(dirPath string, names ...string) error {
+	for _, name := range names {
+		if subDir := filepath.Dir(name); subDir != "." {
+			if err := os.MkdirAll(filepath.Join(dirPath, subDir), 0o755); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func makeTempPrefix(zid id.Zid) string { return "tmp-" + zid.String() }
 
-func writeMetaFile(metaPath string, m *meta.Meta) error {
-	metaFile, err := oso.SafeWriteWith(metaPath, makeTempPrefix(m.Zid))
-	if err != nil {
-		return err
-	}
-	defer metaFile.RollbackIfNeeded()
-	writeFileZid(metaFile, m.Zid)
-	_, _ = m.WriteComputed(metaFile)
-	return metaFile.Close()
+func writeMetaFile(metaPath string, m *meta.Meta, fc *fileCipher) error {
+	var buf bytes.Buffer
+	writeFileZid(&buf, m.Zid)
+	_, _ = m.WriteComputed(&buf)
+	return writeFileContent(metaPath, makeTempPrefix(m.Zid), fc.seal(m.Zid, fileMeta, buf.Bytes()))
 }
 
-func writeZettelFile(contentPath string, m *meta.Meta, content []byte) error {
-	zettelFile, err := oso.SafeWriteWith(contentPath, makeTempPrefix(m.Zid))
-	if err != nil {
-		return err
-	}
-	defer zettelFile.RollbackIfNeeded()
-	writeMetaHeader(zettelFile, m)
-	_, _ = zettelFile.Write(content)
-	return zettelFile.Close()
+func writeZettelFile(contentPath string, m *meta.Meta, content []byte, fc *fileCipher) error {
+	var buf bytes.Buffer
+	writeMetaHeader(&buf, m)
+	_, _ = buf.Write(content)
+	return writeFileContent(contentPath, makeTempPrefix(m.Zid), fc.seal(m.Zid, fileContent, buf.Bytes()))
 }
 
 var newline = []byte{'\n'}
@@ -280,7 +289,7 @@ type fileDeleteZettel struct {
 }
 type resDeleteZettel = error
 
-func (cmd *fileDeleteZettel) run(dirPath string) {
+func (cmd *fileDeleteZettel) run(dirPath string, _ *fileCipher) {
 	var err error
 
 	entry := cmd.entry
@@ -312,8 +321,8 @@ func (cmd *fileDeleteZettel) run(dirPath string) {
 
 // Utility functions ----------------------------------------
 
-func parseMetaFile(zid id.Zid, path string) (*meta.Meta, error) {
-	src, err := os.ReadFile(path)
+func parseMetaFile(zid id.Zid, path string, fc *fileCipher) (*meta.Meta, error) {
+	src, err := fc.readFile(zid, fileMeta, path)
 	if err != nil {
 		return nil, err
 	}
@@ -321,8 +330,8 @@ func parseMetaFile(zid id.Zid, path string) (*meta.Meta, error) {
 	return meta.NewFromInput(zid, inp), nil
 }
 
-func parseMetaContentFile(zid id.Zid, path string) (*meta.Meta, []byte, error) {
-	src, err := os.ReadFile(path)
+func parseMetaContentFile(zid id.Zid, path string, fc *fileCipher) (*meta.Meta, []byte, error) {
+	src, err := fc.readFile(zid, fileContent, path)
 	if err != nil {
 		return nil, nil, err
 	}
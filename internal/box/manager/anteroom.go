@@ -133,6 +133,12 @@ func (ar *anteroomQueue) Dequeue() (arAction, id.Zid, bool) {
 	return arNothing, id.Invalid, false
 }
 
+func (ar *anteroomQueue) IsEmpty() bool {
+	ar.mx.Lock()
+	defer ar.mx.Unlock()
+	return ar.first == nil
+}
+
 func (ar *anteroomQueue) removeFirst() {
 	ar.first = ar.first.next
 	if ar.first == nil {
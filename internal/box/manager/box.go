@@ -22,9 +22,12 @@ import (
 	"t73f.de/r/zsc/domain/id/idset"
 	"t73f.de/r/zsc/domain/meta"
 
+	"zettelstore.de/z/internal/audit"
+	"zettelstore.de/z/internal/auth"
 	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/logging"
 	"zettelstore.de/z/internal/query"
+	"zettelstore.de/z/internal/webhook"
 )
 
 // Contains all box.Box related functions
@@ -70,9 +73,11 @@ func (mgr *Manager) CreateZettel(ctx context.Context, ztl box.Zettel) (id.Zid, e
 	defer mgr.mgrMx.RUnlock()
 	if createBox, isCreateBox := mgr.boxes[0].(box.CreateBox); isCreateBox {
 		ztl.Meta = mgr.cleanMetaProperties(ztl.Meta)
+		setWebhookWriter(ctx, ztl.Meta)
 		zid, err := createBox.CreateZettel(ctx, ztl)
 		if err == nil {
 			mgr.idxUpdateZettel(ctx, ztl)
+			mgr.auditZettel(ctx, audit.OpCreate, zid, nil, mgr.auditAfter(ctx, zid, mgr.getZettel))
 		}
 		return zid, err
 	}
@@ -112,6 +117,10 @@ func (mgr *Manager) GetAllZettel(ctx context.Context, zid id.Zid) ([]box.Zettel,
 	defer mgr.mgrMx.RUnlock()
 	var result []box.Zettel
 	for _, p := range mgr.boxes {
+		if lb, isLayered := p.(box.LayeredBox); isLayered {
+			result = append(result, lb.GetAllZettel(ctx, zid)...)
+			continue
+		}
 		if z, err := p.GetZettel(ctx, zid); err == nil {
 			mgr.Enrich(ctx, z.Meta, p.Name())
 			result = append(result, z)
@@ -253,11 +262,19 @@ func (mgr *Manager) UpdateZettel(ctx context.Context, zettel box.Zettel) error {
 }
 func (mgr *Manager) updateZettel(ctx context.Context, zettel box.Zettel) error {
 	if updateBox, isUpdateBox := mgr.boxes[0].(box.UpdateBox); isUpdateBox {
+		var before *box.Zettel
+		if mgr.auditLog != nil {
+			if oldZettel, err := mgr.GetZettel(ctx, zettel.Meta.Zid); err == nil {
+				before = &oldZettel
+			}
+		}
 		zettel.Meta = mgr.cleanMetaProperties(zettel.Meta)
+		setWebhookWriter(ctx, zettel.Meta)
 		if err := updateBox.UpdateZettel(ctx, zettel); err != nil {
 			return err
 		}
 		mgr.idxUpdateZettel(ctx, zettel)
+		mgr.auditZettel(ctx, audit.OpUpdate, zettel.Meta.Zid, before, mgr.auditAfter(ctx, zettel.Meta.Zid, mgr.GetZettel))
 		return nil
 	}
 	return box.ErrReadOnly
@@ -278,6 +295,22 @@ func (mgr *Manager) CanDeleteZettel(ctx context.Context, zid id.Zid) bool {
 	return false
 }
 
+// IsStoredInFirstBox returns true, if the zettel is stored in the box that
+// stores new and updated zettel, and not only in one of the other boxes.
+func (mgr *Manager) IsStoredInFirstBox(ctx context.Context, zid id.Zid) bool {
+	if err := mgr.checkContinue(ctx); err != nil {
+		return false
+	}
+	mgr.mgrMx.RLock()
+	defer mgr.mgrMx.RUnlock()
+	// A zettel that only exists in the lower layer of an overlay box cannot be
+	// deleted there.
+	if deleteBox, isDeleteBox := mgr.boxes[0].(box.DeleteBox); isDeleteBox {
+		return deleteBox.CanDeleteZettel(ctx, zid)
+	}
+	return mgr.boxes[0].HasZettel(ctx, zid)
+}
+
 // DeleteZettel removes the zettel from the box.
 func (mgr *Manager) DeleteZettel(ctx context.Context, zid id.Zid) error {
 	mgr.mgrLogger.Debug("DeleteZettel", "zid", zid)
@@ -286,11 +319,18 @@ func (mgr *Manager) DeleteZettel(ctx context.Context, zid id.Zid) error {
 	}
 	mgr.mgrMx.RLock()
 	defer mgr.mgrMx.RUnlock()
+	var before *box.Zettel
+	if mgr.auditLog != nil {
+		if oldZettel, err := mgr.getZettel(ctx, zid); err == nil {
+			before = &oldZettel
+		}
+	}
 	for _, p := range mgr.boxes {
 		if deleteBox, isDeleteBox := p.(box.DeleteBox); isDeleteBox {
 			err := deleteBox.DeleteZettel(ctx, zid)
 			if err == nil {
 				mgr.idxDeleteZettel(ctx, zid)
+				mgr.auditZettel(ctx, audit.OpDelete, zid, before, nil)
 				return err
 			}
 			if _, isErr := errors.AsType[box.ErrZettelNotFound](err); !isErr && !errors.Is(err, box.ErrReadOnly) {
@@ -301,7 +341,8 @@ func (mgr *Manager) DeleteZettel(ctx context.Context, zid id.Zid) error {
 	return box.ErrZettelNotFound{Zid: zid}
 }
 
-// Remove all (computed) properties from metadata before storing the zettel.
+// Remove all (computed) properties and the box name from metadata before
+// storing the zettel.
 func (mgr *Manager) cleanMetaProperties(m *meta.Meta) *meta.Meta {
 	result := m.Clone()
 	for key := range result.ComputedRest() {
@@ -309,5 +350,55 @@ func (mgr *Manager) cleanMetaProperties(m *meta.Meta) *meta.Meta {
 			result.Delete(key)
 		}
 	}
+	result.Delete(meta.KeyBoxName)
 	return result
 }
+
+// setWebhookWriter records the current user in a webhook target, so that
+// only targets stored by the owner are used.
+func setWebhookWriter(ctx context.Context, m *meta.Meta) {
+	m.Delete(webhook.KeyWriter)
+	if _, isTarget := m.Get(webhook.KeyURL); !isTarget {
+		return
+	}
+	if user := auth.GetCurrentUser(ctx); user != nil {
+		m.Set(webhook.KeyWriter, meta.Value(user.Zid.String()))
+	}
+}
+
+// auditAfter retrieves a zettel after it was stored, so that its version in
+// the audit log is the same as the version of the zettel retrieved later.
+// It returns nil, if there is no audit log.
+func (mgr *Manager) auditAfter(ctx context.Context, zid id.Zid, getZettel func(context.Context, id.Zid) (box.Zettel, error)) *box.Zettel {
+	if mgr.auditLog == nil {
+		return nil
+	}
+	z, err := getZettel(ctx, zid)
+	if err != nil {
+		mgr.mgrLogger.Error("Unable to retrieve zettel for audit log", "zid", zid, "err", err)
+		return nil
+	}
+	return &z
+}
+
+// auditZettel stores a change of a zettel in the audit log. Zettel that do
+// not exist before or after the change are given as nil.
+func (mgr *Manager) auditZettel(ctx context.Context, op audit.Op, zid id.Zid, before, after *box.Zettel) {
+	if mgr.auditLog == nil {
+		return
+	}
+	rec := audit.Record{Op: op, Zid: zid, Source: audit.GetSource(ctx)}
+	if user := auth.GetCurrentUser(ctx); user != nil {
+		rec.User = string(user.GetDefault(meta.KeyUserID, ""))
+		rec.UserZid = user.Zid
+	}
+	if before != nil {
+		rec.Before = before.Version()
+	}
+	if after != nil {
+		rec.After = after.Version()
+	}
+	if err := mgr.auditLog.Append(rec); err != nil {
+		mgr.mgrLogger.Error("Unable to write audit log", "op", op, "zid", zid, "err", err)
+	}
+}
@@ -0,0 +1,127 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package manager
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"t73f.de/r/zero/set"
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/audit"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/manager/mapstore"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// auditTestBox stores zettel in memory. Like a real box, it assigns the
+// zettel identifier when a zettel is created.
+type auditTestBox map[id.Zid]box.Zettel
+
+func (auditTestBox) Name() string     { return "test" }
+func (auditTestBox) Location() string { return "" }
+func (tb auditTestBox) GetZettel(_ context.Context, zid id.Zid) (box.Zettel, error) {
+	z, found := tb[zid]
+	if !found {
+		return box.Zettel{}, box.ErrZettelNotFound{Zid: zid}
+	}
+	return box.Zettel{Meta: z.Meta.Clone(), Content: z.Content}, nil
+}
+func (tb auditTestBox) HasZettel(_ context.Context, zid id.Zid) bool {
+	_, found := tb[zid]
+	return found
+}
+func (auditTestBox) ApplyZid(context.Context, box.ZidFunc, box.RetrievePredicate) error {
+	return nil
+}
+func (auditTestBox) ApplyMeta(context.Context, box.MetaFunc, box.RetrievePredicate) error {
+	return nil
+}
+func (tb auditTestBox) ReadStats(st *box.ManagedBoxStats)             { st.Zettel = len(tb) }
+func (auditTestBox) CanCreateZettel(context.Context) bool             { return true }
+func (auditTestBox) CanUpdateZettel(context.Context, box.Zettel) bool { return true }
+func (tb auditTestBox) CreateZettel(_ context.Context, z box.Zettel) (id.Zid, error) {
+	zid := id.Zid(100 + len(tb))
+	m := z.Meta.Clone()
+	m.Zid = zid
+	tb[zid] = box.Zettel{Meta: m, Content: z.Content}
+	return zid, nil
+}
+func (tb auditTestBox) UpdateZettel(_ context.Context, z box.Zettel) error {
+	tb[z.Meta.Zid] = box.Zettel{Meta: z.Meta.Clone(), Content: z.Content}
+	return nil
+}
+
+func TestAuditVersion(t *testing.T) {
+	t.Parallel()
+	auditLog, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = auditLog.Close() }()
+	logger := slog.New(slog.DiscardHandler)
+	mgr := &Manager{
+		mgrLogger:    logger,
+		boxes:        []box.ManagedBox{auditTestBox{}},
+		propertyKeys: set.New[string](),
+		auditLog:     auditLog,
+		idxLogger:    logger,
+		idxStore:     mapstore.New(),
+		idxAr:        newAnteroomQueue(1000),
+		idxReady:     make(chan struct{}, 1),
+	}
+	mgr.setState(box.StartStateStarted)
+	ctx := context.Background()
+
+	m := meta.New(id.Invalid)
+	m.Set(meta.KeyTitle, "Audit")
+	ztl := box.Zettel{Meta: m, Content: zettel.NewContent([]byte("content"))}
+	zid, err := mgr.CreateZettel(ctx, ztl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAuditVersion(t, mgr, zid, audit.OpCreate)
+
+	stored, err := mgr.GetZettel(ctx, zid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored.Content = zettel.NewContent([]byte("changed"))
+	if err = mgr.UpdateZettel(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	checkAuditVersion(t, mgr, zid, audit.OpUpdate)
+}
+
+func checkAuditVersion(t *testing.T, mgr *Manager, zid id.Zid, op audit.Op) {
+	t.Helper()
+	recs, err := mgr.auditLog.Records(func(rec *audit.Record) bool { return rec.Op == op })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Zid != zid {
+		t.Fatalf("expected one %s record for %v, but got %v", op, zid, recs)
+	}
+	z, err := mgr.GetZettel(context.Background(), zid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := z.Version(); recs[0].After != exp {
+		t.Errorf("%s: expected version %q, but got %q", op, exp, recs[0].After)
+	}
+}
@@ -28,12 +28,13 @@ func (mgr *Manager) Enrich(ctx context.Context, m *meta.Meta, boxName string) {
 	if _, hasCreated := m.Get(meta.KeyCreated); !hasCreated {
 		m.Set(meta.KeyCreated, computeCreated(m.Zid))
 	}
+	if boxName != "" {
+		// Always needed, because access rights may depend on the box.
+		m.Set(meta.KeyBoxName, meta.Value(boxName))
+	}
 
 	if box.DoEnrich(ctx) {
 		computePublished(m)
-		if boxName != "" {
-			m.Set(meta.KeyBoxName, meta.Value(boxName))
-		}
 		mgr.idxStore.Enrich(ctx, m)
 	}
 }
@@ -0,0 +1,81 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package manager
+
+import (
+	"sync"
+
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/kernel"
+)
+
+// idxPoolQueueSize is the number of zettel that may wait for one worker.
+const idxPoolQueueSize = 64
+
+// idxPool is a pool of workers that index zettel in parallel.
+//
+// A zettel is always processed by the same worker. Therefore, all updates of
+// one zettel are applied in the order they were submitted, even if the zettel
+// is changed again while it is indexed.
+type idxPool struct {
+	queues  []chan id.Zid
+	pending sync.WaitGroup // Submitted, but not yet processed zettel
+	running sync.WaitGroup // Running workers
+}
+
+// newIdxPool starts numWorkers workers that call work for every submitted
+// zettel.
+func newIdxPool(numWorkers int, work func(id.Zid)) *idxPool {
+	pool := &idxPool{queues: make([]chan id.Zid, max(numWorkers, 1))}
+	for i := range pool.queues {
+		queue := make(chan id.Zid, idxPoolQueueSize)
+		pool.queues[i] = queue
+		pool.running.Go(func() {
+			for zid := range queue {
+				pool.process(work, zid)
+			}
+		})
+	}
+	return pool
+}
+
+func (pool *idxPool) process(work func(id.Zid), zid id.Zid) {
+	defer pool.pending.Done()
+	// Something may panic. Ensure that the worker continues.
+	defer func() {
+		if ri := recover(); ri != nil {
+			kernel.Main.LogRecover("Indexer worker", ri)
+		}
+	}()
+	work(zid)
+}
+
+// submit hands over a zettel to its worker. It blocks if the worker has too
+// much to do. submit and wait must not be called concurrently.
+func (pool *idxPool) submit(zid id.Zid) {
+	pool.pending.Add(1)
+	pool.queues[uint64(zid)%uint64(len(pool.queues))] <- zid
+}
+
+// wait blocks until all submitted zettel are processed.
+func (pool *idxPool) wait() { pool.pending.Wait() }
+
+// stop processes all submitted zettel and terminates all workers.
+func (pool *idxPool) stop() {
+	for _, queue := range pool.queues {
+		close(queue)
+	}
+	pool.running.Wait()
+}
@@ -0,0 +1,60 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package manager
+
+import (
+	"sync"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+)
+
+func TestIdxPool(t *testing.T) {
+	const numZettel = 1000
+	for _, numWorkers := range []int{0, 1, 4} {
+		var mx sync.Mutex
+		seen := map[id.Zid][]int{}
+		seq := 0
+		pool := newIdxPool(numWorkers, func(zid id.Zid) {
+			mx.Lock()
+			seen[zid] = append(seen[zid], seq)
+			seq++
+			mx.Unlock()
+		})
+		for round := range 3 {
+			for i := range numZettel {
+				pool.submit(id.Zid(i + 1))
+			}
+			pool.wait()
+			mx.Lock()
+			for zid, got := range seen {
+				if len(got) != round+1 {
+					t.Errorf("workers=%d: zettel %v processed %d times, expected %d", numWorkers, zid, len(got), round+1)
+				}
+			}
+			mx.Unlock()
+		}
+		pool.stop()
+		if len(seen) != numZettel {
+			t.Errorf("workers=%d: expected %d zettel, but got %d", numWorkers, numZettel, len(seen))
+		}
+		for zid, got := range seen {
+			for i := 1; i < len(got); i++ {
+				if got[i-1] >= got[i] {
+					t.Errorf("workers=%d: zettel %v processed out of order: %v", numWorkers, zid, got)
+				}
+			}
+		}
+	}
+}
@@ -67,7 +67,8 @@ func (mgr *Manager) SearchContains(s string) *idset.Set {
 }
 
 // idxIndexer runs in the background and updates the index data structures.
-// This is the main service of the idxIndexer.
+// This is the main service of the idxIndexer. Zettel are indexed by a pool of
+// workers.
 func (mgr *Manager) idxIndexer() {
 	// Something may panic. Ensure a running indexer.
 	defer func() {
@@ -80,22 +81,41 @@ func (mgr *Manager) idxIndexer() {
 	timerDuration := 15 * time.Second
 	timer := time.NewTimer(timerDuration)
 	ctx := box.NoEnrichContext(context.Background())
+	pool := newIdxPool(mgr.idxNumWorkers, func(zid id.Zid) { mgr.idxIndexZettel(ctx, zid) })
+	defer pool.stop()
 	for {
-		mgr.idxWorkService(ctx)
+		mgr.idxWorkService(ctx, pool)
 		if !mgr.idxSleepService(timer, timerDuration) {
 			return
 		}
 	}
 }
 
-func (mgr *Manager) idxWorkService(ctx context.Context) {
+func (mgr *Manager) idxWorkService(ctx context.Context, pool *idxPool) {
 	var start time.Time
+	inReload := false
+	endReload := func() {
+		if inReload {
+			pool.wait()
+			mgr.idxMx.Lock()
+			mgr.idxDurReload = time.Since(start)
+			mgr.idxMx.Unlock()
+			inReload = false
+		}
+	}
 	for {
-		switch action, zid, lastReload := mgr.idxAr.Dequeue(); action {
+		switch action, zid, isReload := mgr.idxAr.Dequeue(); action {
 		case arNothing:
-			return
+			endReload()
+			// Workers may have enqueued zettel whose references changed.
+			pool.wait()
+			if mgr.idxAr.IsEmpty() {
+				return
+			}
 		case arReload:
 			mgr.idxLogger.Debug("reload")
+			endReload()
+			pool.wait()
 			zids, err := mgr.FetchZids(ctx)
 			if err == nil {
 				start = time.Now()
@@ -106,26 +126,33 @@ func (mgr *Manager) idxWorkService(ctx context.Context) {
 				mgr.idxMx.Unlock()
 			}
 		case arZettel:
-			mgr.idxLogger.Debug("zettel", "zid", zid)
-			zettel, err := mgr.GetZettel(ctx, zid)
-			if err != nil {
-				// Zettel was deleted or is not accessible b/c of other reasons
-				logging.LogTrace(mgr.idxLogger, "delete", "zid", zid)
-				mgr.idxDeleteZettel(ctx, zid)
-				continue
-			}
-			logging.LogTrace(mgr.idxLogger, "update", "zid", zid)
-			mgr.idxUpdateZettel(ctx, zettel)
-			mgr.idxMx.Lock()
-			if lastReload {
-				mgr.idxDurReload = time.Since(start)
+			if !isReload {
+				endReload()
 			}
-			mgr.idxSinceReload++
-			mgr.idxMx.Unlock()
+			pool.submit(zid)
+			inReload = isReload
 		}
 	}
 }
 
+// idxIndexZettel updates the index data of the given zettel. It is called by
+// the workers of the pool.
+func (mgr *Manager) idxIndexZettel(ctx context.Context, zid id.Zid) {
+	mgr.idxLogger.Debug("zettel", "zid", zid)
+	zettel, err := mgr.GetZettel(ctx, zid)
+	if err != nil {
+		// Zettel was deleted or is not accessible b/c of other reasons
+		logging.LogTrace(mgr.idxLogger, "delete", "zid", zid)
+		mgr.idxDeleteZettel(ctx, zid)
+		return
+	}
+	logging.LogTrace(mgr.idxLogger, "update", "zid", zid)
+	mgr.idxUpdateZettel(ctx, zettel)
+	mgr.idxMx.Lock()
+	mgr.idxSinceReload++
+	mgr.idxMx.Unlock()
+}
+
 func (mgr *Manager) idxSleepService(timer *time.Timer, timerDuration time.Duration) bool {
 	select {
 	case _, ok := <-mgr.idxReady:
@@ -148,6 +175,13 @@ func (mgr *Manager) idxSleepService(timer *time.Timer, timerDuration time.Durati
 }
 
 func (mgr *Manager) idxUpdateZettel(ctx context.Context, zettel box.Zettel) {
+	zi := mgr.idxMakeZettelIndex(ctx, zettel)
+	toCheck := mgr.idxStore.UpdateReferences(ctx, zi)
+	mgr.idxCheckZettel(toCheck)
+}
+
+// idxMakeZettelIndex computes the index data of the given zettel.
+func (mgr *Manager) idxMakeZettelIndex(ctx context.Context, zettel box.Zettel) *store.ZettelIndex {
 	var cData collectData
 	cData.initialize()
 	if mustIndexZettel(zettel.Meta) {
@@ -159,8 +193,7 @@ func (mgr *Manager) idxUpdateZettel(ctx context.Context, zettel box.Zettel) {
 	zi := store.NewZettelIndex(m)
 	mgr.idxCollectFromMeta(ctx, m, zi, &cData)
 	mgr.idxProcessData(ctx, zi, &cData)
-	toCheck := mgr.idxStore.UpdateReferences(ctx, zi)
-	mgr.idxCheckZettel(toCheck)
+	return zi
 }
 
 func mustIndexZettel(m *meta.Meta) bool {
@@ -170,7 +203,7 @@ func mustIndexZettel(m *meta.Meta) bool {
 func (mgr *Manager) idxCollectFromMeta(ctx context.Context, m *meta.Meta, zi *store.ZettelIndex, cData *collectData) {
 	for key, val := range m.Computed() {
 		descr := meta.GetDescription(key)
-		if descr.IsProperty() {
+		if descr.IsProperty() || key == meta.KeyBoxName {
 			continue
 		}
 		switch descr.Type {
@@ -244,3 +277,44 @@ func (mgr *Manager) idxCheckZettel(s *idset.Set) {
 		mgr.idxAr.EnqueueZettel(zid)
 	})
 }
+
+// CheckIndex compares the index data with the zettel of all boxes and returns
+// all inconsistencies. If repair is true, the index data of the inconsistent
+// zettel is updated. Since the index is updated concurrently, a zettel that
+// was changed during the check may be reported, even if its index data is
+// correct.
+func (mgr *Manager) CheckIndex(ctx context.Context, repair bool) ([]box.IndexProblem, error) {
+	mgr.mgrLogger.Debug("CheckIndex", "repair", repair)
+	if err := mgr.checkContinue(ctx); err != nil {
+		return nil, err
+	}
+	ctx = box.NoEnrichContext(ctx)
+	zids, err := mgr.FetchZids(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []box.IndexProblem
+	zids.ForEach(func(zid id.Zid) {
+		zettel, err2 := mgr.GetZettel(ctx, zid)
+		if err2 != nil {
+			// Zettel was deleted in the meantime
+			return
+		}
+		zi := mgr.idxMakeZettelIndex(ctx, zettel)
+		problems := mgr.idxStore.CheckZettel(ctx, zi)
+		if repair && len(problems) > 0 {
+			mgr.idxCheckZettel(mgr.idxStore.UpdateReferences(ctx, zi))
+		}
+		result = append(result, problems...)
+	})
+	result = append(result, mgr.idxStore.CheckReferences(ctx, zids, repair)...)
+	if repair && len(result) > 0 {
+		select {
+		case mgr.idxReady <- struct{}{}:
+		default:
+		}
+	}
+	mgr.idxLogger.Info("Index checked", "zettel", zids.Length(), "problems", len(result), "repair", repair)
+	return result, nil
+}
@@ -21,6 +21,7 @@ import (
 	"log/slog"
 	"net/url"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,6 +32,7 @@ import (
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
 
+	"zettelstore.de/z/internal/audit"
 	"zettelstore.de/z/internal/auth"
 	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/box/manager/mapstore"
@@ -45,12 +47,16 @@ type ConnectData struct {
 	Config   config.Config
 	Enricher box.Enricher
 	Notify   box.UpdateNotifier
+	Secret   []byte // startup secret, e.g. to derive encryption keys
 }
 
 // Constants for query parameter
 const (
-	QueryName     = "name"
-	QueryReadOnly = "readonly"
+	QueryName       = "name"
+	QueryReadOnly   = "readonly"
+	QueryVisibility = "visibility" // Visibility of all zettel of the box
+	QueryReadACL    = "read-acl"   // Users and groups that may read zettel of the box
+	QueryWriteACL   = "write-acl"  // Users and groups that may change zettel of the box
 )
 
 // Connect returns a handle to the specified box.
@@ -86,6 +92,9 @@ type Manager struct {
 	done         chan struct{}
 	infos        chan box.UpdateInfo
 	propertyKeys *set.Set[string] // Set of property key names
+	access       map[string]*meta.Meta
+	firstBox     string // Name of the box that stores new zettel
+	auditLog     *audit.Log
 
 	// Indexer data
 	idxLogger *slog.Logger
@@ -93,6 +102,8 @@ type Manager struct {
 	idxAr     *anteroomQueue
 	idxReady  chan struct{} // Signal a non-empty anteroom to background task
 
+	idxNumWorkers int // Number of workers that index zettel in parallel
+
 	// Indexer stats data
 	idxMx          sync.RWMutex
 	idxLastReload  time.Time
@@ -115,7 +126,7 @@ func (mgr *Manager) State() box.StartState {
 }
 
 // New creates a new managing box.
-func New(boxURIs []*url.URL, authManager auth.BaseManager, rtConfig config.Config) (*Manager, error) {
+func New(boxURIs []*url.URL, authManager auth.BaseManager, rtConfig config.Config, secret []byte) (*Manager, error) {
 	descrs := meta.GetSortedKeyDescriptions()
 	propertyKeys := set.New[string]()
 	for _, kd := range descrs {
@@ -134,12 +145,25 @@ func New(boxURIs []*url.URL, authManager auth.BaseManager, rtConfig config.Confi
 		idxStore:  createIdxStore(rtConfig),
 		idxAr:     newAnteroomQueue(1000),
 		idxReady:  make(chan struct{}, 1),
+
+		idxNumWorkers: kernel.Main.GetConfig(kernel.BoxService, kernel.BoxIndexWorkers).(int),
+	}
+	if mgr.idxNumWorkers <= 0 {
+		mgr.idxNumWorkers = runtime.GOMAXPROCS(0)
 	}
 
 	if err := setupBoxURIs(boxURIs, authManager.IsReadonly()); err != nil {
 		return nil, err
 	}
-	cdata := ConnectData{Config: rtConfig, Enricher: mgr, Notify: mgr.notifyChanged}
+	access, err := setupBoxAccess(boxURIs)
+	if err != nil {
+		return nil, err
+	}
+	mgr.access = access
+	if len(boxURIs) > 0 {
+		mgr.firstBox = boxURIs[0].Query().Get(QueryName)
+	}
+	cdata := ConnectData{Config: rtConfig, Enricher: mgr, Notify: mgr.notifyChanged, Secret: secret}
 	boxes := make([]box.ManagedBox, 0, len(boxURIs)+2)
 	for _, u := range boxURIs {
 		b, err := Connect(u, &cdata)
@@ -231,6 +255,47 @@ func setupBoxURIs(boxURIs []*url.URL, isReadonly bool) error {
 	}
 	return nil
 }
+
+// setupBoxAccess collects the access settings of all boxes, as metadata
+// that uses the same keys as a zettel.
+func setupBoxAccess(boxURIs []*url.URL) (map[string]*meta.Meta, error) {
+	result := map[string]*meta.Meta{}
+	for i, u := range boxURIs {
+		q := u.Query()
+		var m *meta.Meta
+		for _, key := range []string{QueryVisibility, QueryReadACL, QueryWriteACL} {
+			if !q.Has(key) {
+				continue
+			}
+			val := meta.Value(strings.TrimSpace(q.Get(key)))
+			if key == QueryVisibility && val.AsVisibility() == meta.VisibilityUnknown {
+				return nil, fmt.Errorf("invalid visibility %q in box-uri-%d %v", val, i+1, u)
+			}
+			if m == nil {
+				m = meta.New(id.Invalid)
+			}
+			m.Set(key, val)
+		}
+		if m != nil {
+			result[q.Get(QueryName)] = m
+		}
+	}
+	return result, nil
+}
+
+// SetAuditLog sets the log that records all changes to zettel. It must be
+// called before the manager is started.
+func (mgr *Manager) SetAuditLog(l *audit.Log) { mgr.auditLog = l }
+
+// BoxAccess returns the access settings of the named box, or nil if there
+// are none. An empty name denotes the box that stores new zettel.
+func (mgr *Manager) BoxAccess(name string) *meta.Meta {
+	if name == "" {
+		name = mgr.firstBox
+	}
+	return mgr.access[name]
+}
+
 func nameFromPath(path string) string {
 	name := filepath.Base(path)
 	if name[0] == '.' || name[0] == '/' {
@@ -283,6 +348,10 @@ func (mgr *Manager) notifier() {
 					logging.LogTrace(mgr.mgrLogger, "notifier ignored", "reason", reason, "zid", zid)
 					continue
 				}
+				if reason == box.OnCreate {
+					reason = box.OnZettel
+					ci.Reason, ci.Created = reason, true
+				}
 
 				isStarted := mgr.State() == box.StartStateStarted
 				mgr.idxEnqueue(reason, zid)
@@ -305,9 +374,12 @@ type destutterData struct {
 }
 type destutterCache = map[id.Zid]destutterData
 
+// ignoreUpdate returns true, if the same change was reported shortly before.
+// A change of a zettel that was just created is the same change.
 func ignoreUpdate(cache destutterCache, now time.Time, reason box.UpdateReason, zid id.Zid) bool {
 	if dsd, found := cache[zid]; found {
-		if dsd.reason == reason && dsd.deadAt.After(now) {
+		sameReason := dsd.reason == reason || (dsd.reason == box.OnCreate && reason == box.OnZettel)
+		if sameReason && dsd.deadAt.After(now) {
 			return true
 		}
 	}
@@ -17,6 +17,9 @@ import (
 	"net/url"
 	"slices"
 	"testing"
+	"time"
+
+	"zettelstore.de/z/internal/box"
 )
 
 func TestSetupBoxURIs(t *testing.T) {
@@ -77,6 +80,49 @@ func TestSetupBoxURIs(t *testing.T) {
 	}
 }
 
+func TestSetupBoxAccess(t *testing.T) {
+	testcases := []struct {
+		uri    string
+		key    string
+		exp    string
+		expErr bool
+	}{
+		{"mem:?name=mem", "", "", false},
+		{"mem:?name=mem&visibility=owner", QueryVisibility, "owner", false},
+		{"mem:?name=mem&visibility=secret", "", "", true},
+		{"mem:?name=mem&read-acl=20260101000000+20260101000100", QueryReadACL, "20260101000000 20260101000100", false},
+		{"mem:?name=mem&write-acl=20260101000000", QueryWriteACL, "20260101000000", false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.uri, func(t *testing.T) {
+			u, err := url.Parse(tc.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			access, err := setupBoxAccess([]*url.URL{u})
+			if err != nil {
+				if !tc.expErr {
+					t.Errorf("no error expected, but got %v", err)
+				}
+				return
+			}
+			if tc.expErr {
+				t.Error("error expected, but got none")
+			}
+			m, found := access["mem"]
+			if tc.key == "" {
+				if found {
+					t.Errorf("no access settings expected, but got %v", m)
+				}
+				return
+			}
+			if got, _ := m.Get(tc.key); string(got) != tc.exp {
+				t.Errorf("expected %q, but got %q", tc.exp, got)
+			}
+		})
+	}
+}
+
 func TestNameFromPath(t *testing.T) {
 	testcases := []struct {
 		path string
@@ -105,3 +151,30 @@ func TestNameFromPath(t *testing.T) {
 		})
 	}
 }
+
+func TestIgnoreUpdate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		reasons []box.UpdateReason
+		exp     []bool
+	}{
+		{"zettel", []box.UpdateReason{box.OnZettel, box.OnZettel}, []bool{false, true}},
+		{"create", []box.UpdateReason{box.OnCreate, box.OnZettel, box.OnCreate}, []bool{false, true, true}},
+		{"zettel-create", []box.UpdateReason{box.OnZettel, box.OnCreate}, []bool{false, false}},
+		{"delete", []box.UpdateReason{box.OnCreate, box.OnDelete, box.OnDelete}, []bool{false, false, true}},
+	}
+	now := time.Now()
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := destutterCache{}
+			for i, reason := range tc.reasons {
+				if got := ignoreUpdate(cache, now, reason, 1); got != tc.exp[i] {
+					t.Errorf("%d: reason %v, expected %v, but got %v", i, reason, tc.exp[i], got)
+				}
+			}
+			if ignoreUpdate(cache, now.Add(time.Second), tc.reasons[0], 1) {
+				t.Error("update must not be ignored after some time")
+			}
+		})
+	}
+}
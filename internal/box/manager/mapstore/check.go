@@ -0,0 +1,222 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package mapstore
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/id/idset"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/manager/store"
+)
+
+func (ms *mapStore) CheckZettel(_ context.Context, zidx *store.ZettelIndex) []box.IndexProblem {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	zid := zidx.Zid
+	zi, found := ms.idx[zid]
+	if !found || zi.meta == nil {
+		return []box.IndexProblem{{Kind: box.IndexMissing, Zid: zid, Detail: "zettel is not indexed"}}
+	}
+
+	var result []box.IndexProblem
+	stale := func(detail string) {
+		result = append(result, box.IndexProblem{Kind: box.IndexStale, Zid: zid, Detail: detail})
+	}
+	if !zi.meta.Equal(makeCheckMeta(zidx.GetMeta()), true) {
+		stale("metadata differs")
+	}
+	if detail := diffRefs(zi.forward, zidx.GetBackRefs()); detail != "" {
+		stale("references " + detail)
+	}
+	if detail := diffRefs(zi.dead, zidx.GetDeadRefs()); detail != "" {
+		stale("dead references " + detail)
+	}
+	if newWords, remWords := zidx.GetWords().Diff(zi.words); len(newWords) > 0 || len(remWords) > 0 {
+		stale(fmt.Sprintf("words: %d not indexed, %d obsolete", len(newWords), len(remWords)))
+	}
+	if newUrls, remUrls := zidx.GetUrls().Diff(zi.urls); len(newUrls) > 0 || len(remUrls) > 0 {
+		stale(fmt.Sprintf("URLs: %d not indexed, %d obsolete", len(newUrls), len(remUrls)))
+	}
+
+	inverseRefs := zidx.GetInverseRefs()
+	keys := slices.Collect(maps.Keys(inverseRefs))
+	for key, mr := range zi.otherRefs {
+		if _, isInverse := inverseRefs[key]; !isInverse && !mr.forward.IsEmpty() {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if detail := diffRefs(zi.otherRefs[key].forward, inverseRefs[key]); detail != "" {
+			result = append(result, box.IndexProblem{
+				Kind: box.IndexInverse, Zid: zid, Detail: fmt.Sprintf("metadata %q %s", key, detail),
+			})
+		}
+	}
+	return result
+}
+
+// makeCheckMeta returns the metadata that should be stored for the given
+// metadata.
+func makeCheckMeta(origM *meta.Meta) *meta.Meta {
+	m := meta.New(origM.Zid)
+	for key, val := range origM.All() {
+		if isInternableValue(key) || key == meta.KeyBoxName || !meta.IsComputed(key) {
+			m.Set(key, val)
+		}
+	}
+	return m
+}
+
+// diffRefs returns a description of the difference between the stored and the
+// expected references, or the empty string if there is none.
+func diffRefs(stored, expected *idset.Set) string {
+	newRefs, remRefs := stored.Diff(expected)
+	switch {
+	case newRefs.IsEmpty() && remRefs.IsEmpty():
+		return ""
+	case remRefs.IsEmpty():
+		return fmt.Sprintf("not indexed: %v", newRefs)
+	case newRefs.IsEmpty():
+		return fmt.Sprintf("obsolete: %v", remRefs)
+	}
+	return fmt.Sprintf("not indexed: %v, obsolete: %v", newRefs, remRefs)
+}
+
+func (ms *mapStore) CheckReferences(_ context.Context, zids *idset.Set, repair bool) []box.IndexProblem {
+	if repair {
+		ms.mx.Lock()
+		defer ms.mx.Unlock()
+	} else {
+		ms.mx.RLock()
+		defer ms.mx.RUnlock()
+	}
+
+	var result []box.IndexProblem
+	report := func(kind box.IndexProblemKind, zid id.Zid, format string, args ...any) {
+		result = append(result, box.IndexProblem{Kind: kind, Zid: zid, Detail: fmt.Sprintf(format, args...)})
+	}
+	for _, zid := range slices.Sorted(maps.Keys(ms.idx)) {
+		zi := ms.idx[zid]
+		if zi.meta != nil && !zids.Contains(zid) {
+			report(box.IndexStale, zid, "zettel does not exist")
+		}
+		zi.forward.ForEach(func(ref id.Zid) {
+			if rzi, found := ms.idx[ref]; !found || !rzi.backward.Contains(zid) {
+				report(box.IndexDangling, zid, "reference to %v is not stored there", ref)
+			}
+		})
+		zi.backward.ForEach(func(ref id.Zid) {
+			if rzi, found := ms.idx[ref]; !found || !rzi.forward.Contains(zid) {
+				report(box.IndexDangling, zid, "back reference from %v does not exist", ref)
+			}
+		})
+		for _, key := range slices.Sorted(maps.Keys(zi.otherRefs)) {
+			mr := zi.otherRefs[key]
+			mr.forward.ForEach(func(ref id.Zid) {
+				if rzi, found := ms.idx[ref]; !found || !rzi.otherRefs[key].backward.Contains(zid) {
+					report(box.IndexInverse, zid, "metadata %q of %v does not refer back", key, ref)
+				}
+			})
+			mr.backward.ForEach(func(ref id.Zid) {
+				if rzi, found := ms.idx[ref]; !found || !rzi.otherRefs[key].forward.Contains(zid) {
+					report(box.IndexInverse, zid, "metadata %q from %v does not exist", key, ref)
+				}
+			})
+		}
+		zi.dead.ForEach(func(ref id.Zid) {
+			if !ms.dead[ref].Contains(zid) {
+				report(box.IndexDangling, zid, "dead reference to %v is not stored", ref)
+			}
+		})
+	}
+	for _, ref := range slices.Sorted(maps.Keys(ms.dead)) {
+		ms.dead[ref].ForEach(func(zid id.Zid) {
+			if zi, found := ms.idx[zid]; !found || !zi.dead.Contains(ref) {
+				report(box.IndexDangling, zid, "dead reference to %v does not exist", ref)
+			}
+		})
+	}
+
+	if repair && len(result) > 0 {
+		ms.repairReferences(zids)
+	}
+	return result
+}
+
+// repairReferences removes the index data of zettel that do not exist and
+// rebuilds all backward references from the forward references of all
+// zettel.
+func (ms *mapStore) repairReferences(zids *idset.Set) {
+	// Must only be called if ms.mx is write-locked!
+	for zid, zi := range ms.idx {
+		if zi.meta != nil && !zids.Contains(zid) {
+			ms.doDeleteZettel(zid)
+		}
+	}
+
+	for _, zi := range ms.idx {
+		zi.backward = nil
+		for key, mr := range zi.otherRefs {
+			mr.backward = nil
+			zi.otherRefs[key] = mr
+		}
+	}
+	clear(ms.dead)
+	for zid, zi := range maps.Collect(maps.All(ms.idx)) {
+		zi.forward.ForEach(func(ref id.Zid) {
+			rzi := ms.getOrCreateEntry(ref)
+			rzi.backward = rzi.backward.Add(zid)
+		})
+		for key, mr := range zi.otherRefs {
+			mr.forward.ForEach(func(ref id.Zid) {
+				rzi := ms.getOrCreateEntry(ref)
+				if rzi.otherRefs == nil {
+					rzi.otherRefs = make(map[string]bidiRefs)
+				}
+				rmr := rzi.otherRefs[key]
+				rmr.backward = rmr.backward.Add(zid)
+				rzi.otherRefs[key] = rmr
+			})
+		}
+		zi.dead.ForEach(func(ref id.Zid) {
+			ms.dead[ref] = ms.dead[ref].Add(zid)
+		})
+	}
+
+	// Remove entries of zettel that are neither indexed nor referenced.
+	for zid, zi := range ms.idx {
+		if zi.meta != nil || !zi.backward.IsEmpty() {
+			continue
+		}
+		for key, mr := range zi.otherRefs {
+			if mr.forward.IsEmpty() && mr.backward.IsEmpty() {
+				delete(zi.otherRefs, key)
+			}
+		}
+		if len(zi.otherRefs) == 0 {
+			delete(ms.idx, zid)
+		}
+	}
+	for _, zi := range ms.idx {
+		zi.optimize()
+	}
+}
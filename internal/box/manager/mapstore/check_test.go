@@ -0,0 +1,143 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package mapstore
+
+import (
+	"context"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/id/idset"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/manager/store"
+)
+
+// newCheckStore returns a store, where zettel 1 links to zettel 2, has a
+// dead reference to zettel 9, and refers via metadata to zettel 3.
+func newCheckStore(t *testing.T) *mapStore {
+	t.Helper()
+	ms := New().(*mapStore)
+	ctx := context.Background()
+	zidx := store.NewZettelIndex(meta.New(1))
+	zidx.AddBackRef(2)
+	zidx.AddDeadRef(9)
+	zidx.AddInverseRef(meta.KeyFolge, 3)
+	ms.UpdateReferences(ctx, zidx)
+	ms.UpdateReferences(ctx, store.NewZettelIndex(meta.New(2)))
+	ms.UpdateReferences(ctx, store.NewZettelIndex(meta.New(3)))
+	if problems := ms.CheckReferences(ctx, idset.New(1, 2, 3), false); len(problems) > 0 {
+		t.Fatalf("new store must not have problems, but got %v", problems)
+	}
+	return ms
+}
+
+func TestCheckReferences(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name    string
+		corrupt func(*mapStore)
+		zids    []id.Zid
+		exp     box.IndexProblem
+		check   func(*mapStore) bool // Is the store repaired?
+	}{
+		{
+			name:    "forward",
+			corrupt: func(ms *mapStore) { ms.idx[2].backward = ms.idx[2].backward.Remove(1) },
+			exp:     box.IndexProblem{Kind: box.IndexDangling, Zid: 1, Detail: "reference to 00000000000002 is not stored there"},
+			check:   func(ms *mapStore) bool { return ms.idx[2].backward.Contains(1) },
+		},
+		{
+			name:    "backward",
+			corrupt: func(ms *mapStore) { ms.idx[3].backward = ms.idx[3].backward.Add(2) },
+			exp:     box.IndexProblem{Kind: box.IndexDangling, Zid: 3, Detail: "back reference from 00000000000002 does not exist"},
+			check:   func(ms *mapStore) bool { return !ms.idx[3].backward.Contains(2) },
+		},
+		{
+			name: "inverse-forward",
+			corrupt: func(ms *mapStore) {
+				mr := ms.idx[3].otherRefs[meta.KeyFolge]
+				mr.backward = mr.backward.Remove(1)
+				ms.idx[3].otherRefs[meta.KeyFolge] = mr
+			},
+			exp:   box.IndexProblem{Kind: box.IndexInverse, Zid: 1, Detail: `metadata "folge" of 00000000000003 does not refer back`},
+			check: func(ms *mapStore) bool { return ms.idx[3].otherRefs[meta.KeyFolge].backward.Contains(1) },
+		},
+		{
+			name: "inverse-backward",
+			corrupt: func(ms *mapStore) {
+				mr := ms.idx[3].otherRefs[meta.KeyFolge]
+				mr.backward = mr.backward.Add(2)
+				ms.idx[3].otherRefs[meta.KeyFolge] = mr
+			},
+			exp:   box.IndexProblem{Kind: box.IndexInverse, Zid: 3, Detail: `metadata "folge" from 00000000000002 does not exist`},
+			check: func(ms *mapStore) bool { return !ms.idx[3].otherRefs[meta.KeyFolge].backward.Contains(2) },
+		},
+		{
+			name:    "dead-missing",
+			corrupt: func(ms *mapStore) { delete(ms.dead, 9) },
+			exp:     box.IndexProblem{Kind: box.IndexDangling, Zid: 1, Detail: "dead reference to 00000000000009 is not stored"},
+			check:   func(ms *mapStore) bool { return ms.dead[9].Contains(1) },
+		},
+		{
+			name:    "dead-obsolete",
+			corrupt: func(ms *mapStore) { ms.dead[8] = ms.dead[8].Add(2) },
+			exp:     box.IndexProblem{Kind: box.IndexDangling, Zid: 2, Detail: "dead reference to 00000000000008 does not exist"},
+			check: func(ms *mapStore) bool {
+				_, found := ms.dead[8]
+				return !found
+			},
+		},
+		{
+			name:    "not-existing",
+			corrupt: func(*mapStore) {},
+			zids:    []id.Zid{1, 3},
+			exp:     box.IndexProblem{Kind: box.IndexStale, Zid: 2, Detail: "zettel does not exist"},
+			check: func(ms *mapStore) bool {
+				zi, found := ms.idx[2]
+				return found && zi.meta == nil && zi.backward.Contains(1)
+			},
+		},
+	}
+	ctx := context.Background()
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ms := newCheckStore(t)
+			zids := idset.New(1, 2, 3)
+			if tc.zids != nil {
+				zids = idset.New(tc.zids...)
+			}
+			tc.corrupt(ms)
+
+			problems := ms.CheckReferences(ctx, zids, false)
+			if len(problems) != 1 || problems[0] != tc.exp {
+				t.Fatalf("expected problem %v, but got %v", tc.exp, problems)
+			}
+			if tc.check(ms) {
+				t.Error("store must not be repaired without request")
+			}
+
+			if problems = ms.CheckReferences(ctx, zids, true); len(problems) != 1 {
+				t.Errorf("expected problem to be reported when repairing, but got %v", problems)
+			}
+			if !tc.check(ms) {
+				t.Error("store was not repaired")
+			}
+			if problems = ms.CheckReferences(ctx, zids, false); len(problems) > 0 {
+				t.Errorf("repaired store must not have problems, but got %v", problems)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"t73f.de/r/zsc/domain/id/idset"
 	"t73f.de/r/zsc/domain/meta"
 
+	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/query"
 )
 
@@ -59,6 +60,16 @@ type Store interface {
 	// Returns set of zettel identifier that must also be checked for changes.
 	DeleteZettel(context.Context, id.Zid) *idset.Set
 
+	// CheckZettel compares the stored index data of a zettel with the given
+	// index data, which was computed from the zettel.
+	CheckZettel(context.Context, *ZettelIndex) []box.IndexProblem
+
+	// CheckReferences checks that all stored references are recorded on both
+	// sides. The given set contains the identifier of all existing zettel.
+	// If repair is true, all references are rebuilt and the data of
+	// non-existing zettel is removed.
+	CheckReferences(ctx context.Context, zids *idset.Set, repair bool) []box.IndexProblem
+
 	// Optimize removes unneeded space.
 	Optimize()
 
@@ -18,7 +18,9 @@ import (
 	"context"
 	"log/slog"
 	"net/url"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"t73f.de/r/zsc/domain/id"
 
@@ -42,10 +44,19 @@ func init() {
 				maxZettel: box.GetQueryInt(u, "max-zettel", 0, 127, 65535),
 				maxBytes:  box.GetQueryInt(u, "max-bytes", 0, 65535, (1024*1024*1024)-1),
 				readonly:  box.GetQueryBool(u, manager.QueryReadOnly),
+				snapshot:  getSnapshotPath(u),
+				interval:  time.Duration(box.GetQueryInt(u, "snapshot-interval", 0, 60, 86400)) * time.Second,
 			}, nil
 		})
 }
 
+func getSnapshotPath(u *url.URL) string {
+	if p := u.Query().Get("snapshot"); p != "" {
+		return filepath.Clean(p)
+	}
+	return ""
+}
+
 type memBox struct {
 	logger    *slog.Logger
 	cdata     manager.ConnectData
@@ -57,6 +68,12 @@ type memBox struct {
 	zettel    map[id.Zid]box.Zettel
 	curBytes  int
 	readonly  bool
+	snapshot  string        // path of snapshot file, empty if no snapshots
+	interval  time.Duration // time between two snapshots, zero if only on stop
+	changed   bool          // zettel changed since last snapshot
+	done      chan struct{} // stops the snapshot service
+
+	mxSnapshot sync.Mutex // Serializes writing snapshots
 }
 
 func (mb *memBox) notifyChanged(zid id.Zid, reason box.UpdateReason) {
@@ -78,15 +95,37 @@ func (mb *memBox) State() box.StartState {
 }
 
 func (mb *memBox) Start(context.Context) error {
+	zettel, curBytes := make(map[id.Zid]box.Zettel), 0
+	if mb.snapshot != "" {
+		var err error
+		if zettel, curBytes, err = mb.loadSnapshot(); err != nil {
+			mb.logger.Error("Unable to load snapshot", "err", err, "path", mb.snapshot)
+			return err
+		}
+	}
 	mb.mx.Lock()
-	mb.zettel = make(map[id.Zid]box.Zettel)
-	mb.curBytes = 0
+	mb.zettel = zettel
+	mb.curBytes = curBytes
+	mb.changed = false
+	if mb.snapshot != "" && !mb.readonly && mb.interval > 0 {
+		mb.done = make(chan struct{})
+		go mb.snapshotService(mb.interval, mb.done)
+	}
 	mb.mx.Unlock()
 	logging.LogTrace(mb.logger, "Start box", "max-zettel", mb.maxZettel, "max-bytes", mb.maxBytes)
 	return nil
 }
 
 func (mb *memBox) Stop(context.Context) {
+	mb.mx.Lock()
+	if mb.done != nil {
+		close(mb.done)
+		mb.done = nil
+	}
+	mb.mx.Unlock()
+	if mb.snapshot != "" && !mb.readonly {
+		_ = mb.saveSnapshot()
+	}
 	mb.mx.Lock()
 	mb.zettel = nil
 	mb.mx.Unlock()
@@ -121,9 +160,10 @@ func (mb *memBox) CreateZettel(_ context.Context, zettel box.Zettel) (id.Zid, er
 	zettel.Meta = meta
 	mb.zettel[zid] = zettel
 	mb.curBytes = newBytes
+	mb.changed = true
 	mb.mx.Unlock()
 
-	mb.notifyChanged(zid, box.OnZettel)
+	mb.notifyChanged(zid, box.OnCreate)
 	logging.LogTrace(mb.logger, "CreateZettel", "zid", zid)
 	return zid, nil
 }
@@ -215,6 +255,7 @@ func (mb *memBox) UpdateZettel(_ context.Context, zettel box.Zettel) error {
 	zettel.Meta = m
 	mb.zettel[m.Zid] = zettel
 	mb.curBytes = newBytes
+	mb.changed = true
 	mb.mx.Unlock()
 	mb.notifyChanged(m.Zid, box.OnZettel)
 	logging.LogTrace(mb.logger, "UpdateZettel")
@@ -240,6 +281,7 @@ func (mb *memBox) DeleteZettel(_ context.Context, zid id.Zid) error {
 	}
 	delete(mb.zettel, zid)
 	mb.curBytes -= oldZettel.ByteSize()
+	mb.changed = true
 	mb.mx.Unlock()
 	mb.notifyChanged(zid, box.OnDelete)
 	logging.LogTrace(mb.logger, "DeleteZettel")
@@ -0,0 +1,173 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package membox
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"t73f.de/r/zero/oso"
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+	"t73f.de/r/zsx/input"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// A snapshot is a ZIP file. Every zettel is stored in two entries: one for the
+// metadata, and one for the content.
+const (
+	snapshotExtMeta    = ".meta"
+	snapshotExtContent = ".content"
+)
+
+// loadSnapshot reads all zettel from the snapshot file. A missing file is not
+// an error, it just results in an empty box. Zettel that would exceed the
+// configured limits are ignored.
+func (mb *memBox) loadSnapshot() (map[id.Zid]box.Zettel, int, error) {
+	result := make(map[id.Zid]box.Zettel)
+	reader, err := zip.OpenReader(mb.snapshot)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, 0, nil
+		}
+		return nil, 0, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	metas := make(map[id.Zid]*meta.Meta)
+	contents := make(map[id.Zid][]byte)
+	for _, f := range reader.File {
+		ext := path.Ext(f.Name)
+		zid, errZid := id.Parse(strings.TrimSuffix(f.Name, ext))
+		if errZid != nil {
+			mb.logger.Warn("Ignore snapshot entry", "name", f.Name)
+			continue
+		}
+		data, errRead := readSnapshotEntry(f)
+		if errRead != nil {
+			return nil, 0, errRead
+		}
+		switch ext {
+		case snapshotExtMeta:
+			metas[zid] = meta.NewFromInput(zid, input.NewInput(data))
+		case snapshotExtContent:
+			contents[zid] = data
+		default:
+			mb.logger.Warn("Ignore snapshot entry", "name", f.Name)
+		}
+	}
+
+	curBytes := 0
+	for zid, m := range metas {
+		z := box.Zettel{Meta: m, Content: zettel.NewContent(contents[zid])}
+		newBytes := curBytes + z.ByteSize()
+		if mb.maxZettel <= len(result) || mb.maxBytes < newBytes {
+			mb.logger.Warn("Snapshot exceeds box capacity, zettel ignored", "zid", zid)
+			continue
+		}
+		result[zid] = z
+		curBytes = newBytes
+	}
+	logging.LogTrace(mb.logger, "Snapshot loaded", "zettel", len(result), "bytes", curBytes)
+	return result, curBytes, nil
+}
+
+func readSnapshotEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
+
+// saveSnapshot writes all zettel into the snapshot file, if they were changed
+// since the last snapshot.
+func (mb *memBox) saveSnapshot() error {
+	mb.mxSnapshot.Lock()
+	defer mb.mxSnapshot.Unlock()
+	mb.mx.Lock()
+	if !mb.changed || mb.zettel == nil {
+		mb.mx.Unlock()
+		return nil
+	}
+	zs := make([]box.Zettel, 0, len(mb.zettel))
+	for _, z := range mb.zettel {
+		zs = append(zs, z)
+	}
+	mb.changed = false
+	mb.mx.Unlock()
+
+	err := writeSnapshot(mb.snapshot, zs)
+	if err != nil {
+		mb.mx.Lock()
+		mb.changed = true // Try again later
+		mb.mx.Unlock()
+		mb.logger.Error("Unable to write snapshot", "err", err, "path", mb.snapshot)
+		return err
+	}
+	logging.LogTrace(mb.logger, "Snapshot written", "zettel", len(zs))
+	return nil
+}
+
+func writeSnapshot(snapshotPath string, zs []box.Zettel) error {
+	f, err := oso.SafeWriteWith(snapshotPath, "tmp-snapshot")
+	if err != nil {
+		return err
+	}
+	defer f.RollbackIfNeeded()
+	zw := zip.NewWriter(f)
+	for _, z := range zs {
+		name := z.Meta.Zid.String()
+		w, errCreate := zw.Create(name + snapshotExtMeta)
+		if errCreate != nil {
+			return errCreate
+		}
+		if _, err = z.Meta.Write(w); err != nil {
+			return err
+		}
+		if w, errCreate = zw.Create(name + snapshotExtContent); errCreate != nil {
+			return errCreate
+		}
+		if _, err = z.Content.Write(w); err != nil {
+			return err
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// snapshotService writes a snapshot periodically, until the box is stopped.
+func (mb *memBox) snapshotService(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = mb.saveSnapshot()
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,159 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package membox
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/zettel"
+)
+
+func makeSnapshotZettel(zid id.Zid, title, content string) box.Zettel {
+	m := meta.New(zid)
+	m.Set(meta.KeyTitle, meta.Value(title))
+	m.Set(meta.KeySyntax, meta.ValueSyntaxZmk)
+	return box.Zettel{Meta: m, Content: zettel.NewContent([]byte(content))}
+}
+
+func newSnapshotBox(snapshot string, maxZettel, maxBytes int) *memBox {
+	return &memBox{
+		logger:    slog.New(slog.DiscardHandler),
+		snapshot:  snapshot,
+		maxZettel: maxZettel,
+		maxBytes:  maxBytes,
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	t.Parallel()
+	snapshot := filepath.Join(t.TempDir(), "snapshot.zip")
+	zs := []box.Zettel{
+		makeSnapshotZettel(1, "First", "Content of the first zettel"),
+		makeSnapshotZettel(2, "Second", ""),
+		makeSnapshotZettel(3, "Third", "Line 1\nLine 2\n"),
+	}
+	if err := writeSnapshot(snapshot, zs); err != nil {
+		t.Fatal(err)
+	}
+
+	mb := newSnapshotBox(snapshot, 127, 65535)
+	got, curBytes, err := mb.loadSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(zs) {
+		t.Fatalf("expected %d zettel, but got %d", len(zs), len(got))
+	}
+	expBytes := 0
+	for _, z := range zs {
+		expBytes += z.ByteSize()
+		gz, found := got[z.Meta.Zid]
+		if !found {
+			t.Errorf("zettel %v not loaded", z.Meta.Zid)
+			continue
+		}
+		if !gz.Meta.Equal(z.Meta, true) {
+			t.Errorf("metadata of zettel %v: expected %v, but got %v", z.Meta.Zid, z.Meta, gz.Meta)
+		}
+		if exp, act := z.Content.AsString(), gz.Content.AsString(); exp != act {
+			t.Errorf("content of zettel %v: expected %q, but got %q", z.Meta.Zid, exp, act)
+		}
+	}
+	if curBytes != expBytes {
+		t.Errorf("expected %d bytes, but got %d", expBytes, curBytes)
+	}
+}
+
+func TestSnapshotMissing(t *testing.T) {
+	t.Parallel()
+	mb := newSnapshotBox(filepath.Join(t.TempDir(), "missing.zip"), 127, 65535)
+	got, curBytes, err := mb.loadSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 || curBytes != 0 {
+		t.Errorf("missing snapshot must result in an empty box, but got %d zettel / %d bytes", len(got), curBytes)
+	}
+}
+
+func TestSnapshotLimits(t *testing.T) {
+	t.Parallel()
+	snapshot := filepath.Join(t.TempDir(), "snapshot.zip")
+	zs := []box.Zettel{
+		makeSnapshotZettel(1, "One", "1111111111"),
+		makeSnapshotZettel(2, "Two", "2222222222"),
+		makeSnapshotZettel(3, "Three", "3333333333"),
+	}
+	if err := writeSnapshot(snapshot, zs); err != nil {
+		t.Fatal(err)
+	}
+	zsize := zs[0].ByteSize()
+
+	testcases := []struct {
+		name      string
+		maxZettel int
+		maxBytes  int
+		expZettel int
+	}{
+		{"unlimited", 127, 65535, 3},
+		{"max-zettel", 2, 65535, 2},
+		{"max-bytes", 127, 2*zsize + zsize/2, 2},
+		{"too small", 127, zsize - 1, 0},
+	}
+	for _, tc := range testcases {
+		mb := newSnapshotBox(snapshot, tc.maxZettel, tc.maxBytes)
+		got, curBytes, err := mb.loadSnapshot()
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(got) != tc.expZettel {
+			t.Errorf("%s: expected %d zettel, but got %d", tc.name, tc.expZettel, len(got))
+		}
+		if curBytes > tc.maxBytes {
+			t.Errorf("%s: %d bytes loaded, but limit is %d", tc.name, curBytes, tc.maxBytes)
+		}
+	}
+}
+
+func TestSaveSnapshotOnlyIfChanged(t *testing.T) {
+	t.Parallel()
+	snapshot := filepath.Join(t.TempDir(), "snapshot.zip")
+	mb := newSnapshotBox(snapshot, 127, 65535)
+	mb.zettel = map[id.Zid]box.Zettel{1: makeSnapshotZettel(1, "One", "Content")}
+
+	if err := mb.saveSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, err := mb.loadSnapshot(); err != nil || len(got) != 0 {
+		t.Errorf("unchanged box must not write a snapshot, but got %d zettel / %v", len(got), err)
+	}
+
+	mb.changed = true
+	if err := mb.saveSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if mb.changed {
+		t.Error("box must be marked as unchanged after the snapshot was written")
+	}
+	if got, _, err := mb.loadSnapshot(); err != nil || len(got) != 1 {
+		t.Errorf("expected one zettel in snapshot, but got %d / %v", len(got), err)
+	}
+}
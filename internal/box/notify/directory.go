@@ -338,7 +338,7 @@ func (ds *DirService) onUpdateFileEvent(entries entrySet, name string) id.Zid {
 	if entries == nil {
 		return id.Invalid
 	}
-	zid := seekZid(name)
+	zid := seekZid(filepath.Base(name))
 	if zid == id.Invalid {
 		return id.Invalid
 	}
@@ -358,7 +358,7 @@ func (ds *DirService) onDeleteFileEvent(entries entrySet, name string) id.Zid {
 	if entries == nil {
 		return id.Invalid
 	}
-	zid := seekZid(name)
+	zid := seekZid(filepath.Base(name))
 	if zid == id.Invalid {
 		return id.Invalid
 	}
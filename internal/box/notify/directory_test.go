@@ -14,6 +14,9 @@
 package notify
 
 import (
+	"log/slog"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"t73f.de/r/zsc/domain/id"
@@ -73,3 +76,45 @@ func TestNewExtIsBetter(t *testing.T) {
 		}
 	}
 }
+
+func TestDuplicateZidInSubDirs(t *testing.T) {
+	t.Parallel()
+	const zid = id.Zid(20260101000000)
+	top := "20260101000000.zettel"
+	subA := filepath.Join("a", "20260101000000.zettel")
+	subB := filepath.Join("b", "20260101000000.zettel")
+	testcases := [][]string{
+		{top, subA, subB},
+		{subB, subA, top},
+		{subA, top, subB},
+	}
+	for _, names := range testcases {
+		ds := &DirService{logger: slog.New(slog.DiscardHandler)}
+		entries := entrySet{}
+		for i, name := range names {
+			got := ds.onUpdateFileEvent(entries, name)
+			if i == 0 && got != zid {
+				t.Errorf("%v: first file %q must be accepted, but got %v", names, name, got)
+			}
+		}
+		entry := entries[zid]
+		if entry == nil {
+			t.Errorf("%v: no entry for %v", names, zid)
+			continue
+		}
+		if entry.ContentName != top {
+			t.Errorf("%v: file in top directory must be used, but got %q", names, entry.ContentName)
+		}
+		useless := slices.Clone(entry.UselessFiles)
+		slices.Sort(useless)
+		if exp := []string{subA, subB}; !slices.Equal(useless, exp) {
+			t.Errorf("%v: expected duplicates %v, but got %v", names, exp, useless)
+		}
+
+		// Deleting the used file makes a duplicate useful.
+		ds.onDeleteFileEvent(entries, top)
+		if entry = entries[zid]; entry == nil || entry.ContentName != subA {
+			t.Errorf("%v: after delete, %q must be used, but got %v", names, subA, entry)
+		}
+	}
+}
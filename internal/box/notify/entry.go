@@ -51,13 +51,14 @@ func (e *DirEntry) HasMetaInContent() bool {
 }
 
 // SetupFromMetaContent fills entry data based on metadata and zettel content.
-func (e *DirEntry) SetupFromMetaContent(m *meta.Meta, content zettel.Content, isZettelFileSyntax func(string) bool) {
+// New files will be placed in the given sub-directory, which might be empty.
+func (e *DirEntry) SetupFromMetaContent(m *meta.Meta, content zettel.Content, subDir string, isZettelFileSyntax func(string) bool) {
 	if e.Zid != m.Zid {
 		panic("Zid differ")
 	}
 	if contentName := e.ContentName; contentName != "" {
 		if !extIsMetaAndContent(e.ContentExt) && e.MetaName == "" {
-			e.MetaName = e.calcBaseName(contentName)
+			e.MetaName = e.calcBaseName(contentName, subDir)
 		}
 		return
 	}
@@ -70,15 +71,15 @@ func (e *DirEntry) SetupFromMetaContent(m *meta.Meta, content zettel.Content, is
 		if metaName != "" {
 			ext = contentExtWithMeta(syntax, content)
 		}
-		e.ContentName = e.calcBaseName(metaName) + "." + ext
+		e.ContentName = e.calcBaseName(metaName, subDir) + "." + ext
 		e.ContentExt = ext
 	} else {
 		if len(content.AsBytes()) > 0 {
-			e.ContentName = e.calcBaseName(metaName) + "." + ext
+			e.ContentName = e.calcBaseName(metaName, subDir) + "." + ext
 			e.ContentExt = ext
 		}
 		if metaName == "" {
-			e.MetaName = e.calcBaseName(e.ContentName)
+			e.MetaName = e.calcBaseName(e.ContentName, subDir)
 		}
 	}
 }
@@ -109,9 +110,9 @@ func calcContentExt(syntax meta.Value, isZettelFileSyntax func(string) bool) str
 
 }
 
-func (e *DirEntry) calcBaseName(name string) string {
+func (e *DirEntry) calcBaseName(name, subDir string) string {
 	if name == "" {
-		return e.Zid.String()
+		return filepath.Join(subDir, e.Zid.String())
 	}
 	return name[0 : len(name)-len(filepath.Ext(name))]
 
@@ -25,19 +25,21 @@ import (
 )
 
 type fsdirNotifier struct {
-	logger  *slog.Logger
-	events  chan Event
-	done    chan struct{}
-	refresh chan struct{}
-	base    *fsnotify.Watcher
-	path    string
-	fetcher EntryFetcher
-	parent  string
+	logger    *slog.Logger
+	events    chan Event
+	done      chan struct{}
+	refresh   chan struct{}
+	base      *fsnotify.Watcher
+	path      string
+	fetcher   EntryFetcher
+	parent    string
+	recursive bool
+	subDirs   map[string]struct{} // watched sub-directories, if recursive
 }
 
 // NewFSDirNotifier creates a directory based notifier that receives notifications
-// from the file system.
-func NewFSDirNotifier(logger *slog.Logger, path string) (Notifier, error) {
+// from the file system. If recursive is true, sub-directories are supervised too.
+func NewFSDirNotifier(logger *slog.Logger, path string, recursive bool) (Notifier, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		logger.Debug("Unable to create absolute path", "err", err, "path", path)
@@ -68,14 +70,16 @@ func NewFSDirNotifier(logger *slog.Logger, path string) (Notifier, error) {
 	}
 
 	fsdn := &fsdirNotifier{
-		logger:  logger,
-		events:  make(chan Event),
-		refresh: make(chan struct{}),
-		done:    make(chan struct{}),
-		base:    watcher,
-		path:    absPath,
-		fetcher: newDirPathFetcher(absPath),
-		parent:  absParentDir,
+		logger:    logger,
+		events:    make(chan Event),
+		refresh:   make(chan struct{}),
+		done:      make(chan struct{}),
+		base:      watcher,
+		path:      absPath,
+		fetcher:   newDirPathFetcher(absPath, recursive),
+		parent:    absParentDir,
+		recursive: recursive,
+		subDirs:   map[string]struct{}{},
 	}
 	go fsdn.eventLoop()
 	return fsdn, nil
@@ -93,7 +97,7 @@ func (fsdn *fsdirNotifier) eventLoop() {
 	defer func() { _ = fsdn.base.Close() }()
 	defer close(fsdn.events)
 	defer close(fsdn.refresh)
-	if !listDirElements(fsdn.logger, fsdn.fetcher, fsdn.events, fsdn.done) {
+	if !fsdn.listElements() {
 		return
 	}
 
@@ -101,6 +105,44 @@ func (fsdn *fsdirNotifier) eventLoop() {
 	}
 }
 
+// listElements supervises all sub-directories, if needed, and lists all files.
+func (fsdn *fsdirNotifier) listElements() bool {
+	if fsdn.recursive {
+		walkSubDirs(fsdn.path, fsdn.addSubDir)
+	}
+	return listDirElements(fsdn.logger, fsdn.fetcher, fsdn.events, fsdn.done)
+}
+
+func (fsdn *fsdirNotifier) addSubDir(path string) {
+	if _, found := fsdn.subDirs[path]; found {
+		return
+	}
+	if err := fsdn.base.Add(path); err != nil {
+		fsdn.logger.Info("Unable to supervise sub-directory", "err", err, "path", path)
+		return
+	}
+	fsdn.subDirs[path] = struct{}{}
+	logging.LogTrace(fsdn.logger, "Sub-directory added", "path", path)
+}
+
+// removeSubDir stops supervising the given sub-directory and all its
+// sub-directories. It returns false, if the path is not a supervised
+// sub-directory.
+func (fsdn *fsdirNotifier) removeSubDir(path string) bool {
+	if _, found := fsdn.subDirs[path]; !found {
+		return false
+	}
+	prefix := path + string(filepath.Separator)
+	for subDir := range fsdn.subDirs {
+		if subDir == path || strings.HasPrefix(subDir, prefix) {
+			_ = fsdn.base.Remove(subDir)
+			delete(fsdn.subDirs, subDir)
+		}
+	}
+	logging.LogTrace(fsdn.logger, "Sub-directory removed", "path", path)
+	return true
+}
+
 func (fsdn *fsdirNotifier) readAndProcessEvent() bool {
 	select {
 	case <-fsdn.done:
@@ -114,7 +156,7 @@ func (fsdn *fsdirNotifier) readAndProcessEvent() bool {
 		return false
 	case <-fsdn.refresh:
 		logging.LogTrace(fsdn.logger, "refresh")
-		listDirElements(fsdn.logger, fsdn.fetcher, fsdn.events, fsdn.done)
+		fsdn.listElements()
 	case err, ok := <-fsdn.base.Errors:
 		logging.LogTrace(fsdn.logger, "got errors", "err", err, "ok", ok)
 		if !ok {
@@ -143,11 +185,11 @@ func (fsdn *fsdirNotifier) traceDone(pos int64) {
 }
 
 func (fsdn *fsdirNotifier) processEvent(ev *fsnotify.Event) bool {
-	if strings.HasPrefix(ev.Name, fsdn.path) {
-		if len(ev.Name) == len(fsdn.path) {
-			return fsdn.processDirEvent(ev)
-		}
-		return fsdn.processFileEvent(ev)
+	if ev.Name == fsdn.path {
+		return fsdn.processDirEvent(ev)
+	}
+	if name, found := strings.CutPrefix(ev.Name, fsdn.path+string(filepath.Separator)); found {
+		return fsdn.processFileEvent(ev, name)
 	}
 	logging.LogTrace(fsdn.logger, "event does not match", "path", fsdn.path, "name", ev.Name, "op", ev.Op)
 	return true
@@ -178,14 +220,29 @@ func (fsdn *fsdirNotifier) processDirEvent(ev *fsnotify.Event) bool {
 			}
 		}
 		fsdn.logger.Debug("Directory added", "name", fsdn.path)
-		return listDirElements(fsdn.logger, fsdn.fetcher, fsdn.events, fsdn.done)
+		return fsdn.listElements()
 	}
 
 	logging.LogTrace(fsdn.logger, "Directory processed", "name", ev.Name, "op", ev.Op)
 	return true
 }
 
-func (fsdn *fsdirNotifier) processFileEvent(ev *fsnotify.Event) bool {
+func (fsdn *fsdirNotifier) processFileEvent(ev *fsnotify.Event, name string) bool {
+	if fsdn.recursive {
+		if ev.Has(fsnotify.Create) {
+			if fi, err := os.Lstat(ev.Name); err == nil && fi.IsDir() && !isHiddenDir(fi.Name()) {
+				// A new sub-directory might already contain files, e.g. if it was moved.
+				fsdn.logger.Debug("Sub-directory created", "name", ev.Name)
+				return fsdn.listElements()
+			}
+		}
+		if (ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename)) && fsdn.removeSubDir(ev.Name) {
+			// Files of a moved sub-directory are not reported, so list all files again.
+			fsdn.logger.Debug("Sub-directory removed", "name", ev.Name)
+			return fsdn.listElements()
+		}
+	}
+
 	if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) {
 		if fi, err := os.Lstat(ev.Name); err != nil || !fi.Mode().IsRegular() {
 			regular := err == nil && fi.Mode().IsRegular()
@@ -194,18 +251,18 @@ func (fsdn *fsdirNotifier) processFileEvent(ev *fsnotify.Event) bool {
 			return true
 		}
 		logging.LogTrace(fsdn.logger, "File updated", "name", ev.Name, "op", ev.Op)
-		return fsdn.sendEvent(Update, filepath.Base(ev.Name))
+		return fsdn.sendEvent(Update, name)
 	}
 
 	if ev.Has(fsnotify.Rename) {
 		fi, err := os.Lstat(ev.Name)
 		if err != nil {
 			logging.LogTrace(fsdn.logger, "File deleted", "name", ev.Name, "op", ev.Op)
-			return fsdn.sendEvent(Delete, filepath.Base(ev.Name))
+			return fsdn.sendEvent(Delete, name)
 		}
 		if fi.Mode().IsRegular() {
 			logging.LogTrace(fsdn.logger, "File updated", "name", ev.Name, "op", ev.Op)
-			return fsdn.sendEvent(Update, filepath.Base(ev.Name))
+			return fsdn.sendEvent(Update, name)
 		}
 		logging.LogTrace(fsdn.logger, "File not regular", "name", ev.Name)
 		return true
@@ -213,7 +270,7 @@ func (fsdn *fsdirNotifier) processFileEvent(ev *fsnotify.Event) bool {
 
 	if ev.Has(fsnotify.Remove) {
 		logging.LogTrace(fsdn.logger, "File deleted", "name", ev.Name, "op", ev.Op)
-		return fsdn.sendEvent(Delete, filepath.Base(ev.Name))
+		return fsdn.sendEvent(Delete, name)
 	}
 
 	logging.LogTrace(fsdn.logger, "File processed", "name", ev.Name, "op", ev.Op)
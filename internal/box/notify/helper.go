@@ -15,8 +15,11 @@ package notify
 
 import (
 	"archive/zip"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"zettelstore.de/z/internal/logging"
 )
@@ -27,12 +30,18 @@ type EntryFetcher interface {
 }
 
 type dirPathFetcher struct {
-	dirPath string
+	dirPath   string
+	recursive bool
 }
 
-func newDirPathFetcher(dirPath string) EntryFetcher { return &dirPathFetcher{dirPath} }
+func newDirPathFetcher(dirPath string, recursive bool) EntryFetcher {
+	return &dirPathFetcher{dirPath, recursive}
+}
 
 func (dpf *dirPathFetcher) Fetch() ([]string, error) {
+	if dpf.recursive {
+		return dpf.fetchRecursive()
+	}
 	entries, err := os.ReadDir(dpf.dirPath)
 	if err != nil {
 		return nil, err
@@ -47,6 +56,49 @@ func (dpf *dirPathFetcher) Fetch() ([]string, error) {
 	return result, nil
 }
 
+// fetchRecursive returns the names of all files within the directory and its
+// sub-directories, relative to the directory. Hidden sub-directories are ignored.
+func (dpf *dirPathFetcher) fetchRecursive() ([]string, error) {
+	var result []string
+	err := filepath.WalkDir(dpf.dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dpf.dirPath && isHiddenDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		name, err := filepath.Rel(dpf.dirPath, path)
+		if err != nil {
+			return err
+		}
+		result = append(result, name)
+		return nil
+	})
+	return result, err
+}
+
+func isHiddenDir(name string) bool { return strings.HasPrefix(name, ".") }
+
+// walkSubDirs calls fn for every non-hidden sub-directory of the given directory.
+func walkSubDirs(dirPath string, fn func(string)) {
+	_ = filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == dirPath {
+			return nil
+		}
+		if isHiddenDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		fn(path)
+		return nil
+	})
+}
+
 type zipPathFetcher struct {
 	zipPath string
 }
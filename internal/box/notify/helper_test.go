@@ -0,0 +1,71 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFetchRecursive(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	files := []string{
+		"20260101000001.zettel",
+		filepath.Join("sub", "20260101000002.zettel"),
+		filepath.Join("sub", "deeper", "20260101000003.md"),
+		filepath.Join(".hidden", "20260101000004.zettel"),
+		filepath.Join("sub", ".git", "20260101000005.zettel"),
+	}
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := newDirPathFetcher(dir, true).Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	exp := files[:3]
+	slices.Sort(exp)
+	if !slices.Equal(got, exp) {
+		t.Errorf("recursive fetch: expected %v, but got %v", exp, got)
+	}
+
+	got, err = newDirPathFetcher(dir, false).Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp = files[:1]; !slices.Equal(got, exp) {
+		t.Errorf("non-recursive fetch: expected %v, but got %v", exp, got)
+	}
+}
+
+func TestFetchRecursiveMissing(t *testing.T) {
+	t.Parallel()
+	if got, err := newDirPathFetcher(filepath.Join(t.TempDir(), "missing"), true).Fetch(); err == nil {
+		t.Errorf("missing directory must result in an error, but got %v", got)
+	}
+}
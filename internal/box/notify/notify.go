@@ -43,7 +43,8 @@ type EventOp uint8
 // Destroy signals that the container is not there any more. It might me Make later again.
 //
 // Update signals that file Event.Name was created/updated.
-// File name is relative to the container.
+// File name is relative to the container. It may contain the name of a
+// sub-directory.
 //
 // Delete signals that file Event.Name was removed.
 // File name is relative to the container's name.
@@ -27,8 +27,9 @@ type simpleDirNotifier struct {
 }
 
 // NewSimpleDirNotifier creates a directory based notifier that will not receive
-// any notifications from the operating system.
-func NewSimpleDirNotifier(logger *slog.Logger, path string) (Notifier, error) {
+// any notifications from the operating system. If recursive is true, files of
+// sub-directories are listed too.
+func NewSimpleDirNotifier(logger *slog.Logger, path string, recursive bool) (Notifier, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
@@ -38,7 +39,7 @@ func NewSimpleDirNotifier(logger *slog.Logger, path string) (Notifier, error) {
 		events:  make(chan Event),
 		done:    make(chan struct{}),
 		refresh: make(chan struct{}),
-		fetcher: newDirPathFetcher(absPath),
+		fetcher: newDirPathFetcher(absPath, recursive),
 	}
 	go sdn.eventLoop()
 	return sdn, nil
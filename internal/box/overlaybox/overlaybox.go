@@ -0,0 +1,318 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+// Package overlaybox combines a read-only lower box with a writable upper box.
+//
+// Changing a zettel of the lower box stores a copy of it in the upper box
+// (copy-on-write). Deleting such a copy resets the zettel to its original
+// content of the lower box.
+package overlaybox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/id/idset"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/manager"
+	"zettelstore.de/z/internal/kernel"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/query"
+)
+
+// Constants for query parameter
+const (
+	queryLower = "lower"
+	queryUpper = "upper"
+)
+
+func init() {
+	manager.Register(
+		box.SchemeOverlayBox,
+		func(u *url.URL, cdata *manager.ConnectData) (box.ManagedBox, error) {
+			q := u.Query()
+			name := q.Get(manager.QueryName)
+			ob := &overlayBox{
+				logger: kernel.Main.GetLogger(kernel.BoxService).With(
+					"box", box.SchemeOverlayBox, "name", name),
+				name:     name,
+				location: u.String(),
+				cdata:    *cdata,
+			}
+			subData := manager.ConnectData{
+				Config:   cdata.Config,
+				Enricher: ob,
+				Notify:   ob.notifyChanged,
+				Secret:   cdata.Secret,
+			}
+			lower, err := connectLayer(q.Get(queryLower), name+"-"+queryLower, true, &subData)
+			if err != nil {
+				return nil, fmt.Errorf("lower box of overlay %q: %w", name, err)
+			}
+			upper, err := connectLayer(
+				q.Get(queryUpper), name+"-"+queryUpper, box.GetQueryBool(u, manager.QueryReadOnly), &subData)
+			if err != nil {
+				return nil, fmt.Errorf("upper box of overlay %q: %w", name, err)
+			}
+			ob.lower, ob.upper = lower, upper
+			return ob, nil
+		})
+}
+
+func connectLayer(uri, defaultName string, readonly bool, cdata *manager.ConnectData) (box.ManagedBox, error) {
+	if uri == "" {
+		return nil, errors.New("missing box URI")
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case box.SchemeCompBox, box.SchemeConstBox:
+		return nil, fmt.Errorf("box scheme %q not allowed here", u.Scheme)
+	}
+	q := u.Query()
+	if q.Get(manager.QueryName) == "" {
+		q.Set(manager.QueryName, defaultName)
+	}
+	if readonly {
+		q.Set(manager.QueryReadOnly, "")
+	}
+	u.RawQuery = q.Encode()
+	return manager.Connect(u, cdata)
+}
+
+type overlayBox struct {
+	logger   *slog.Logger
+	name     string
+	location string
+	cdata    manager.ConnectData
+	lower    box.ManagedBox
+	upper    box.ManagedBox
+}
+
+func (ob *overlayBox) Name() string     { return ob.name }
+func (ob *overlayBox) Location() string { return ob.location }
+
+// Enrich is called by the layer boxes. All zettel are reported to belong to
+// the overlay box.
+func (ob *overlayBox) Enrich(ctx context.Context, m *meta.Meta, _ string) {
+	ob.cdata.Enricher.Enrich(ctx, m, ob.name)
+}
+
+// notifyChanged is called by the layer boxes. Changes of the lower box are
+// ignored, if the upper box shadows the zettel. If a zettel of the upper box
+// was deleted, the original zettel of the lower box becomes visible again.
+func (ob *overlayBox) notifyChanged(bbox box.BaseBox, zid id.Zid, reason box.UpdateReason) {
+	notify := ob.cdata.Notify
+	if notify == nil {
+		return
+	}
+	ctx := context.Background()
+	switch reason {
+	case box.OnZettel, box.OnCreate, box.OnDelete:
+		if bbox == ob.lower && ob.upper.HasZettel(ctx, zid) {
+			logging.LogTrace(ob.logger, "notifyChanged/shadowed", "zid", zid, "reason", reason)
+			return
+		}
+		if reason == box.OnDelete && bbox == ob.upper && ob.lower.HasZettel(ctx, zid) {
+			reason = box.OnZettel
+		}
+	}
+	logging.LogTrace(ob.logger, "notifyChanged", "zid", zid, "reason", reason)
+	notify(ob, zid, reason)
+}
+
+func (ob *overlayBox) State() box.StartState {
+	for _, layer := range []box.ManagedBox{ob.upper, ob.lower} {
+		if ss, ok := layer.(box.StartStopper); ok {
+			if state := ss.State(); state != box.StartStateStarted {
+				return state
+			}
+		}
+	}
+	return box.StartStateStarted
+}
+
+func (ob *overlayBox) Start(ctx context.Context) error {
+	if ss, ok := ob.lower.(box.StartStopper); ok {
+		if err := ss.Start(ctx); err != nil {
+			return err
+		}
+	}
+	if ss, ok := ob.upper.(box.StartStopper); ok {
+		if err := ss.Start(ctx); err != nil {
+			if ssLower, isLower := ob.lower.(box.StartStopper); isLower {
+				ssLower.Stop(ctx)
+			}
+			return err
+		}
+	}
+	ob.logger.Info("Start overlay", "lower", ob.lower.Location(), "upper", ob.upper.Location())
+	return nil
+}
+
+func (ob *overlayBox) Stop(ctx context.Context) {
+	if ss, ok := ob.upper.(box.StartStopper); ok {
+		ss.Stop(ctx)
+	}
+	if ss, ok := ob.lower.(box.StartStopper); ok {
+		ss.Stop(ctx)
+	}
+}
+
+func (ob *overlayBox) Refresh(ctx context.Context) {
+	for _, layer := range []box.ManagedBox{ob.lower, ob.upper} {
+		if rb, ok := layer.(box.Refresher); ok {
+			rb.Refresh(ctx)
+		}
+	}
+}
+
+func (ob *overlayBox) CanCreateZettel(ctx context.Context) bool {
+	if cb, ok := ob.upper.(box.CreateBox); ok {
+		return cb.CanCreateZettel(ctx)
+	}
+	return false
+}
+
+func (ob *overlayBox) CreateZettel(ctx context.Context, zettel box.Zettel) (id.Zid, error) {
+	if cb, ok := ob.upper.(box.CreateBox); ok {
+		return cb.CreateZettel(ctx, zettel)
+	}
+	return id.Invalid, box.ErrReadOnly
+}
+
+func (ob *overlayBox) GetZettel(ctx context.Context, zid id.Zid) (box.Zettel, error) {
+	z, err := ob.upper.GetZettel(ctx, zid)
+	if _, isErr := errors.AsType[box.ErrZettelNotFound](err); isErr {
+		return ob.lower.GetZettel(ctx, zid)
+	}
+	return z, err
+}
+
+// GetAllZettel returns the visible zettel, and its original version, if the
+// zettel was changed.
+func (ob *overlayBox) GetAllZettel(ctx context.Context, zid id.Zid) []box.Zettel {
+	var result []box.Zettel
+	for _, layer := range []box.ManagedBox{ob.upper, ob.lower} {
+		if z, err := layer.GetZettel(ctx, zid); err == nil {
+			name := ob.name
+			if len(result) > 0 {
+				name = layer.Name()
+			}
+			ob.cdata.Enricher.Enrich(ctx, z.Meta, name)
+			result = append(result, z)
+		}
+	}
+	return result
+}
+
+func (ob *overlayBox) HasZettel(ctx context.Context, zid id.Zid) bool {
+	return ob.upper.HasZettel(ctx, zid) || ob.lower.HasZettel(ctx, zid)
+}
+
+func (ob *overlayBox) ApplyZid(ctx context.Context, handle box.ZidFunc, constraint box.RetrievePredicate) error {
+	seen := idset.New()
+	err := ob.upper.ApplyZid(ctx, func(zid id.Zid) {
+		seen.Add(zid)
+		handle(zid)
+	}, constraint)
+	if err != nil {
+		return err
+	}
+	return ob.lower.ApplyZid(ctx, func(zid id.Zid) {
+		if !seen.Contains(zid) {
+			handle(zid)
+		}
+	}, constraint)
+}
+
+func (ob *overlayBox) ApplyMeta(ctx context.Context, handle box.MetaFunc, constraint box.RetrievePredicate) error {
+	seen := idset.New()
+	err := ob.upper.ApplyMeta(ctx, func(m *meta.Meta) {
+		seen.Add(m.Zid)
+		handle(m)
+	}, constraint)
+	if err != nil {
+		return err
+	}
+	return ob.lower.ApplyMeta(ctx, func(m *meta.Meta) {
+		if !seen.Contains(m.Zid) {
+			handle(m)
+		}
+	}, constraint)
+}
+
+func (ob *overlayBox) CanUpdateZettel(ctx context.Context, zettel box.Zettel) bool {
+	if ub, ok := ob.upper.(box.UpdateBox); ok {
+		return ub.CanUpdateZettel(ctx, zettel)
+	}
+	return false
+}
+
+// UpdateZettel always stores the zettel in the upper box. If the zettel was
+// stored in the lower box only, it is copied up.
+func (ob *overlayBox) UpdateZettel(ctx context.Context, zettel box.Zettel) error {
+	if ub, ok := ob.upper.(box.UpdateBox); ok {
+		err := ub.UpdateZettel(ctx, zettel)
+		logging.LogTrace(ob.logger, "UpdateZettel", "zid", zettel.Meta.Zid, logging.Err(err))
+		return err
+	}
+	return box.ErrReadOnly
+}
+
+func (ob *overlayBox) CanDeleteZettel(ctx context.Context, zid id.Zid) bool {
+	if db, ok := ob.upper.(box.DeleteBox); ok {
+		return db.CanDeleteZettel(ctx, zid)
+	}
+	return false
+}
+
+// DeleteZettel removes the zettel from the upper box. If there is an original
+// version in the lower box, the zettel is reset to it.
+func (ob *overlayBox) DeleteZettel(ctx context.Context, zid id.Zid) error {
+	if !ob.upper.HasZettel(ctx, zid) {
+		if ob.lower.HasZettel(ctx, zid) {
+			return box.ErrReadOnly
+		}
+		return box.ErrZettelNotFound{Zid: zid}
+	}
+	if db, ok := ob.upper.(box.DeleteBox); ok {
+		err := db.DeleteZettel(ctx, zid)
+		logging.LogTrace(ob.logger, "DeleteZettel", "zid", zid, logging.Err(err))
+		return err
+	}
+	return box.ErrReadOnly
+}
+
+func (ob *overlayBox) ReadStats(st *box.ManagedBoxStats) {
+	var upperSt box.ManagedBoxStats
+	ob.upper.ReadStats(&upperSt)
+	st.ReadOnly = upperSt.ReadOnly
+
+	// A changed zettel of the lower box is stored in both boxes, but it must
+	// be counted only once.
+	count := 0
+	if err := ob.ApplyZid(context.Background(), func(id.Zid) { count++ }, query.AlwaysIncluded); err != nil {
+		ob.logger.Error("Unable to count zettel", "err", err)
+	}
+	st.Zettel = count
+	logging.LogTrace(ob.logger, "ReadStats", "zettel", st.Zettel)
+}
@@ -0,0 +1,201 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package overlaybox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/manager"
+	"zettelstore.de/z/internal/zettel"
+)
+
+type testLayer struct {
+	name   string
+	zettel map[id.Zid]box.Zettel
+	notify box.UpdateNotifier
+}
+
+func newTestLayer(name string, zids ...id.Zid) *testLayer {
+	tl := &testLayer{name: name, zettel: map[id.Zid]box.Zettel{}}
+	for _, zid := range zids {
+		tl.zettel[zid] = makeZettel(zid, name)
+	}
+	return tl
+}
+
+func makeZettel(zid id.Zid, content string) box.Zettel {
+	return box.Zettel{Meta: meta.New(zid), Content: zettel.NewContent([]byte(content))}
+}
+
+func (tl *testLayer) Name() string     { return tl.name }
+func (tl *testLayer) Location() string { return tl.name }
+func (tl *testLayer) GetZettel(_ context.Context, zid id.Zid) (box.Zettel, error) {
+	if z, found := tl.zettel[zid]; found {
+		return z, nil
+	}
+	return box.Zettel{}, box.ErrZettelNotFound{Zid: zid}
+}
+func (tl *testLayer) HasZettel(_ context.Context, zid id.Zid) bool {
+	_, found := tl.zettel[zid]
+	return found
+}
+func (tl *testLayer) ApplyZid(_ context.Context, handle box.ZidFunc, _ box.RetrievePredicate) error {
+	for zid := range tl.zettel {
+		handle(zid)
+	}
+	return nil
+}
+func (tl *testLayer) ApplyMeta(_ context.Context, handle box.MetaFunc, _ box.RetrievePredicate) error {
+	for _, z := range tl.zettel {
+		handle(z.Meta)
+	}
+	return nil
+}
+func (tl *testLayer) ReadStats(st *box.ManagedBoxStats) { st.Zettel = len(tl.zettel) }
+func (*testLayer) CanUpdateZettel(context.Context, box.Zettel) bool {
+	return true
+}
+func (tl *testLayer) UpdateZettel(_ context.Context, z box.Zettel) error {
+	tl.zettel[z.Meta.Zid] = z
+	tl.notify(tl, z.Meta.Zid, box.OnZettel)
+	return nil
+}
+func (tl *testLayer) CanDeleteZettel(_ context.Context, zid id.Zid) bool {
+	return tl.HasZettel(context.Background(), zid)
+}
+func (tl *testLayer) DeleteZettel(_ context.Context, zid id.Zid) error {
+	delete(tl.zettel, zid)
+	tl.notify(tl, zid, box.OnDelete)
+	return nil
+}
+
+type testEnricher struct{}
+
+func (testEnricher) Enrich(_ context.Context, m *meta.Meta, boxName string) {
+	m.Set(meta.KeyBoxName, meta.Value(boxName))
+}
+
+type testUpdate struct {
+	zid    id.Zid
+	reason box.UpdateReason
+}
+
+func newTestOverlay() (*overlayBox, *testLayer, *testLayer, *[]testUpdate) {
+	var updates []testUpdate
+	ob := &overlayBox{
+		logger: slog.New(slog.DiscardHandler),
+		name:   "overlay",
+		cdata: manager.ConnectData{
+			Enricher: testEnricher{},
+			Notify: func(_ box.BaseBox, zid id.Zid, reason box.UpdateReason) {
+				updates = append(updates, testUpdate{zid, reason})
+			},
+		},
+	}
+	lower := newTestLayer("lower", 1, 2)
+	upper := newTestLayer("upper", 2, 3)
+	lower.notify, upper.notify = ob.notifyChanged, ob.notifyChanged
+	ob.lower, ob.upper = lower, upper
+	return ob, lower, upper, &updates
+}
+
+func TestOverlayGet(t *testing.T) {
+	ob, _, _, _ := newTestOverlay()
+	ctx := context.Background()
+	for _, tc := range []struct {
+		zid id.Zid
+		exp string
+	}{{1, "lower"}, {2, "upper"}, {3, "upper"}} {
+		z, err := ob.GetZettel(ctx, tc.zid)
+		if err != nil {
+			t.Errorf("GetZettel(%v): %v", tc.zid, err)
+			continue
+		}
+		if got := z.Content.AsString(); got != tc.exp {
+			t.Errorf("GetZettel(%v) should be from %q, but is from %q", tc.zid, tc.exp, got)
+		}
+	}
+	if _, err := ob.GetZettel(ctx, 4); err == nil {
+		t.Error("zettel 4 should not be found")
+	}
+
+	count := 0
+	if err := ob.ApplyZid(ctx, func(id.Zid) { count++ }, nil); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("ApplyZid should report 3 zettel, but got %d", count)
+	}
+
+	var st box.ManagedBoxStats
+	ob.ReadStats(&st)
+	if st.Zettel != 3 {
+		t.Errorf("ReadStats should count shadowed zettel once and report 3 zettel, but got %d", st.Zettel)
+	}
+
+	zs := ob.GetAllZettel(ctx, 2)
+	if len(zs) != 2 {
+		t.Fatalf("GetAllZettel(2) should return two versions, but got %d", len(zs))
+	}
+	if name := zs[1].Meta.GetDefault(meta.KeyBoxName, ""); name != "lower" {
+		t.Errorf("original version should be from box %q, but got %q", "lower", name)
+	}
+}
+
+func TestOverlayCopyOnWriteAndReset(t *testing.T) {
+	ob, lower, upper, updates := newTestOverlay()
+	ctx := context.Background()
+
+	if err := ob.DeleteZettel(ctx, 1); !errors.Is(err, box.ErrReadOnly) {
+		t.Errorf("deleting an original zettel must fail with read-only, but got %v", err)
+	}
+	if err := ob.UpdateZettel(ctx, makeZettel(1, "changed")); err != nil {
+		t.Fatal(err)
+	}
+	if orig := lower.zettel[1]; !upper.HasZettel(ctx, 1) || orig.Content.AsString() != "lower" {
+		t.Error("changed zettel must be copied up, leaving the original untouched")
+	}
+	if err := ob.DeleteZettel(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if z, err := ob.GetZettel(ctx, 1); err != nil || z.Content.AsString() != "lower" {
+		t.Errorf("zettel must be reset to its original, but got %v/%v", z.Content.AsString(), err)
+	}
+	if err := ob.DeleteZettel(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	exp := []testUpdate{{1, box.OnZettel}, {1, box.OnZettel}, {3, box.OnDelete}}
+	if len(*updates) != len(exp) {
+		t.Fatalf("expected updates %v, but got %v", exp, *updates)
+	}
+	for i, u := range exp {
+		if (*updates)[i] != u {
+			t.Errorf("update %d: expected %v, but got %v", i, u, (*updates)[i])
+		}
+	}
+
+	// Changes of shadowed zettel in the lower box are not reported.
+	*updates = nil
+	lower.notify(lower, 2, box.OnZettel)
+	if len(*updates) != 0 {
+		t.Errorf("change of shadowed zettel must not be reported, but got %v", *updates)
+	}
+}
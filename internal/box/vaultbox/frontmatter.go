@@ -0,0 +1,207 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package vaultbox
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+)
+
+// fmField is a field of the YAML front matter. Only a small subset of YAML is
+// supported: scalar values, lists (in block or flow style), and block scalars.
+type fmField struct {
+	key    string
+	values []string
+}
+
+// splitFrontMatter separates the front matter from the Markdown text.
+func splitFrontMatter(data []byte) ([]byte, []byte) {
+	first, rest, found := cutLine(data)
+	if !found || string(bytes.TrimRight(first, " \t\r")) != "---" {
+		return nil, data
+	}
+	for pos := 0; pos < len(rest); {
+		line, _, _ := cutLine(rest[pos:])
+		end := pos + len(line) + 1
+		switch string(bytes.TrimRight(line, " \t\r")) {
+		case "---", "...":
+			return rest[:pos], rest[min(end, len(rest)):]
+		}
+		pos = end
+	}
+	return nil, data
+}
+
+func cutLine(data []byte) ([]byte, []byte, bool) {
+	return bytes.Cut(data, []byte{'\n'})
+}
+
+// parseFrontMatter returns the fields of the front matter in the order of
+// their appearance.
+func parseFrontMatter(src []byte) []fmField {
+	var result []fmField
+	var cur *fmField
+	blockScalar := false
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			if cur == nil {
+				continue
+			}
+			if blockScalar {
+				if len(cur.values) == 0 {
+					cur.values = append(cur.values, trimmed)
+				} else {
+					cur.values[0] += " " + trimmed
+				}
+			} else if item, isItem := strings.CutPrefix(trimmed, "-"); isItem {
+				if item = unquote(strings.TrimSpace(item)); item != "" {
+					cur.values = append(cur.values, item)
+				}
+			}
+			continue
+		}
+
+		key, val, found := strings.Cut(line, ":")
+		if !found {
+			cur = nil
+			continue
+		}
+		result = append(result, fmField{key: strings.ToLower(strings.TrimSpace(key))})
+		cur = &result[len(result)-1]
+		val = strings.TrimSpace(val)
+		blockScalar = strings.HasPrefix(val, "|") || strings.HasPrefix(val, ">")
+		switch {
+		case val == "", blockScalar:
+		case strings.HasPrefix(val, "[") && strings.HasSuffix(val, "]"):
+			for item := range strings.SplitSeq(val[1:len(val)-1], ",") {
+				if item = unquote(strings.TrimSpace(item)); item != "" {
+					cur.values = append(cur.values, item)
+				}
+			}
+		default:
+			cur.values = append(cur.values, unquote(val))
+		}
+	}
+	return result
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 {
+		if first, last := s[0], s[len(s)-1]; first == last && (first == '"' || first == '\'') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+// fmZid returns the zettel identifier stored in the front matter.
+func fmZid(fields []fmField) id.Zid {
+	for _, f := range fields {
+		if (f.key == "zid" || f.key == meta.KeyID) && len(f.values) == 1 {
+			if zid, err := id.Parse(f.values[0]); err == nil {
+				return zid
+			}
+		}
+	}
+	return id.Invalid
+}
+
+// fmAliases returns the alternative names of a note, to be used as a target
+// of a wiki link.
+func fmAliases(fields []fmField) []string {
+	var result []string
+	for _, f := range fields {
+		if f.key == "aliases" || f.key == "alias" {
+			result = append(result, f.values...)
+		}
+	}
+	return result
+}
+
+// makeMeta maps the front matter fields to metadata. Fields that are not
+// valid metadata keys or that are computed by Zettelstore are ignored.
+func makeMeta(zid id.Zid, title string, fields []fmField, modTime time.Time) *meta.Meta {
+	m := meta.New(zid)
+	m.Set(meta.KeyTitle, meta.Value(meta.RemoveNonGraphic(title)))
+	for _, f := range fields {
+		if len(f.values) == 0 {
+			continue
+		}
+		switch f.key {
+		case "zid", meta.KeyID, meta.KeySyntax, "aliases", "alias":
+		case meta.KeyTitle:
+			m.Set(meta.KeyTitle, meta.Value(meta.RemoveNonGraphic(f.values[0])))
+		case meta.KeyTags, "tag":
+			m.Set(meta.KeyTags, makeTags(f.values))
+		case meta.KeyCreated, "date":
+			if ts, ok := makeTimestamp(f.values[0]); ok {
+				m.Set(meta.KeyCreated, ts)
+			}
+		case meta.KeyModified, "updated":
+			if ts, ok := makeTimestamp(f.values[0]); ok {
+				m.Set(meta.KeyModified, ts)
+			}
+		default:
+			if meta.KeyIsValid(f.key) && !meta.IsComputed(f.key) {
+				m.Set(f.key, meta.Value(meta.RemoveNonGraphic(strings.Join(f.values, " "))))
+			}
+		}
+	}
+	if _, found := m.Get(meta.KeyModified); !found && !modTime.IsZero() {
+		m.Set(meta.KeyModified, meta.Value(modTime.Local().Format(id.TimestampLayout)))
+	}
+	m.Set(meta.KeySyntax, meta.ValueSyntaxMarkdown)
+	return m
+}
+
+// makeTags transforms tags of a vault into tags of Zettelstore. A tag must
+// start with "#" and must not contain a space character.
+func makeTags(values []string) meta.Value {
+	tags := make([]string, 0, len(values))
+	for _, val := range values {
+		for tag := range strings.FieldsSeq(strings.ReplaceAll(val, ",", " ")) {
+			if tag = strings.TrimLeft(tag, "#"); tag != "" {
+				tags = append(tags, "#"+tag)
+			}
+		}
+	}
+	return meta.Value(strings.Join(tags, " "))
+}
+
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func makeTimestamp(s string) (meta.Value, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return meta.Value(t.Local().Format(id.TimestampLayout)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,467 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+// Package vaultbox provides a read-only box for a folder of Markdown notes,
+// as used by applications like Obsidian.
+//
+// Every note becomes a zettel. Its zettel identifier is taken from the front
+// matter, or it is derived from the path of the note. Wiki links are
+// transformed into links to other zettel.
+package vaultbox
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/manager"
+	"zettelstore.de/z/internal/kernel"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// queryRescan is the query parameter to specify the number of seconds
+// between two scans of the vault.
+const queryRescan = "rescan"
+
+func init() {
+	manager.Register(
+		box.SchemeVaultBox,
+		func(u *url.URL, cdata *manager.ConnectData) (box.ManagedBox, error) {
+			name := u.Query().Get(manager.QueryName)
+			path := getDirPath(u)
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			return &vaultBox{
+				logger: kernel.Main.GetLogger(kernel.BoxService).With(
+					"box", box.SchemeVaultBox, "name", name),
+				name:     name,
+				location: u.String(),
+				dir:      path,
+				cdata:    *cdata,
+				interval: time.Duration(box.GetQueryInt(u, queryRescan, 0, 0, 86400)) * time.Second,
+			}, nil
+		})
+}
+
+func getDirPath(u *url.URL) string {
+	if u.Opaque != "" {
+		return filepath.Clean(u.Opaque)
+	}
+	return filepath.Clean(u.Path)
+}
+
+// vaultBox presents the Markdown notes of a directory as read-only zettel.
+type vaultBox struct {
+	logger   *slog.Logger
+	name     string
+	location string
+	dir      string
+	cdata    manager.ConnectData
+	interval time.Duration // zero, if the vault is only scanned on refresh
+	done     chan struct{}
+	mxScan   sync.Mutex // Only one scan at a time
+
+	mx      sync.RWMutex
+	entries map[id.Zid]*vaultEntry
+	byPath  map[string]*vaultEntry
+	names   map[string]id.Zid // lower-case link targets
+}
+
+// vaultEntry stores data about one note.
+type vaultEntry struct {
+	zid     id.Zid
+	path    string // relative to the vault, slash separated
+	modTime time.Time
+	size    int64
+	fields  []fmField
+	meta    *meta.Meta
+}
+
+const extMarkdown = ".md"
+
+func (vb *vaultBox) Name() string     { return vb.name }
+func (vb *vaultBox) Location() string { return vb.location }
+
+func (vb *vaultBox) State() box.StartState {
+	vb.mx.RLock()
+	defer vb.mx.RUnlock()
+	if vb.entries == nil {
+		return box.StartStateStopped
+	}
+	return box.StartStateStarted
+}
+
+func (vb *vaultBox) Start(context.Context) error {
+	if err := vb.scan(false); err != nil {
+		return err
+	}
+	if vb.interval > 0 {
+		vb.done = make(chan struct{})
+		go vb.scanService(vb.interval, vb.done)
+	}
+	vb.logger.Info("Start vault", "path", vb.dir, "zettel", vb.numEntries())
+	return nil
+}
+
+func (vb *vaultBox) Stop(context.Context) {
+	if vb.done != nil {
+		close(vb.done)
+		vb.done = nil
+	}
+	vb.mx.Lock()
+	vb.entries, vb.byPath, vb.names = nil, nil, nil
+	vb.mx.Unlock()
+}
+
+func (vb *vaultBox) Refresh(context.Context) {
+	if err := vb.scan(false); err != nil {
+		vb.logger.Error("Unable to scan vault", "err", err, "path", vb.dir)
+	}
+	logging.LogTrace(vb.logger, "Refresh")
+}
+
+// scanService scans the vault periodically, until the box is stopped.
+func (vb *vaultBox) scanService(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := vb.scan(true); err != nil {
+				vb.logger.Error("Unable to scan vault", "err", err, "path", vb.dir)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// scan reads all notes of the vault. Notes that were not changed since the
+// last scan are not read again. If notify is true, changes are reported.
+func (vb *vaultBox) scan(notify bool) error {
+	vb.mxScan.Lock()
+	defer vb.mxScan.Unlock()
+	paths, err := vb.fetchPaths()
+	if err != nil {
+		return err
+	}
+
+	vb.mx.RLock()
+	oldByPath, oldEntries, oldNames := vb.byPath, vb.entries, vb.names
+	vb.mx.RUnlock()
+
+	var changed []id.Zid
+	entries := make([]*vaultEntry, 0, len(paths))
+	for _, p := range paths {
+		fi, errStat := os.Stat(filepath.Join(vb.dir, filepath.FromSlash(p)))
+		if errStat != nil {
+			continue
+		}
+		if entry, found := oldByPath[p]; found && entry.modTime.Equal(fi.ModTime()) && entry.size == fi.Size() {
+			unchanged := *entry // Old entries may still be in use
+			entries = append(entries, &unchanged)
+			continue
+		}
+		fields, errRead := vb.readFields(p)
+		if errRead != nil {
+			vb.logger.Warn("Unable to read note", "err", errRead, "path", p)
+			continue
+		}
+		entries = append(entries, &vaultEntry{path: p, modTime: fi.ModTime(), size: fi.Size(), fields: fields})
+	}
+
+	newEntries, newByPath := vb.assignZids(entries, oldByPath)
+	newNames := calcNames(entries)
+	for _, entry := range entries {
+		if entry.meta == nil {
+			entry.meta = makeMeta(entry.zid, noteTitle(entry.path), entry.fields, entry.modTime)
+			changed = append(changed, entry.zid)
+		}
+	}
+
+	vb.mx.Lock()
+	vb.entries, vb.byPath, vb.names = newEntries, newByPath, newNames
+	vb.mx.Unlock()
+	logging.LogTrace(vb.logger, "Scan", "zettel", len(newEntries), "changed", len(changed))
+
+	if notify && vb.cdata.Notify != nil {
+		vb.notifyChanges(changed, oldEntries, newEntries, !maps.Equal(oldNames, newNames))
+	}
+	return nil
+}
+
+// notifyChanges reports all changed, deleted, and new notes. If the link
+// targets changed, all notes are reported, because their links might now
+// refer to other zettel.
+func (vb *vaultBox) notifyChanges(changed []id.Zid, oldEntries, newEntries map[id.Zid]*vaultEntry, namesChanged bool) {
+	if namesChanged {
+		changed = changed[:0]
+		for zid := range newEntries {
+			changed = append(changed, zid)
+		}
+	}
+	for _, zid := range changed {
+		vb.cdata.Notify(vb, zid, box.OnZettel)
+	}
+	for zid := range oldEntries {
+		if _, found := newEntries[zid]; !found {
+			vb.cdata.Notify(vb, zid, box.OnDelete)
+		}
+	}
+}
+
+// fetchPaths returns the slash separated paths of all Markdown notes,
+// relative to the vault. Hidden files and directories are ignored, e.g. the
+// configuration and the trash folder of the application.
+func (vb *vaultBox) fetchPaths() ([]string, error) {
+	var result []string
+	err := filepath.WalkDir(vb.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == vb.dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.EqualFold(filepath.Ext(p), extMarkdown) {
+			return nil
+		}
+		rel, err := filepath.Rel(vb.dir, p)
+		if err != nil {
+			return err
+		}
+		result = append(result, filepath.ToSlash(rel))
+		return nil
+	})
+	return result, err
+}
+
+func (vb *vaultBox) readFields(p string) ([]fmField, error) {
+	data, err := os.ReadFile(filepath.Join(vb.dir, filepath.FromSlash(p)))
+	if err != nil {
+		return nil, err
+	}
+	fm, _ := splitFrontMatter(data)
+	return parseFrontMatter(fm), nil
+}
+
+// assignZids sets the zettel identifier of all entries. A zettel identifier
+// of the front matter has precedence over a derived one. An already assigned
+// identifier of an unchanged path is kept.
+func (vb *vaultBox) assignZids(entries []*vaultEntry, oldByPath map[string]*vaultEntry) (map[id.Zid]*vaultEntry, map[string]*vaultEntry) {
+	byZid := make(map[id.Zid]*vaultEntry, len(entries))
+	byPath := make(map[string]*vaultEntry, len(entries))
+	var rest []*vaultEntry
+	for _, entry := range entries {
+		byPath[entry.path] = entry
+		if zid := fmZid(entry.fields); zid.IsValid() {
+			if _, found := byZid[zid]; !found {
+				entry.zid = zid
+				byZid[zid] = entry
+				continue
+			}
+			vb.logger.Warn("Duplicate zettel identifier in front matter", "zid", zid, "path", entry.path)
+			entry.meta = nil
+		}
+		rest = append(rest, entry)
+	}
+	for _, entry := range rest {
+		if old, found := oldByPath[entry.path]; found && old.zid.IsValid() {
+			if _, taken := byZid[old.zid]; !taken {
+				if entry.zid != old.zid {
+					entry.zid, entry.meta = old.zid, nil
+				}
+				byZid[entry.zid] = entry
+				continue
+			}
+		}
+		zid := derivedZid(entry.path)
+		for {
+			if _, taken := byZid[zid]; !taken {
+				break
+			}
+			vb.logger.Warn("Derived zettel identifier already used", "zid", zid, "path", entry.path)
+			zid = nextDerivedZid(zid)
+		}
+		if entry.zid != zid {
+			entry.zid, entry.meta = zid, nil
+		}
+		byZid[zid] = entry
+	}
+	return byZid, byPath
+}
+
+// Derived zettel identifier are placed above the range reserved for
+// Zettelstore.
+const (
+	minDerivedZid = 10000000000000
+	numDerivedZid = 90000000000000
+)
+
+// derivedZid calculates a zettel identifier from the path of a note. It stays
+// the same, as long as the note is not renamed or moved.
+func derivedZid(p string) id.Zid {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(p))
+	return id.Zid(minDerivedZid + h.Sum64()%numDerivedZid)
+}
+
+func nextDerivedZid(zid id.Zid) id.Zid {
+	if zid++; zid >= minDerivedZid+numDerivedZid {
+		return minDerivedZid
+	}
+	return zid
+}
+
+// noteTitle returns the file name of a note without its extension.
+func noteTitle(p string) string {
+	base := path.Base(p)
+	return base[:len(base)-len(path.Ext(base))]
+}
+
+// calcNames returns all targets of a wiki link, together with the zettel
+// identifier it refers to. A target is either the path of a note, its file
+// name, or one of its aliases, all without the extension. If a name is
+// ambiguous, the note with the shortest path wins.
+func calcNames(entries []*vaultEntry) map[string]id.Zid {
+	sorted := slices.Clone(entries)
+	slices.SortFunc(sorted, func(a, b *vaultEntry) int {
+		return cmp.Or(cmp.Compare(len(a.path), len(b.path)), cmp.Compare(a.path, b.path))
+	})
+	result := make(map[string]id.Zid, 2*len(entries))
+	add := func(name string, zid id.Zid) {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			if _, found := result[name]; !found {
+				result[name] = zid
+			}
+		}
+	}
+	for _, entry := range sorted {
+		add(strings.TrimSuffix(entry.path, path.Ext(entry.path)), entry.zid)
+	}
+	for _, entry := range sorted {
+		add(noteTitle(entry.path), entry.zid)
+	}
+	for _, entry := range sorted {
+		for _, alias := range fmAliases(entry.fields) {
+			add(alias, entry.zid)
+		}
+	}
+	return result
+}
+
+func (vb *vaultBox) resolve(target string) (id.Zid, bool) {
+	target = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(target)), extMarkdown)
+	vb.mx.RLock()
+	zid, found := vb.names[target]
+	vb.mx.RUnlock()
+	return zid, found
+}
+
+func (vb *vaultBox) getEntry(zid id.Zid) *vaultEntry {
+	vb.mx.RLock()
+	defer vb.mx.RUnlock()
+	return vb.entries[zid]
+}
+
+func (vb *vaultBox) numEntries() int {
+	vb.mx.RLock()
+	defer vb.mx.RUnlock()
+	return len(vb.entries)
+}
+
+func (vb *vaultBox) GetZettel(_ context.Context, zid id.Zid) (box.Zettel, error) {
+	entry := vb.getEntry(zid)
+	if entry == nil {
+		return box.Zettel{}, box.ErrZettelNotFound{Zid: zid}
+	}
+	data, err := os.ReadFile(filepath.Join(vb.dir, filepath.FromSlash(entry.path)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return box.Zettel{}, box.ErrZettelNotFound{Zid: zid}
+		}
+		return box.Zettel{}, err
+	}
+	_, body := splitFrontMatter(data)
+	logging.LogTrace(vb.logger, "GetZettel", "zid", zid)
+	return box.Zettel{
+		Meta:    entry.meta.Clone(),
+		Content: zettel.NewContent(resolveWikiLinks(body, vb.resolve)),
+	}, nil
+}
+
+func (vb *vaultBox) HasZettel(_ context.Context, zid id.Zid) bool {
+	return vb.getEntry(zid) != nil
+}
+
+func (vb *vaultBox) ApplyZid(_ context.Context, handle box.ZidFunc, constraint box.RetrievePredicate) error {
+	vb.mx.RLock()
+	zids := make([]id.Zid, 0, len(vb.entries))
+	for zid := range vb.entries {
+		if constraint(zid) {
+			zids = append(zids, zid)
+		}
+	}
+	vb.mx.RUnlock()
+	logging.LogTrace(vb.logger, "ApplyZid", "entries", len(zids))
+	for _, zid := range zids {
+		handle(zid)
+	}
+	return nil
+}
+
+func (vb *vaultBox) ApplyMeta(ctx context.Context, handle box.MetaFunc, constraint box.RetrievePredicate) error {
+	vb.mx.RLock()
+	metas := make([]*meta.Meta, 0, len(vb.entries))
+	for zid, entry := range vb.entries {
+		if constraint(zid) {
+			metas = append(metas, entry.meta.Clone())
+		}
+	}
+	vb.mx.RUnlock()
+	logging.LogTrace(vb.logger, "ApplyMeta", "entries", len(metas))
+	for _, m := range metas {
+		vb.cdata.Enricher.Enrich(ctx, m, vb.name)
+		handle(m)
+	}
+	return nil
+}
+
+func (vb *vaultBox) ReadStats(st *box.ManagedBoxStats) {
+	st.ReadOnly = true
+	st.Zettel = vb.numEntries()
+	logging.LogTrace(vb.logger, "ReadStats", "zettel", st.Zettel)
+}
@@ -0,0 +1,109 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package vaultbox
+
+import (
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+)
+
+func TestFrontMatter(t *testing.T) {
+	src := []byte(`---
+title: "A note"
+tags: [project, "team work"]
+aliases:
+  - Note A
+  - 'First note'
+zid: 20260102030405
+created: 2026-01-02
+description: >
+  A long
+  text
+---
+Body [[Other]]
+`)
+	fm, body := splitFrontMatter(src)
+	if got := string(body); got != "Body [[Other]]\n" {
+		t.Errorf("wrong body: %q", got)
+	}
+	fields := parseFrontMatter(fm)
+	if zid := fmZid(fields); zid != 20260102030405 {
+		t.Errorf("wrong zid from front matter: %v", zid)
+	}
+	if aliases := fmAliases(fields); len(aliases) != 2 || aliases[1] != "First note" {
+		t.Errorf("wrong aliases: %q", aliases)
+	}
+
+	m := makeMeta(1, "file name", fields, time.Time{})
+	for _, tc := range []struct {
+		key string
+		exp meta.Value
+	}{
+		{meta.KeyTitle, "A note"},
+		{meta.KeyTags, "#project #team #work"},
+		{meta.KeyCreated, "20260102000000"},
+		{meta.KeySyntax, meta.ValueSyntaxMarkdown},
+		{"description", "A long text"},
+	} {
+		if got, _ := m.Get(tc.key); got != tc.exp {
+			t.Errorf("meta %q should be %q, but got %q", tc.key, tc.exp, got)
+		}
+	}
+	if _, found := m.Get("zid"); found {
+		t.Error("key zid must not be part of the metadata")
+	}
+
+	if fm, body = splitFrontMatter([]byte("---\nno end")); fm != nil || string(body) != "---\nno end" {
+		t.Errorf("unterminated front matter must be part of the body, but got %q/%q", fm, body)
+	}
+}
+
+func TestResolveWikiLinks(t *testing.T) {
+	names := map[string]id.Zid{"other": 10000000000001, "dir/note": 10000000000002}
+	resolve := func(target string) (id.Zid, bool) {
+		zid, found := names[target]
+		return zid, found
+	}
+	for _, tc := range []struct{ src, exp string }{
+		{"no links", "no links"},
+		{"see [[other]].", "see [other](10000000000001)."},
+		{"[[other|the *other*]]", "[the \\*other\\*](10000000000001)"},
+		{"[[dir/note#Heading]]", "[dir/note#Heading](10000000000002)"},
+		{"![[other]]", "![other](10000000000001)"},
+		{"[[unknown|Unknown]] note", "Unknown note"},
+		{"`[[other]]` [[other]]", "`[[other]]` [other](10000000000001)"},
+		{"```\n[[other]]\n```\n[[other]]", "```\n[[other]]\n```\n[other](10000000000001)"},
+		{"[[open", "[[open"},
+	} {
+		if got := string(resolveWikiLinks([]byte(tc.src), resolve)); got != tc.exp {
+			t.Errorf("resolveWikiLinks(%q) should be %q, but got %q", tc.src, tc.exp, got)
+		}
+	}
+}
+
+func TestDerivedZid(t *testing.T) {
+	zid := derivedZid("dir/note.md")
+	if zid < minDerivedZid || !zid.IsValid() {
+		t.Errorf("derived zid %v is not valid", zid)
+	}
+	if other := derivedZid("dir/note.md"); other != zid {
+		t.Errorf("derived zid must be stable, but got %v and %v", zid, other)
+	}
+	if next := nextDerivedZid(minDerivedZid + numDerivedZid - 1); next != minDerivedZid {
+		t.Errorf("next derived zid must wrap around, but got %v", next)
+	}
+}
@@ -0,0 +1,158 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package vaultbox
+
+import (
+	"bytes"
+	"strings"
+
+	"t73f.de/r/zsc/domain/id"
+)
+
+// resolveFunc returns the zettel identifier of a wiki link target.
+type resolveFunc func(target string) (id.Zid, bool)
+
+// resolveWikiLinks replaces all wiki links "[[Target|Label]]" with Markdown
+// links to the zettel of the target, so that the Markdown parser is able to
+// detect references to other zettel. Embeds "![[Target]]" become Markdown
+// images. Links within code are not changed. A link to an unknown target is
+// replaced by its label.
+func resolveWikiLinks(src []byte, resolve resolveFunc) []byte {
+	if !bytes.Contains(src, []byte("[[")) {
+		return src
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(src))
+	fence := ""
+	for len(src) > 0 {
+		line, rest, found := cutLine(src)
+		src = rest
+		if marker := fenceMarker(line); marker != "" {
+			if fence == "" {
+				fence = marker
+			} else if strings.HasPrefix(marker, fence) {
+				fence = ""
+			}
+			buf.Write(line)
+		} else if fence != "" {
+			buf.Write(line)
+		} else {
+			resolveLine(&buf, line, resolve)
+		}
+		if found {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// fenceMarker returns the backticks or tildes that start a fenced code block.
+func fenceMarker(line []byte) string {
+	trimmed := bytes.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+	ch := trimmed[0]
+	if ch != '`' && ch != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == ch {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	return string(trimmed[:n])
+}
+
+func resolveLine(buf *bytes.Buffer, line []byte, resolve resolveFunc) {
+	for len(line) > 0 {
+		switch {
+		case line[0] == '`':
+			n := 0
+			for n < len(line) && line[n] == '`' {
+				n++
+			}
+			end := bytes.Index(line[n:], line[:n])
+			if end < 0 {
+				buf.Write(line)
+				return
+			}
+			end += 2 * n
+			buf.Write(line[:end])
+			line = line[end:]
+			continue
+		case bytes.HasPrefix(line, []byte("![[")):
+			if n := writeWikiLink(buf, line[3:], true, resolve); n > 0 {
+				line = line[3+n:]
+				continue
+			}
+		case bytes.HasPrefix(line, []byte("[[")):
+			if n := writeWikiLink(buf, line[2:], false, resolve); n > 0 {
+				line = line[2+n:]
+				continue
+			}
+		}
+		buf.WriteByte(line[0])
+		line = line[1:]
+	}
+}
+
+// writeWikiLink writes the Markdown link for the wiki link that starts at src.
+// It returns the number of bytes consumed, or zero if there is no wiki link.
+func writeWikiLink(buf *bytes.Buffer, src []byte, embed bool, resolve resolveFunc) int {
+	end := bytes.Index(src, []byte("]]"))
+	if end <= 0 {
+		return 0
+	}
+	inner := string(src[:end])
+	if strings.Contains(inner, "[") {
+		return 0
+	}
+	target, label, hasLabel := strings.Cut(inner, "|")
+	target = strings.TrimSpace(target)
+	if pos := strings.IndexAny(target, "#^"); pos >= 0 {
+		target = strings.TrimSpace(target[:pos])
+	}
+	if !hasLabel {
+		label = strings.TrimSpace(inner)
+	}
+	zid, found := resolve(target)
+	if !found {
+		writeLabel(buf, label)
+		return end + 2
+	}
+	if embed {
+		buf.WriteByte('!')
+	}
+	buf.WriteByte('[')
+	writeLabel(buf, label)
+	buf.WriteString("](")
+	buf.WriteString(zid.String())
+	buf.WriteByte(')')
+	return end + 2
+}
+
+func writeLabel(buf *bytes.Buffer, label string) {
+	for i := range len(label) {
+		switch ch := label[i]; ch {
+		case '[', ']', '\\', '*', '_', '`', '<':
+			buf.WriteByte('\\')
+			buf.WriteByte(ch)
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+}
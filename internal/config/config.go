@@ -22,6 +22,7 @@ import (
 
 // Key values that are supported by Config.Get
 const (
+	KeyAutoReload      = "auto-reload"
 	KeyFooterZettel    = "footer-zettel"
 	KeyHomeZettel      = "home-zettel"
 	KeyListsMenuZettel = "lists-menu-zettel"
@@ -69,6 +70,10 @@ type AuthConfig interface {
 
 	// GetVisibility returns the visibility value of the metadata.
 	GetVisibility(m *meta.Meta) meta.Visibility
+
+	// IsTOTPRequired returns true, if users with the given role must
+	// authenticate with a time-based one-time password.
+	IsTOTPRequired(userRole meta.UserRole) bool
 }
 
 // HTMLInsecurity states what kind of insecure HTML is allowed.
@@ -17,6 +17,7 @@ import (
 	"errors"
 	"log/slog"
 	"sync"
+	"time"
 
 	"t73f.de/r/zsc/domain/id"
 
@@ -37,6 +38,12 @@ func (as *authService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger)
 	as.logLevelVar = levelVar
 	as.logger = logger
 	as.descr = descriptionMap{
+		AuthLockoutDuration: {
+			"Lockout duration after failed logins",
+			makeDurationParser(15*time.Minute, 1*time.Minute, 24*time.Hour),
+			true,
+		},
+		AuthLockoutThreshold: {"Failed logins until lockout", parseInt, true},
 		AuthOwner: {
 			"Owner's zettel id",
 			func(val string) (any, error) {
@@ -63,9 +70,11 @@ func (as *authService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger)
 		AuthRefreshMode: {"Refresh mode", parseBool, true},
 	}
 	as.next = interfaceMap{
-		AuthOwner:       id.Invalid,
-		AuthReadonly:    false,
-		AuthRefreshMode: false,
+		AuthLockoutDuration:  15 * time.Minute,
+		AuthLockoutThreshold: 10,
+		AuthOwner:            id.Invalid,
+		AuthReadonly:         false,
+		AuthRefreshMode:      false,
 	}
 }
 
@@ -79,7 +88,9 @@ func (as *authService) Start(*Kernel) error {
 	readonlyMode := as.GetNextConfig(AuthReadonly).(bool)
 	owner := as.GetNextConfig(AuthOwner).(id.Zid)
 	refreshMode := as.GetNextConfig(AuthRefreshMode).(bool)
-	authMgr, err := as.createManager(readonlyMode, owner, refreshMode)
+	lockoutThreshold := as.GetNextConfig(AuthLockoutThreshold).(int)
+	lockoutDuration := as.GetNextConfig(AuthLockoutDuration).(time.Duration)
+	authMgr, err := as.createManager(readonlyMode, owner, refreshMode, lockoutThreshold, lockoutDuration)
 	if err != nil {
 		as.logger.Error("Unable to create manager", "err", err)
 		return err
@@ -103,3 +114,13 @@ func (as *authService) Stop(*Kernel) {
 }
 
 func (*authService) GetStatistics() []KeyValue { return nil }
+
+// getLockoutManager returns the manager of failed logins, if the service is started.
+func (as *authService) getLockoutManager() auth.LockoutManager {
+	as.mxService.RLock()
+	defer as.mxService.RUnlock()
+	if as.manager == nil {
+		return nil
+	}
+	return as.manager
+}
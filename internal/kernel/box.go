@@ -48,6 +48,20 @@ func (ps *boxService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger) {
 			}),
 			true,
 		},
+		BoxIndexWorkers: {
+			"Number of index workers",
+			ps.noFrozen(func(val string) (any, error) {
+				num, err := strconv.Atoi(val)
+				if err != nil {
+					return nil, err
+				}
+				if num < 0 {
+					return nil, errors.New("number of index workers must not be negative")
+				}
+				return num, nil
+			}),
+			true,
+		},
 		BoxURIs: {
 			"Box URI",
 			func(val string) (any, error) {
@@ -61,7 +75,7 @@ func (ps *boxService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger) {
 				case box.SchemeCompBox, box.SchemeConstBox:
 					return nil, fmt.Errorf("box scheme %q not allowed here", uVal.Scheme)
 
-				case box.SchemeDirBox, box.SchemeFileBox, box.SchemeMemoryBox:
+				case box.SchemeDirBox, box.SchemeFileBox, box.SchemeMemoryBox, box.SchemeOverlayBox, box.SchemeVaultBox:
 					// Very valid schemes here
 				default:
 					return nil, fmt.Errorf("unknown box scheme: %s", uVal.Scheme)
@@ -73,6 +87,7 @@ func (ps *boxService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger) {
 	}
 	ps.next = interfaceMap{
 		BoxDefaultDirType: BoxDirTypeNotify,
+		BoxIndexWorkers:   0,
 	}
 }
 
@@ -146,6 +161,15 @@ func (ps *boxService) dumpIndex(w io.Writer) {
 	ps.manager.Dump(w)
 }
 
+func (ps *boxService) checkIndex(repair bool) ([]box.IndexProblem, error) {
+	ps.mxService.RLock()
+	defer ps.mxService.RUnlock()
+	if ps.manager != nil {
+		return ps.manager.CheckIndex(context.Background(), repair)
+	}
+	return nil, nil
+}
+
 func (ps *boxService) Refresh() error {
 	ps.mxService.RLock()
 	defer ps.mxService.RUnlock()
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -49,6 +50,7 @@ const (
 	keyMarkdownDialect   = "markdown-dialect"
 	keyMaxTransclusions  = "max-transclusions"
 	keySiteName          = "site-name"
+	keyTOTPRequired      = "totp-required"
 	keyZettelFileSyntax  = "zettel-file-syntax"
 )
 
@@ -108,6 +110,19 @@ func (cs *configService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger
 		keyMaxTransclusions: {"Maximum number of transclusions", parseInt, true},
 		keySiteName:         {"Site name", parseString, true},
 		ConfigSxMaxNesting:  {"Maximum nesting of Sx calls", parseInt, true},
+		keyTOTPRequired: {
+			"User roles that require one-time passwords",
+			func(val string) (any, error) {
+				var roles []meta.UserRole
+				for _, field := range strings.Fields(val) {
+					if role := meta.Value(field).AsUserRole(); role != meta.UserRoleUnknown {
+						roles = append(roles, role)
+					}
+				}
+				return roles, nil
+			},
+			true,
+		},
 		keyZettelFileSyntax: {
 			"Zettel file syntax",
 			func(val string) (any, error) {
@@ -115,6 +130,7 @@ func (cs *configService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger
 			},
 			true,
 		},
+		config.KeyAutoReload:      {"Auto reload zettel", parseBool, true},
 		config.KeyListsMenuZettel: {"Lists menu", parseZid, true},
 		config.KeyShowBackLinks:   {"Show back links", parseString, true},
 		config.KeyShowFolgeLinks:  {"Show folge links", parseString, true},
@@ -133,7 +149,9 @@ func (cs *configService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger
 		keyMaxTransclusions:       defaultMaxTransclusions,
 		keySiteName:               defaultSiteName,
 		ConfigSxMaxNesting:        32 * 1024,
+		keyTOTPRequired:           []meta.UserRole(nil),
 		keyZettelFileSyntax:       set.New[string](),
+		config.KeyAutoReload:      false,
 		config.KeyListsMenuZettel: id.ZidTOCListsMenu,
 		config.KeyShowBackLinks:   "",
 		config.KeyShowFolgeLinks:  "",
@@ -372,3 +390,10 @@ func (cs *configService) GetVisibility(m *meta.Meta) meta.Visibility {
 	cs.mxService.RUnlock()
 	return vis
 }
+
+// IsTOTPRequired returns true, if users with the given role must authenticate
+// with a time-based one-time password.
+func (cs *configService) IsTOTPRequired(userRole meta.UserRole) bool {
+	roles, ok := cs.GetCurConfig(keyTOTPRequired).([]meta.UserRole)
+	return ok && slices.Contains(roles, userRole)
+}
@@ -23,9 +23,11 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	zerostrings "t73f.de/r/zero/strings"
 
+	"zettelstore.de/z/internal/audit"
 	"zettelstore.de/z/internal/logging"
 )
 
@@ -73,6 +75,14 @@ func (sess *cmdSession) println(args ...string) {
 	_, _ = sess.w.Write(sess.eol)
 }
 
+// audit stores a change made via the console in the audit log.
+func (sess *cmdSession) audit(op audit.Op, detail string) {
+	rec := audit.Record{Op: op, Source: audit.SourceConsole, Detail: detail}
+	if err := sess.kern.auditLog.Append(rec); err != nil {
+		sess.println("Unable to write audit log:", err.Error())
+	}
+}
+
 func (sess *cmdSession) usage(cmd, val string) {
 	sess.println("Usage:", cmd, val)
 }
@@ -138,7 +148,8 @@ var commands = map[string]command{
 		"end this session",
 		func(*cmdSession, string, []string) bool { return false },
 	},
-	"config": {"show configuration keys", cmdConfig},
+	"check-index": {"check consistency of the index", cmdCheckIndex},
+	"config":      {"show configuration keys", cmdConfig},
 	"crlf": {
 		"toggle crlf mode",
 		func(sess *cmdSession, _ string, _ []string) bool {
@@ -193,9 +204,10 @@ var commands = map[string]command{
 		"shutdown Zettelstore",
 		func(sess *cmdSession, _ string, _ []string) bool { sess.kern.Shutdown(false); return false },
 	},
-	"start": {"start service", cmdStart},
-	"stat":  {"show service statistics", cmdStat},
-	"stop":  {"stop service", cmdStop},
+	"start":  {"start service", cmdStart},
+	"stat":   {"show service statistics", cmdStat},
+	"stop":   {"stop service", cmdStop},
+	"unlock": {"show/unlock accounts locked after failed logins", cmdUnlock},
 }
 
 func cmdHelp(sess *cmdSession, _ string, _ []string) bool {
@@ -293,6 +305,7 @@ func cmdSetConfig(sess *cmdSession, cmd string, args []string) bool {
 	newValue := strings.Join(args[2:], " ")
 	if err := srvD.srv.SetConfig(key, newValue); err == nil {
 		logging.LogMandatory(sess.kern.logger, "Update system configuration", "key", key, "value", newValue)
+		sess.audit(audit.OpSetConfig, args[0]+":"+key+"="+newValue)
 	} else {
 		sess.println("Unable to set key", args[1], "to value", newValue, "because:", err.Error())
 	}
@@ -500,6 +513,36 @@ func cmdDumpIndex(sess *cmdSession, _ string, _ []string) bool {
 	return true
 }
 
+func cmdCheckIndex(sess *cmdSession, cmd string, args []string) bool {
+	repair := false
+	if len(args) > 0 {
+		if args[0] != "repair" {
+			sess.usage(cmd, "[repair]")
+			return true
+		}
+		repair = true
+	}
+	problems, err := sess.kern.box.checkIndex(repair)
+	if err != nil {
+		sess.println("Error:", err.Error())
+		return true
+	}
+	if len(problems) == 0 {
+		sess.println("Index is consistent")
+		return true
+	}
+	table := [][]string{{"Kind", "Zettel", "Detail"}}
+	for _, p := range problems {
+		table = append(table, []string{string(p.Kind), p.Zid.String(), p.Detail})
+	}
+	sess.printTable(table)
+	if repair {
+		sess.println("Problems were repaired")
+		sess.audit(audit.OpRepairIndex, strconv.Itoa(len(problems))+" problems")
+	}
+	return true
+}
+
 func cmdRefresh(sess *cmdSession, _ string, _ []string) bool {
 	kern := sess.kern
 	logging.LogMandatory(sess.kern.logger, "Refresh")
@@ -509,6 +552,39 @@ func cmdRefresh(sess *cmdSession, _ string, _ []string) bool {
 	return true
 }
 
+func cmdUnlock(sess *cmdSession, cmd string, args []string) bool {
+	lm := sess.kern.auth.getLockoutManager()
+	if lm == nil {
+		sess.println("Authentication service not started")
+		return true
+	}
+	if len(args) == 0 {
+		lockouts := lm.Lockouts()
+		if len(lockouts) == 0 {
+			sess.println("Nothing is locked")
+			sess.usage(cmd, "IDENT|ADDRESS ...")
+			return true
+		}
+		table := [][]string{{"Kind", "Key", "Failures", "Last Failure", "Locked Until"}}
+		for _, ld := range lockouts {
+			table = append(table, []string{
+				string(ld.Kind), ld.Key, strconv.Itoa(ld.Failures),
+				ld.LastFailure.Local().Format(time.DateTime), ld.LockedUntil.Local().Format(time.DateTime),
+			})
+		}
+		sess.printTable(table)
+		return true
+	}
+	for _, key := range args {
+		if lm.Unlock(key) {
+			sess.audit(audit.OpUnlock, key)
+		} else {
+			sess.println("Not locked:", key)
+		}
+	}
+	return true
+}
+
 func cmdDumpRecover(sess *cmdSession, cmd string, args []string) bool {
 	if len(args) == 0 {
 		sess.usage(cmd, "RECOVER")
@@ -35,6 +35,7 @@ import (
 	"t73f.de/r/zero/semver"
 	"t73f.de/r/zsc/domain/id"
 
+	"zettelstore.de/z/internal/audit"
 	"zettelstore.de/z/internal/auth"
 	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/config"
@@ -70,6 +71,8 @@ type Kernel struct {
 	srvNames map[string]serviceData
 	depStart serviceDependency
 	depStop  serviceDependency // reverse of depStart
+
+	auditLog *audit.Log
 }
 
 type serviceDescr struct {
@@ -181,14 +184,17 @@ const (
 
 // Constants for authentication service keys.
 const (
-	AuthOwner       = "owner"
-	AuthReadonly    = "readonly"
-	AuthRefreshMode = "refresh-mode"
+	AuthLockoutDuration  = "lockout-duration"
+	AuthLockoutThreshold = "lockout-threshold"
+	AuthOwner            = "owner"
+	AuthReadonly         = "readonly"
+	AuthRefreshMode      = "refresh-mode"
 )
 
 // Constants for box service keys.
 const (
 	BoxDefaultDirType = "defdirtype"
+	BoxIndexWorkers   = "index-workers"
 	BoxURIs           = "box-uri-"
 )
 
@@ -215,6 +221,8 @@ const (
 	WebLoopbackZid       = "loopback-zid"
 	WebPersistentCookie  = "persistent"
 	WebProfiling         = "profiling"
+	WebProxyAddresses    = "proxy-addresses"
+	WebProxyHeader       = "proxy-header"
 	WebMaxRequestSize    = "max-request-size"
 	WebSecureCookie      = "secure"
 	WebTokenLifetimeAPI  = "api-lifetime"
@@ -238,7 +246,8 @@ type LogEntry struct {
 }
 
 // CreateAuthManagerFunc is called to create a new auth manager.
-type CreateAuthManagerFunc func(readonly bool, owner id.Zid, refresh bool) (auth.Manager, error)
+type CreateAuthManagerFunc func(
+	readonly bool, owner id.Zid, refresh bool, lockoutThreshold int, lockoutDuration time.Duration) (auth.Manager, error)
 
 // CreateBoxManagerFunc is called to create a new box manager.
 type CreateBoxManagerFunc func(
@@ -685,6 +694,10 @@ func (kern *Kernel) SetCreators(
 	kern.web.setupServer = setupWebServer
 }
 
+// SetAuditLog sets the log that records changes made via the administrator
+// console. It must be called before the kernel is started.
+func (kern *Kernel) SetAuditLog(l *audit.Log) { kern.auditLog = l }
+
 // --- The kernel as a service -------------------------------------------
 
 type kernelService struct {
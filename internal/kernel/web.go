@@ -87,6 +87,8 @@ func (ws *webService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger) {
 		WebMaxRequestSize:   {"Max Request Size", parseInt64, true},
 		WebPersistentCookie: {"Persistent cookie", parseBool, true},
 		WebProfiling:        {"Runtime profiling", parseBool, true},
+		WebProxyAddresses:   {"Trusted proxy addresses", ws.noFrozen(parseAddrPrefixes), true},
+		WebProxyHeader:      {"Trusted proxy identity header", ws.noFrozen(parseString), true},
 		WebSecureCookie:     {"Secure cookie", parseBool, true},
 		WebTokenLifetimeAPI: {
 			"Token lifetime API",
@@ -118,6 +120,8 @@ func (ws *webService) Initialize(levelVar *slog.LevelVar, logger *slog.Logger) {
 		WebMaxRequestSize:    int64(16 * 1024 * 1024),
 		WebPersistentCookie:  false,
 		WebProfiling:         false,
+		WebProxyAddresses:    []netip.Prefix(nil),
+		WebProxyHeader:       "",
 		WebSecureCookie:      true,
 		WebTokenLifetimeAPI:  10 * time.Minute,
 		WebTokenLifetimeHTML: 60 * time.Minute,
@@ -141,6 +145,33 @@ func makeDurationParser(defDur, minDur, maxDur time.Duration) parseFunc {
 	}
 }
 
+// parseAddrPrefixes parses a list of IP addresses and of address prefixes in
+// CIDR notation, separated by space characters. IPv4-mapped IPv6 addresses are
+// stored as IPv4 addresses, because remote addresses are compared this way.
+func parseAddrPrefixes(val string) (any, error) {
+	var result []netip.Prefix
+	for s := range strings.FieldsSeq(val) {
+		if strings.IndexByte(s, '/') >= 0 {
+			prefix, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, err
+			}
+			if addr := prefix.Addr(); addr.Is4In6() && prefix.Bits() >= 96 {
+				prefix = netip.PrefixFrom(addr.Unmap(), prefix.Bits()-96)
+			}
+			result = append(result, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		result = append(result, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return result, nil
+}
+
 var errWrongBasePrefix = errors.New(WebURLPrefix + " does not match " + WebBaseURL)
 
 func (ws *webService) GetLogger() *slog.Logger { return ws.logger }
@@ -152,6 +183,8 @@ func (ws *webService) Start(kern *Kernel) error {
 	listenAddr := ws.GetNextConfig(WebListenAddress).(string)
 	loopbackIdent := ws.GetNextConfig(WebLoopbackIdent).(string)
 	loopbackZid := ws.GetNextConfig(WebLoopbackZid).(id.Zid)
+	proxyHeader := ws.GetNextConfig(WebProxyHeader).(string)
+	proxyAddrs := ws.GetNextConfig(WebProxyAddresses).([]netip.Prefix)
 	urlPrefix := ws.GetNextConfig(WebURLPrefix).(string)
 	persistentCookie := ws.GetNextConfig(WebPersistentCookie).(bool)
 	secureCookie := ws.GetNextConfig(WebSecureCookie).(bool)
@@ -166,6 +199,13 @@ func (ws *webService) Start(kern *Kernel) error {
 	if lap := netip.MustParseAddrPort(listenAddr); !kern.auth.manager.WithAuth() && !lap.Addr().IsLoopback() {
 		ws.logger.Info("service may be reached from outside, but authentication is not enabled", "listen", listenAddr)
 	}
+	if proxyHeader != "" && kern.auth.manager.WithAuth() {
+		if len(proxyAddrs) == 0 {
+			ws.logger.Warn("proxy-header is set, but there are no proxy-addresses", "proxy-header", proxyHeader)
+		} else {
+			ws.logger.Info("Trust identity header of proxies", "proxy-header", proxyHeader, "proxy-addresses", proxyAddrs)
+		}
+	}
 
 	sd := server.ConfigData{
 		Log:              ws.logger,
@@ -176,6 +216,8 @@ func (ws *webService) Start(kern *Kernel) error {
 		Auth:             kern.auth.manager,
 		LoopbackIdent:    loopbackIdent,
 		LoopbackZid:      loopbackZid,
+		ProxyHeader:      proxyHeader,
+		ProxyAddresses:   proxyAddrs,
 		PersistentCookie: persistentCookie,
 		SecureCookie:     secureCookie,
 		Profiling:        profile,
@@ -0,0 +1,60 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package kernel
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestParseAddrPrefixes(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		val string
+		exp []string // nil signals an error
+	}{
+		{"", []string{}},
+		{"192.0.2.1", []string{"192.0.2.1/32"}},
+		{"::1", []string{"::1/128"}},
+		{"192.0.2.17/24 2001:db8::1/32", []string{"192.0.2.0/24", "2001:db8::/32"}},
+		{" 192.0.2.1\t10.0.0.0/8 ", []string{"192.0.2.1/32", "10.0.0.0/8"}},
+		{"::ffff:192.0.2.1", []string{"192.0.2.1/32"}},
+		{"::ffff:192.0.2.0/120", []string{"192.0.2.0/24"}},
+		{"localhost", nil},
+		{"192.0.2.1/33", nil},
+		{"192.0.2.1 300.0.0.1", nil},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.val, func(t *testing.T) {
+			val, err := parseAddrPrefixes(tc.val)
+			if tc.exp == nil {
+				if err == nil {
+					t.Errorf("error expected, but got %v", val)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, prefix := range val.([]netip.Prefix) {
+				got = append(got, prefix.String())
+			}
+			if !slices.Equal(got, tc.exp) {
+				t.Errorf("expected %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/audit"
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+)
+
+// AuditLogPort is the interface used by this use case.
+type AuditLogPort interface {
+	Records(match func(*audit.Record) bool) ([]audit.Record, error)
+}
+
+// GetAuditLog is the data for this use case.
+type GetAuditLog struct {
+	port  AuditLogPort
+	authz auth.AuthzManager
+}
+
+// NewGetAuditLog creates a new use case.
+func NewGetAuditLog(port AuditLogPort, authz auth.AuthzManager) GetAuditLog {
+	return GetAuditLog{port: port, authz: authz}
+}
+
+// Run executes the use case. Only the owner may read the audit log. If the
+// given zettel identifier is valid, only records of that zettel are returned.
+func (uc GetAuditLog) Run(ctx context.Context, zid id.Zid) ([]audit.Record, error) {
+	if uc.authz.WithAuth() {
+		if user := auth.GetCurrentUser(ctx); user == nil || !uc.authz.IsOwner(user.Zid) {
+			return nil, box.NewErrNotAllowed("GetAuditLog", user, zid)
+		}
+	}
+	if !zid.IsValid() {
+		return uc.port.Records(nil)
+	}
+	return uc.port.Records(func(rec *audit.Record) bool { return rec.Zid == zid })
+}
@@ -18,42 +18,169 @@ import (
 	"log/slog"
 	"math/rand/v2"
 	"net/http"
+	"sync"
 	"time"
 
-	"t73f.de/r/webs/ip"
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
 
 	"zettelstore.de/z/internal/auth"
 	"zettelstore.de/z/internal/auth/impl"
+	"zettelstore.de/z/internal/config"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
 )
 
+// AuthenticatePort is the interface used by this use case. It is needed to
+// remove a recovery code from the user zettel after it was used. It must not
+// check access rights, because the user is not authenticated yet.
+type AuthenticatePort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+}
+
 // Authenticate is the data for this use case.
 type Authenticate struct {
-	log       *slog.Logger
-	token     auth.TokenManager
-	ucGetUser *GetUser
+	log        *slog.Logger
+	token      auth.TokenManager
+	authz      auth.AuthzManager
+	lockout    auth.LockoutManager
+	authConfig config.AuthConfig
+	port       AuthenticatePort
+	ucGetUser  *GetUser
+	totpSteps  *totpSteps
 }
 
 // NewAuthenticate creates a new use case.
-func NewAuthenticate(log *slog.Logger, token auth.TokenManager, ucGetUser *GetUser) Authenticate {
+func NewAuthenticate(
+	log *slog.Logger,
+	token auth.TokenManager,
+	authz auth.AuthzManager,
+	lockout auth.LockoutManager,
+	authConfig config.AuthConfig,
+	port AuthenticatePort,
+	ucGetUser *GetUser,
+) Authenticate {
 	return Authenticate{
-		log:       log,
-		token:     token,
-		ucGetUser: ucGetUser,
+		log:        log,
+		token:      token,
+		authz:      authz,
+		lockout:    lockout,
+		authConfig: authConfig,
+		port:       port,
+		ucGetUser:  ucGetUser,
+		totpSteps:  &totpSteps{last: map[id.Zid]int64{}},
 	}
 }
 
-// Run executes the use case.
+// Run executes the use case. Parameter "otp" is a time-based one-time
+// password or a recovery code. It is only checked if the user has enabled
+// one-time passwords. If one-time passwords are required for the user, but
+// not enabled yet, ErrTOTPEnrolment is returned after the credential was
+// checked. In this case, the user may log in with Enrol.
+//
+// Parameter "r" is used to determine the remote address. It may be nil.
 //
-// Parameter "r" is just included to produce better logging messages. It may be nil. Do not use it
-// for other purposes.
-func (uc *Authenticate) Run(ctx context.Context, r *http.Request, ident, credential string, d time.Duration, k auth.TokenKind) ([]byte, error) {
+// Failed attempts are counted per account and per remote address. Further
+// attempts are rejected for some time, without checking the credential. A
+// successful attempt only forgets the failed attempts of the account.
+func (uc *Authenticate) Run(ctx context.Context, r *http.Request, ident, credential, otp string, d time.Duration, k auth.TokenKind) ([]byte, error) {
+	return uc.withLockout(r, ident, func() ([]byte, error) {
+		return uc.authenticate(ctx, r, ident, credential, otp, d, k)
+	})
+}
+
+// TOTPEnrolment is the result of a login that enabled one-time passwords.
+type TOTPEnrolment struct {
+	Token         []byte
+	User          *meta.Meta
+	RecoveryCodes []string
+}
+
+// Enrol executes the use case for a user, who must use one-time passwords,
+// but has not enabled them yet. Parameter "otp" must be a valid time-based
+// one-time password for the new secret, to make sure that the user has stored
+// the secret in an authenticator app. On success, the secret is stored in the
+// user zettel, and the result contains the recovery codes, which are shown
+// only once. Failed attempts are counted as with Run.
+func (uc *Authenticate) Enrol(ctx context.Context, r *http.Request, ident, credential, secret, otp string, d time.Duration, k auth.TokenKind) (*TOTPEnrolment, error) {
+	var result *TOTPEnrolment
+	_, err := uc.withLockout(r, ident, func() ([]byte, error) {
+		var err error
+		result, err = uc.enrol(ctx, r, ident, credential, secret, otp, d, k)
+		if result == nil {
+			return nil, err
+		}
+		return result.Token, err
+	})
+	return result, err
+}
+
+func (uc *Authenticate) withLockout(r *http.Request, ident string, login func() ([]byte, error)) ([]byte, error) {
+	remote := remoteAddr(r)
+	if wait := uc.lockout.CheckLogin(ident, remote); wait > 0 {
+		defer addDelay(time.Now(), 500*time.Millisecond, 100*time.Millisecond)
+		uc.log.Info("Login attempt too early", "ident", ident, "remote", remote, "wait", wait.Round(time.Second))
+		return nil, nil
+	}
+	token, err := login()
+	if token != nil {
+		uc.lockout.LoginSucceeded(ident)
+	} else if err == nil {
+		uc.lockout.LoginFailed(ident, remote)
+	}
+	return token, err
+}
+
+func (uc *Authenticate) authenticate(ctx context.Context, r *http.Request, ident, credential, otp string, d time.Duration, k auth.TokenKind) ([]byte, error) {
+	identMeta, err := uc.checkCredential(ctx, r, ident, credential)
+	if identMeta == nil {
+		return nil, err
+	}
+	if uc.needsEnrolment(identMeta) {
+		uc.log.Info("One-time password required, but not enabled", "ident", ident, "remote", remoteAddr(r))
+		return nil, ErrTOTPEnrolment
+	}
+	if !uc.checkSecondFactor(ctx, r, identMeta, ident, otp) {
+		return nil, nil
+	}
+	return uc.getToken(identMeta, ident, d, k)
+}
+
+func (uc *Authenticate) enrol(ctx context.Context, r *http.Request, ident, credential, secret, otp string, d time.Duration, k auth.TokenKind) (*TOTPEnrolment, error) {
+	identMeta, err := uc.checkCredential(ctx, r, ident, credential)
+	if identMeta == nil {
+		return nil, err
+	}
+	if !uc.needsEnrolment(identMeta) {
+		uc.log.Info("Enrolment of one-time passwords not allowed", "ident", ident, "remote", remoteAddr(r))
+		return nil, nil
+	}
+	step, ok := impl.CheckTOTP(secret, otp, time.Now())
+	if !ok || !uc.totpSteps.use(identMeta.Zid, step) {
+		uc.log.Info("One-time password for enrolment does not match", "ident", ident, "remote", remoteAddr(r))
+		return nil, nil
+	}
+	codes, err := storeTOTP(ctx, uc.port, identMeta.Zid, secret)
+	uc.log.Info("Enable one-time passwords during login", "ident", ident, logging.Err(err))
+	if err != nil {
+		return nil, err
+	}
+	token, err := uc.getToken(identMeta, ident, d, k)
+	if err != nil {
+		return nil, err
+	}
+	return &TOTPEnrolment{Token: token, User: identMeta, RecoveryCodes: codes}, nil
+}
+
+// checkCredential returns the user zettel of the given ident, if the
+// credential is valid.
+func (uc *Authenticate) checkCredential(ctx context.Context, r *http.Request, ident, credential string) (*meta.Meta, error) {
 	identMeta, err := uc.ucGetUser.Run(ctx, ident)
 	defer addDelay(time.Now(), 500*time.Millisecond, 100*time.Millisecond)
 
 	if identMeta == nil || err != nil {
-		uc.log.Info("No user with given ident found", "ident", ident, "err", err, "remote", ip.GetRemoteAddr(r))
+		uc.log.Info("No user with given ident found", "ident", ident, "err", err, "remote", remoteAddr(r))
 		compensateCompare()
 		return nil, err
 	}
@@ -61,19 +188,13 @@ func (uc *Authenticate) Run(ctx context.Context, r *http.Request, ident, credent
 	if hashCred, ok := identMeta.Get(meta.KeyCredential); ok {
 		ok, err = impl.CompareHashAndCredential(string(hashCred), identMeta.Zid, ident, credential)
 		if err != nil {
-			uc.log.Info("Error while comparing credentials", "ident", ident, "err", err, "remote", ip.GetRemoteAddr(r))
+			uc.log.Info("Error while comparing credentials", "ident", ident, "err", err, "remote", remoteAddr(r))
 			return nil, err
 		}
 		if ok {
-			token, err2 := uc.token.GetToken(identMeta, d, k)
-			if err2 != nil {
-				uc.log.Info("Unable to produce authentication token", "ident", ident, "err", err2)
-				return nil, err2
-			}
-			uc.log.Info("Successful", "user", ident)
-			return token, nil
+			return identMeta, nil
 		}
-		uc.log.Info("Credentials don't match", "ident", ident, "remote", ip.GetRemoteAddr(r))
+		uc.log.Info("Credentials don't match", "ident", ident, "remote", remoteAddr(r))
 		return nil, nil
 	}
 	uc.log.Info("No credential stored", "ident", ident)
@@ -81,6 +202,78 @@ func (uc *Authenticate) Run(ctx context.Context, r *http.Request, ident, credent
 	return nil, nil
 }
 
+func (uc *Authenticate) getToken(user *meta.Meta, ident string, d time.Duration, k auth.TokenKind) ([]byte, error) {
+	token, err := uc.token.GetToken(user, d, k)
+	if err != nil {
+		uc.log.Info("Unable to produce authentication token", "ident", ident, "err", err)
+		return nil, err
+	}
+	uc.log.Info("Successful", "user", ident)
+	return token, nil
+}
+
+// needsEnrolment returns true, if one-time passwords are required for the
+// role of the user, but the user has not enabled them.
+func (uc *Authenticate) needsEnrolment(user *meta.Meta) bool {
+	return !auth.HasTOTP(user) && uc.authConfig.IsTOTPRequired(uc.authz.GetUserRole(user))
+}
+
+// checkSecondFactor checks the one-time password, if the user has enabled
+// one-time passwords.
+func (uc *Authenticate) checkSecondFactor(ctx context.Context, r *http.Request, user *meta.Meta, ident, otp string) bool {
+	if !auth.HasTOTP(user) {
+		return true
+	}
+	if otp == "" {
+		uc.log.Info("One-time password missing", "ident", ident, "remote", remoteAddr(r))
+		return false
+	}
+	secret, _ := user.Get(auth.KeyTOTPSecret)
+	if step, ok := impl.CheckTOTP(string(secret), otp, time.Now()); ok {
+		if uc.totpSteps.use(user.Zid, step) {
+			return true
+		}
+		uc.log.Info("One-time password already used", "ident", ident, "remote", remoteAddr(r))
+		return false
+	}
+	if found, err := useRecoveryCode(ctx, uc.port, user.Zid, otp); found {
+		uc.log.Info("Recovery code used", "ident", ident, logging.Err(err))
+		return err == nil
+	}
+	uc.log.Info("One-time password does not match", "ident", ident, "remote", remoteAddr(r))
+	return false
+}
+
+// totpSteps stores the time step of the last one-time password used by an
+// user, so that a password cannot be used twice.
+type totpSteps struct {
+	mx   sync.Mutex
+	last map[id.Zid]int64
+}
+
+func (ts *totpSteps) use(zid id.Zid, step int64) bool {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+	if step <= ts.last[zid] {
+		return false
+	}
+	ts.last[zid] = step
+	return true
+}
+
+// remoteAddr returns the address of the client. It is determined by the web
+// server, which knows whether forwarding headers can be trusted. Otherwise,
+// the address of the peer is used.
+func remoteAddr(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if remote := auth.GetRemoteAddr(r.Context()); remote != "" {
+		return remote
+	}
+	return r.RemoteAddr
+}
+
 // compensateCompare if normal comapare is not possible, to avoid timing hints.
 func compensateCompare() {
 	_, _ = impl.CompareHashAndCredential(
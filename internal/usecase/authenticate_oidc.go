@@ -0,0 +1,211 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/oidc"
+	"zettelstore.de/z/internal/config"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// AuthenticateOIDCPort is the interface used by this use case. It must not
+// check access rights, because the user is not authenticated yet.
+type AuthenticateOIDCPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	CreateZettel(ctx context.Context, zettel zettel.Zettel) (id.Zid, error)
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+}
+
+// AuthenticateOIDCOptions control which users are allowed to log in.
+type AuthenticateOIDCOptions struct {
+	CreateUsers bool // Create a user zettel for an unknown user
+	AllowOwner  bool // Allow the owner to log in
+	SkipTOTP    bool // Allow users that must authenticate with a one-time password
+}
+
+// AuthenticateOIDC is the data for this use case.
+type AuthenticateOIDC struct {
+	log        *slog.Logger
+	token      auth.TokenManager
+	authz      auth.AuthzManager
+	authConfig config.AuthConfig
+	client     *oidc.Client
+	port       AuthenticateOIDCPort
+	ucGetUser  *GetUser
+	opts       AuthenticateOIDCOptions
+}
+
+// NewAuthenticateOIDC creates a new use case.
+func NewAuthenticateOIDC(
+	log *slog.Logger,
+	token auth.TokenManager,
+	authz auth.AuthzManager,
+	authConfig config.AuthConfig,
+	client *oidc.Client,
+	port AuthenticateOIDCPort,
+	ucGetUser *GetUser,
+	opts AuthenticateOIDCOptions,
+) AuthenticateOIDC {
+	return AuthenticateOIDC{
+		log:        log,
+		token:      token,
+		authz:      authz,
+		authConfig: authConfig,
+		client:     client,
+		port:       port,
+		ucGetUser:  ucGetUser,
+		opts:       opts,
+	}
+}
+
+// Start begins a new login. It returns the URL of the identity provider and
+// the state value of the login.
+func (uc *AuthenticateOIDC) Start(ctx context.Context) (string, string, error) {
+	return uc.client.AuthCodeURL(ctx)
+}
+
+// Run completes the login with the code sent by the identity provider. It
+// returns nil, if the user is not allowed to log in.
+func (uc *AuthenticateOIDC) Run(ctx context.Context, state, code string, d time.Duration, k auth.TokenKind) ([]byte, error) {
+	user, err := uc.client.Exchange(ctx, state, code)
+	if err != nil {
+		uc.log.Info("Login via identity provider failed", "err", err)
+		if errors.Is(err, oidc.ErrUnknownState) || errors.Is(err, oidc.ErrNoIdent) ||
+			errors.Is(err, oidc.ErrNoRole) || errors.Is(err, oidc.ErrInvalidToken) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	identMeta, err := uc.ucGetUser.GetUserBySubject(ctx, user.Issuer, user.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identMeta == nil {
+		if !uc.opts.CreateUsers {
+			uc.log.Info("No user zettel for identified user", "ident", user.Ident, "subject", user.Subject)
+			return nil, nil
+		}
+		if identMeta, err = uc.createUser(ctx, user); err != nil {
+			uc.log.Error("Unable to create user zettel", "ident", user.Ident, "err", err)
+			return nil, err
+		}
+		if identMeta == nil {
+			return nil, nil
+		}
+	} else if err = uc.updateRole(ctx, identMeta, user); err != nil {
+		uc.log.Error("Unable to update user role", "ident", user.Ident, "err", err)
+		return nil, err
+	}
+	if !uc.canLogin(identMeta, user.Ident) {
+		return nil, nil
+	}
+
+	token, err := uc.token.GetToken(identMeta, d, k)
+	if err != nil {
+		uc.log.Info("Unable to produce authentication token", "ident", user.Ident, "err", err)
+		return nil, err
+	}
+	uc.log.Info("Successful via identity provider", "user", user.Ident)
+	return token, nil
+}
+
+// canLogin returns true, if the user is allowed to log in via the identity
+// provider. The owner must be allowed explicitly. A one-time password cannot
+// be checked for such a login, so users that must authenticate with it are
+// not allowed, unless the second factor of the provider is trusted.
+func (uc *AuthenticateOIDC) canLogin(user *meta.Meta, ident string) bool {
+	if uc.authz.IsOwner(user.Zid) && !uc.opts.AllowOwner {
+		uc.log.Info("Owner must not log in via identity provider", "ident", ident)
+		return false
+	}
+	if !uc.opts.SkipTOTP && (auth.HasTOTP(user) || uc.authConfig.IsTOTPRequired(uc.authz.GetUserRole(user))) {
+		uc.log.Info("One-time password required, but not checked by identity provider", "ident", ident)
+		return false
+	}
+	return true
+}
+
+// createUser creates a new user zettel for the authenticated user. Another
+// login of the same user might have created it in the meantime. It returns
+// nil, if the user is not allowed to log in or if the user identification is
+// already used by another user zettel.
+func (uc *AuthenticateOIDC) createUser(ctx context.Context, user oidc.User) (*meta.Meta, error) {
+	mxUserZettel.Lock()
+	defer mxUserZettel.Unlock()
+	if identMeta, err := uc.ucGetUser.GetUserBySubject(ctx, user.Issuer, user.Subject); identMeta != nil || err != nil {
+		return identMeta, err
+	}
+	if strings.ContainsFunc(user.Ident, unicode.IsSpace) {
+		uc.log.Info("User identification must not contain space characters", "ident", user.Ident)
+		return nil, nil
+	}
+	if other, err := uc.ucGetUser.GetUserByIdent(ctx, user.Ident); other != nil || err != nil {
+		uc.log.Info("User identification is already used", "ident", user.Ident, logging.Err(err))
+		return nil, err
+	}
+
+	m := meta.New(id.Invalid)
+	if user.Name != "" {
+		m.Set(meta.KeyTitle, meta.Value(user.Name))
+	} else {
+		m.Set(meta.KeyTitle, meta.Value(user.Ident))
+	}
+	m.Set(meta.KeyUserID, meta.Value(user.Ident))
+	m.SetNonEmpty(meta.KeyUserRole, meta.Value(user.Role))
+	m.Set(auth.KeyOIDCIssuer, meta.Value(user.Issuer))
+	m.Set(auth.KeyOIDCSubject, meta.Value(user.Subject))
+	m.Set(meta.KeySyntax, meta.ValueSyntaxNone)
+	if !uc.canLogin(m, user.Ident) {
+		return nil, nil
+	}
+	prepareCreateMeta(m)
+	zid, err := uc.port.CreateZettel(ctx, zettel.Zettel{Meta: m})
+	if err != nil {
+		return nil, err
+	}
+	m.Zid = zid
+	uc.log.Info("User zettel created", "ident", user.Ident, "zid", zid)
+	return m, nil
+}
+
+// updateRole stores the user role claimed by the identity provider in the
+// user zettel. The role of the owner is never changed.
+func (uc *AuthenticateOIDC) updateRole(ctx context.Context, identMeta *meta.Meta, user oidc.User) error {
+	if user.Role == "" || uc.authz.IsOwner(identMeta.Zid) {
+		return nil
+	}
+	if role, _ := identMeta.Get(meta.KeyUserRole); string(role) == user.Role {
+		return nil
+	}
+	uc.log.Info("Update user role", "ident", user.Ident, "role", user.Role)
+	err := updateUserZettel(ctx, uc.port, identMeta.Zid, func(m *meta.Meta) {
+		m.Set(meta.KeyUserRole, meta.Value(user.Role))
+	})
+	if err == nil {
+		identMeta.Set(meta.KeyUserRole, meta.Value(user.Role))
+	}
+	return err
+}
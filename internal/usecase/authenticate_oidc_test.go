@@ -0,0 +1,140 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/oidc"
+	"zettelstore.de/z/internal/config"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// oidcTestAuthz knows the owner and the roles of users.
+type oidcTestAuthz struct{ userTestAuthz }
+
+func (oidcTestAuthz) IsOwner(zid id.Zid) bool { return zid == 1 }
+func (oidcTestAuthz) GetUserRole(user *meta.Meta) meta.UserRole {
+	if user.Zid == 1 {
+		return meta.UserRoleOwner
+	}
+	return user.GetDefault(meta.KeyUserRole, "reader").AsUserRole()
+}
+
+// oidcTestConfig requires one-time passwords for writers.
+type oidcTestConfig struct{ config.AuthConfig }
+
+func (oidcTestConfig) IsTOTPRequired(role meta.UserRole) bool { return role == meta.UserRoleWriter }
+
+// oidcTestPort allows to create zettel.
+type oidcTestPort struct{ userTestPort }
+
+func (op oidcTestPort) CreateZettel(_ context.Context, z zettel.Zettel) (id.Zid, error) {
+	zid := id.Zid(100 + len(op.tokenTestPort))
+	m := z.Meta.Clone()
+	m.Zid = zid
+	op.tokenTestPort[zid] = m
+	return zid, nil
+}
+func (oidcTestPort) UpdateZettel(context.Context, zettel.Zettel) error { return nil }
+
+func newOIDCTest(opts AuthenticateOIDCOptions) (*AuthenticateOIDC, oidcTestPort) {
+	port := oidcTestPort{userTestPort{tokenTestPort{
+		1:  makeTestMeta(1, meta.KeyUserID, "owner"),
+		13: makeTestMeta(13, meta.KeyUserID, "user"),
+	}}}
+	ucGetUser := NewGetUser(userTestAuthz{}, port)
+	uc := NewAuthenticateOIDC(
+		slog.New(slog.DiscardHandler), nil, oidcTestAuthz{}, oidcTestConfig{}, nil, port, &ucGetUser, opts)
+	return &uc, port
+}
+
+func TestOIDCCanLogin(t *testing.T) {
+	t.Parallel()
+	owner := makeTestMeta(1, meta.KeyUserID, "owner")
+	reader := makeTestMeta(13, meta.KeyUserID, "reader", meta.KeyUserRole, "reader")
+	writer := makeTestMeta(14, meta.KeyUserID, "writer", meta.KeyUserRole, "writer")
+	totp := makeTestMeta(15, meta.KeyUserID, "totp", auth.KeyTOTPSecret, "SECRET")
+	testcases := []struct {
+		name string
+		opts AuthenticateOIDCOptions
+		user *meta.Meta
+		exp  bool
+	}{
+		{"owner", AuthenticateOIDCOptions{}, owner, false},
+		{"owner-allowed", AuthenticateOIDCOptions{AllowOwner: true}, owner, true},
+		{"reader", AuthenticateOIDCOptions{}, reader, true},
+		{"totp-required", AuthenticateOIDCOptions{}, writer, false},
+		{"totp-enabled", AuthenticateOIDCOptions{}, totp, false},
+		{"totp-required-skip", AuthenticateOIDCOptions{SkipTOTP: true}, writer, true},
+		{"totp-enabled-skip", AuthenticateOIDCOptions{SkipTOTP: true}, totp, true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, _ := newOIDCTest(tc.opts)
+			if got := uc.canLogin(tc.user, "ident"); got != tc.exp {
+				t.Errorf("expected %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestOIDCCreateUser(t *testing.T) {
+	t.Parallel()
+	const issuer = "https://login.example.com"
+	testcases := []struct {
+		name    string
+		user    oidc.User
+		created bool
+	}{
+		{"new", oidc.User{Issuer: issuer, Subject: "1234", Ident: "alice", Name: "Alice"}, true},
+		{"used-ident", oidc.User{Issuer: issuer, Subject: "1234", Ident: "user"}, false},
+		{"owner-ident", oidc.User{Issuer: issuer, Subject: "1234", Ident: "owner"}, false},
+		{"space", oidc.User{Issuer: issuer, Subject: "1234", Ident: "alice example"}, false},
+		{"totp-required", oidc.User{Issuer: issuer, Subject: "1234", Ident: "alice", Role: "writer"}, false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, port := newOIDCTest(AuthenticateOIDCOptions{CreateUsers: true})
+			m, err := uc.createUser(context.Background(), tc.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (m != nil) != tc.created || (len(port.tokenTestPort) > 2) != tc.created {
+				t.Fatalf("expected created=%v, but got %v", tc.created, m)
+			}
+			if !tc.created {
+				return
+			}
+			created := port.tokenTestPort[m.Zid]
+			for key, exp := range map[string]string{
+				meta.KeyUserID: tc.user.Ident, meta.KeyTitle: tc.user.Name,
+				auth.KeyOIDCIssuer: issuer, auth.KeyOIDCSubject: tc.user.Subject,
+			} {
+				if got := string(created.GetDefault(key, "")); got != exp {
+					t.Errorf("key %q: expected %q, but got %q", key, exp, got)
+				}
+			}
+			if got, err2 := uc.createUser(context.Background(), tc.user); err2 != nil || got == nil || got.Zid != m.Zid {
+				t.Errorf("second login must find created user %v, but got %v (%v)", m.Zid, got, err2)
+			}
+		})
+	}
+}
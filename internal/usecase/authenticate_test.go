@@ -0,0 +1,168 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/impl"
+)
+
+// authTestToken produces a token that contains the user identification.
+type authTestToken struct{ auth.TokenManager }
+
+func (authTestToken) GetToken(user *meta.Meta, _ time.Duration, _ auth.TokenKind) ([]byte, error) {
+	return []byte("token:" + string(user.GetDefault(meta.KeyUserID, ""))), nil
+}
+
+// authTestLockout never locks, but counts failed attempts.
+type authTestLockout struct {
+	auth.LockoutManager
+	failed *int
+}
+
+func (authTestLockout) CheckLogin(string, string) time.Duration { return 0 }
+func (al authTestLockout) LoginFailed(string, string)           { *al.failed++ }
+func (authTestLockout) LoginSucceeded(string)                   {}
+
+// testTOTPCode computes the one-time password for the given secret and time,
+// as an authenticator app does.
+func testTOTPCode(secret string, now time.Time) string {
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(now.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1_000_000)
+}
+
+func newAuthenticateTest(t *testing.T) (*Authenticate, tokenTestPort, *int) {
+	users := tokenTestPort{1: makeTestMeta(1, meta.KeyUserID, "owner")}
+	for zid, ident := range map[id.Zid]string{13: "reader", 14: "writer"} {
+		hashCred, err := impl.HashCredential(zid, ident, "password")
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[zid] = makeTestMeta(zid,
+			meta.KeyUserID, ident, meta.KeyUserRole, ident, meta.KeyCredential, hashCred)
+	}
+	var updates, failed int
+	ucGetUser := NewGetUser(userTestAuthz{}, userTestPort{users})
+	uc := NewAuthenticate(
+		slog.New(slog.DiscardHandler), authTestToken{}, oidcTestAuthz{},
+		authTestLockout{failed: &failed}, oidcTestConfig{},
+		sessionTestPort{tokenTestPort: users, updates: &updates}, &ucGetUser)
+	return &uc, users, &failed
+}
+
+func TestAuthenticateTOTPRequired(t *testing.T) {
+	t.Parallel()
+	uc, _, failed := newAuthenticateTest(t)
+	ctx := context.Background()
+	token, err := uc.Run(ctx, nil, "writer", "password", "", time.Hour, auth.KindwebUI)
+	if !errors.Is(err, ErrTOTPEnrolment) || token != nil {
+		t.Errorf("expected error %v, but got token %q / %v", ErrTOTPEnrolment, token, err)
+	}
+	token, err = uc.Run(ctx, nil, "writer", "wrong", "", time.Hour, auth.KindwebUI)
+	if err != nil || token != nil {
+		t.Errorf("wrong password must not be accepted, but got token %q / %v", token, err)
+	}
+	if *failed != 1 {
+		t.Errorf("expected one failed attempt, but got %d", *failed)
+	}
+}
+
+func TestAuthenticateEnrol(t *testing.T) {
+	t.Parallel()
+	secret := impl.NewTOTPSecret()
+	testcases := []struct {
+		name   string
+		ident  string
+		cred   string
+		secret string
+		otp    string
+		exp    bool
+	}{
+		{"valid", "writer", "password", secret, testTOTPCode(secret, time.Now()), true},
+		{"wrong-otp", "writer", "password", secret, "000000", false},
+		{"no-otp", "writer", "password", secret, "", false},
+		{"no-secret", "writer", "password", "", testTOTPCode(secret, time.Now()), false},
+		{"wrong-password", "writer", "wrong", secret, testTOTPCode(secret, time.Now()), false},
+		{"not-required", "reader", "password", secret, testTOTPCode(secret, time.Now()), false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			uc, users, failed := newAuthenticateTest(t)
+			result, err := uc.Enrol(context.Background(), nil, tc.ident, tc.cred, tc.secret, tc.otp, time.Hour, auth.KindwebUI)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.exp {
+				if result != nil {
+					t.Errorf("expected no login, but got %v", result)
+				}
+				if *failed != 1 {
+					t.Errorf("expected one failed attempt, but got %d", *failed)
+				}
+				if _, found := users[14].Get(auth.KeyTOTPSecret); found {
+					t.Error("secret must not be stored")
+				}
+				return
+			}
+			if result == nil {
+				t.Fatal("expected login")
+			}
+			if got := string(result.Token); got != "token:"+tc.ident {
+				t.Errorf("expected token for %q, but got %q", tc.ident, got)
+			}
+			if got, _ := users[14].Get(auth.KeyTOTPSecret); string(got) != tc.secret {
+				t.Errorf("expected secret %q to be stored, but got %q", tc.secret, got)
+			}
+			recovery, _ := users[14].Get(auth.KeyTOTPRecovery)
+			hashes := recovery.Fields()
+			if len(result.RecoveryCodes) == 0 {
+				t.Error("expected recovery codes")
+			}
+			i := 0
+			for hash := range hashes {
+				if i >= len(result.RecoveryCodes) || hash != impl.HashRecoveryCode(result.RecoveryCodes[i]) {
+					t.Errorf("recovery code %d not stored", i)
+				}
+				i++
+			}
+
+			// Now the user has enabled one-time passwords and must use them.
+			token, err := uc.Run(context.Background(), nil, tc.ident, tc.cred, "", time.Hour, auth.KindwebUI)
+			if err != nil || token != nil {
+				t.Errorf("login without one-time password must fail, but got %q / %v", token, err)
+			}
+		})
+	}
+}
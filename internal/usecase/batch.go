@@ -0,0 +1,379 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// BatchPort is the interface used by this use case.
+//
+// The port must not check the access rights of the current user: the
+// use case checks them in advance, and it must be able to restore deleted
+// zettel in case of a rollback.
+type BatchPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	CanCreateZettel(ctx context.Context) bool
+	CreateZettel(ctx context.Context, zettel zettel.Zettel) (id.Zid, error)
+	CanUpdateZettel(ctx context.Context, zettel zettel.Zettel) bool
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+	CanDeleteZettel(ctx context.Context, zid id.Zid) bool
+	DeleteZettel(ctx context.Context, zid id.Zid) error
+	IsStoredInFirstBox(ctx context.Context, zid id.Zid) bool
+}
+
+// BatchOpKind specifies the operation on a zettel.
+type BatchOpKind int
+
+// Values for BatchOpKind
+const (
+	_ BatchOpKind = iota
+	BatchCreate
+	BatchUpdate
+	BatchDelete
+)
+
+// BatchOp is one operation of a batch.
+//
+// A zettel to be created may be given a label. The content and the metadata
+// values of all zettel within the batch may reference this zettel as
+// "${label}". These references are replaced by the identifier of the created
+// zettel, even if the zettel is created by a later operation. Text like
+// "${name}", where "name" is not a label of the batch, is not changed.
+type BatchOp struct {
+	Kind    BatchOpKind
+	Zid     id.Zid        // Zettel to update or to delete
+	Zettel  zettel.Zettel // Zettel to create or update
+	Version string        // If not empty, the version of the zettel to update or to delete
+	Label   string        // If not empty, the label of the zettel to create
+}
+
+// BatchState is the state of an operation after the batch was executed.
+type BatchState int
+
+// Values for BatchState
+const (
+	_                 BatchState = iota
+	BatchStateOK                 // Operation was applied
+	BatchStateInvalid            // Operation is not valid, nothing was applied
+	BatchStateSkipped            // Operation was not applied
+	BatchStateFailed             // Operation failed, all previous operations were rolled back
+	BatchStateUndone             // Operation was applied, but rolled back later
+	BatchStateLost               // Operation was applied, but could not be rolled back
+)
+
+// BatchResult is the result of one operation.
+type BatchResult struct {
+	State BatchState
+	Zid   id.Zid // Zettel identifier of the operation, for BatchCreate the new one
+	Err   error  // Reason for BatchStateInvalid, BatchStateFailed, BatchStateLost
+}
+
+// ErrBatchFailed is returned, if a batch could not be applied.
+type ErrBatchFailed struct {
+	Err error // First error that occurred
+}
+
+func (err ErrBatchFailed) Error() string { return "batch failed: " + err.Err.Error() }
+func (err ErrBatchFailed) Unwrap() error { return err.Err }
+
+// Batch is the data for this use case.
+type Batch struct {
+	logger *slog.Logger
+	policy auth.Policy
+	port   BatchPort
+	mx     *sync.Mutex // Only one batch at a time
+}
+
+// NewBatch creates a new use case.
+func NewBatch(logger *slog.Logger, policy auth.Policy, port BatchPort) Batch {
+	return Batch{logger: logger, policy: policy, port: port, mx: &sync.Mutex{}}
+}
+
+// Run executes the use case. All operations are validated first. If one of
+// them is not valid, no operation is applied. Otherwise, the operations are
+// applied in their given order. If one of them fails, all operations applied
+// so far are rolled back.
+func (uc *Batch) Run(ctx context.Context, ops []BatchOp) ([]BatchResult, error) {
+	uc.mx.Lock()
+	defer uc.mx.Unlock()
+
+	results := make([]BatchResult, len(ops))
+	items := make([]batchItem, len(ops))
+	var firstErr error
+	for i, op := range ops {
+		item, err := uc.validate(ctx, op)
+		results[i] = BatchResult{State: BatchStateSkipped, Zid: op.Zid}
+		items[i] = item
+		if err != nil {
+			results[i].State, results[i].Err = BatchStateInvalid, err
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr == nil {
+		firstErr = validateBatchLabels(ops, results)
+	}
+	if firstErr != nil {
+		uc.logger.Info("Batch invalid", "ops", len(ops), logging.User(ctx), logging.Err(firstErr))
+		return results, ErrBatchFailed{Err: firstErr}
+	}
+
+	// Labels of zettel not yet created map to an invalid identifier.
+	labels := make(map[string]id.Zid)
+	for _, op := range ops {
+		if op.Kind == BatchCreate && op.Label != "" {
+			labels[op.Label] = id.Invalid
+		}
+	}
+
+	for i, op := range ops {
+		zid, err := uc.apply(ctx, op, &items[i], labels)
+		results[i].Zid = zid
+		if err == nil {
+			results[i].State = BatchStateOK
+			if op.Kind == BatchCreate && op.Label != "" {
+				labels[op.Label] = zid
+			}
+			continue
+		}
+		results[i].State, results[i].Err = BatchStateFailed, err
+		uc.rollback(ctx, ops[:i], items, results)
+		uc.logger.Info("Batch failed", "ops", len(ops), "failed", i, logging.User(ctx), logging.Err(err))
+		return results, ErrBatchFailed{Err: err}
+	}
+
+	// Now all labels are known. Resolve the references to zettel that were
+	// created by a later operation.
+	for i := range ops {
+		if !items[i].unresolved {
+			continue
+		}
+		if err := uc.resolveLater(ctx, results[i].Zid, labels); err != nil {
+			uc.rollback(ctx, ops, items, results)
+			results[i].State, results[i].Err = BatchStateFailed, err
+			uc.logger.Info("Batch failed", "ops", len(ops), "failed", i, logging.User(ctx), logging.Err(err))
+			return results, ErrBatchFailed{Err: err}
+		}
+	}
+	uc.logger.Info("Batch", "ops", len(ops), logging.User(ctx))
+	return results, nil
+}
+
+// batchItem stores data about an operation, which is needed to apply it and
+// to roll it back.
+type batchItem struct {
+	old        zettel.Zettel // Zettel before an update or a delete operation
+	inFirstBox bool          // Old zettel is stored in the first box
+	unchanged  bool          // Update operation did not change the zettel
+	unresolved bool          // Applied zettel still contains label references
+}
+
+// validate checks that the operation is allowed and possible. It returns the
+// data needed to roll back an update or a delete operation.
+func (uc *Batch) validate(ctx context.Context, op BatchOp) (batchItem, error) {
+	user := auth.GetCurrentUser(ctx)
+	switch op.Kind {
+	case BatchCreate:
+		if op.Zettel.Meta == nil {
+			return batchItem{}, errors.New("no zettel to create")
+		}
+		if op.Label != "" && !batchLabel.MatchString(op.Label) {
+			return batchItem{}, fmt.Errorf("invalid label %q", op.Label)
+		}
+		if !uc.policy.CanCreate(user, op.Zettel.Meta) {
+			return batchItem{}, box.NewErrNotAllowed("Create", user, id.Invalid)
+		}
+		if !uc.port.CanCreateZettel(ctx) {
+			return batchItem{}, box.ErrReadOnly
+		}
+		return batchItem{}, nil
+
+	case BatchUpdate, BatchDelete:
+		if !op.Zid.IsValid() {
+			return batchItem{}, box.ErrInvalidZid{Zid: op.Zid.String()}
+		}
+		old, err := uc.port.GetZettel(box.NoEnrichContext(ctx), op.Zid)
+		if err != nil {
+			return batchItem{}, err
+		}
+		if op.Version != "" && op.Version != old.Version() {
+			return batchItem{}, ErrVersionConflict{Current: old}
+		}
+		item := batchItem{old: old, inFirstBox: uc.port.IsStoredInFirstBox(ctx, op.Zid)}
+		if op.Kind == BatchDelete {
+			if !uc.policy.CanDelete(user, old.Meta) {
+				return batchItem{}, box.NewErrNotAllowed("Delete", user, op.Zid)
+			}
+			if !uc.port.CanDeleteZettel(ctx, op.Zid) {
+				return batchItem{}, box.ErrReadOnly
+			}
+			if !item.inFirstBox {
+				// Only the first box stores restored zettel.
+				return batchItem{}, fmt.Errorf("zettel %v is not stored in the first box and cannot be restored", op.Zid)
+			}
+			return item, nil
+		}
+		if op.Zettel.Meta == nil || op.Zettel.Meta.Zid != op.Zid {
+			return batchItem{}, fmt.Errorf("no zettel to update %v", op.Zid)
+		}
+		if !uc.policy.CanWrite(user, old.Meta, op.Zettel.Meta) {
+			return batchItem{}, box.NewErrNotAllowed("Write", user, op.Zid)
+		}
+		if !uc.port.CanUpdateZettel(ctx, op.Zettel) {
+			return batchItem{}, box.ErrReadOnly
+		}
+		return item, nil
+	}
+	return batchItem{}, fmt.Errorf("unknown batch operation %d", op.Kind)
+}
+
+// validateBatchLabels checks that all labels are unique.
+func validateBatchLabels(ops []BatchOp, results []BatchResult) error {
+	defined := make(map[string]bool)
+	for i, op := range ops {
+		if op.Kind != BatchCreate || op.Label == "" {
+			continue
+		}
+		if defined[op.Label] {
+			err := fmt.Errorf("label %q defined twice", op.Label)
+			results[i].State, results[i].Err = BatchStateInvalid, err
+			return err
+		}
+		defined[op.Label] = true
+	}
+	return nil
+}
+
+func (uc *Batch) apply(ctx context.Context, op BatchOp, item *batchItem, labels map[string]id.Zid) (id.Zid, error) {
+	switch op.Kind {
+	case BatchCreate:
+		z, unresolved := resolveBatchLabels(op.Zettel, labels)
+		item.unresolved = unresolved
+		prepareCreateMeta(z.Meta)
+		z.Content.TrimSpace()
+		return uc.port.CreateZettel(ctx, z)
+	case BatchUpdate:
+		z, unresolved := resolveBatchLabels(op.Zettel, labels)
+		item.unresolved = unresolved
+		if z.Equal(item.old, false) {
+			item.unchanged = true
+			return op.Zid, nil
+		}
+		prepareUpdateMeta(z.Meta, item.old.Meta)
+		z.Content.TrimSpace()
+		return op.Zid, uc.port.UpdateZettel(ctx, z)
+	case BatchDelete:
+		return op.Zid, uc.port.DeleteZettel(ctx, op.Zid)
+	}
+	return op.Zid, fmt.Errorf("unknown batch operation %d", op.Kind)
+}
+
+// resolveLater replaces the label references of an already applied zettel.
+func (uc *Batch) resolveLater(ctx context.Context, zid id.Zid, labels map[string]id.Zid) error {
+	z, err := uc.port.GetZettel(box.NoEnrichContext(ctx), zid)
+	if err != nil {
+		return err
+	}
+	z, _ = resolveBatchLabels(z, labels)
+	return uc.port.UpdateZettel(ctx, z)
+}
+
+// rollback reverts all applied operations in reverse order. A created zettel
+// is deleted. An updated zettel that was stored in the first box gets its old
+// content. Otherwise, its copy in the first box is deleted, so that the
+// original zettel of another box becomes visible again. A deleted zettel is
+// restored in the first box.
+func (uc *Batch) rollback(ctx context.Context, ops []BatchOp, items []batchItem, results []BatchResult) {
+	for i := len(ops) - 1; i >= 0; i-- {
+		var err error
+		switch ops[i].Kind {
+		case BatchCreate:
+			err = uc.port.DeleteZettel(ctx, results[i].Zid)
+		case BatchUpdate:
+			switch {
+			case items[i].unchanged && !items[i].unresolved:
+				// Nothing was written.
+			case items[i].inFirstBox:
+				err = uc.port.UpdateZettel(ctx, items[i].old)
+			default:
+				err = uc.port.DeleteZettel(ctx, results[i].Zid)
+			}
+		case BatchDelete:
+			err = uc.port.UpdateZettel(ctx, items[i].old)
+		}
+		if err != nil {
+			uc.logger.Error("Unable to roll back batch operation", "zid", results[i].Zid, "err", err)
+			results[i].State, results[i].Err = BatchStateLost, err
+			continue
+		}
+		results[i].State = BatchStateUndone
+	}
+}
+
+// batchLabel is the syntax of a label, batchLabelRef that of a reference to
+// a label.
+var (
+	batchLabel    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	batchLabelRef = regexp.MustCompile(`\$\{([A-Za-z0-9_-]+)\}`)
+)
+
+// resolveBatchLabels replaces all references to labels by the identifier of
+// the labeled zettel. It returns true, if some references could not be
+// resolved yet, because the labeled zettel was not created until now.
+func resolveBatchLabels(z zettel.Zettel, labels map[string]id.Zid) (zettel.Zettel, bool) {
+	unresolved := false
+	replace := func(s string) string {
+		return batchLabelRef.ReplaceAllStringFunc(s, func(ref string) string {
+			zid, found := labels[ref[2:len(ref)-1]]
+			if !found {
+				return ref
+			}
+			if !zid.IsValid() {
+				unresolved = true
+				return ref
+			}
+			return zid.String()
+		})
+	}
+	if z.Meta != nil {
+		m := z.Meta.Clone()
+		for key, val := range z.Meta.All() {
+			if newVal := replace(string(val)); newVal != string(val) {
+				m.Set(key, meta.Value(newVal))
+			}
+		}
+		z.Meta = m
+	}
+	if !z.Content.IsBinary() {
+		if s := z.Content.AsString(); batchLabelRef.MatchString(s) {
+			z.Content = zettel.NewContent([]byte(replace(s)))
+		}
+	}
+	return z, unresolved
+}
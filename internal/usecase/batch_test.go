@@ -0,0 +1,342 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// batchTestPort simulates a first box, which stores new and updated zettel,
+// and a lower box, whose zettel are shadowed by the first box.
+type batchTestPort struct {
+	first   map[id.Zid]zettel.Zettel
+	lower   map[id.Zid]zettel.Zettel
+	nextZid id.Zid
+	failZid id.Zid // Updating or deleting this zettel fails
+	failNew int    // Creating the failNew-th zettel fails, if greater zero
+	created int
+}
+
+var errBatchTest = errors.New("batch test failure")
+
+func newBatchTestPort() *batchTestPort {
+	return &batchTestPort{
+		first:   map[id.Zid]zettel.Zettel{},
+		lower:   map[id.Zid]zettel.Zettel{},
+		nextZid: id.Zid(20260101000000),
+	}
+}
+
+func (tp *batchTestPort) GetZettel(_ context.Context, zid id.Zid) (zettel.Zettel, error) {
+	if z, found := tp.first[zid]; found {
+		return z, nil
+	}
+	if z, found := tp.lower[zid]; found {
+		return z, nil
+	}
+	return zettel.Zettel{}, box.ErrZettelNotFound{Zid: zid}
+}
+func (*batchTestPort) CanCreateZettel(context.Context) bool { return true }
+func (tp *batchTestPort) CreateZettel(_ context.Context, z zettel.Zettel) (id.Zid, error) {
+	tp.created++
+	if tp.created == tp.failNew {
+		return id.Invalid, errBatchTest
+	}
+	tp.nextZid++
+	z.Meta = z.Meta.Clone()
+	z.Meta.Zid = tp.nextZid
+	tp.first[tp.nextZid] = z
+	return tp.nextZid, nil
+}
+func (*batchTestPort) CanUpdateZettel(context.Context, zettel.Zettel) bool { return true }
+func (tp *batchTestPort) UpdateZettel(_ context.Context, z zettel.Zettel) error {
+	if z.Meta.Zid == tp.failZid {
+		return errBatchTest
+	}
+	tp.first[z.Meta.Zid] = z
+	return nil
+}
+func (tp *batchTestPort) CanDeleteZettel(_ context.Context, zid id.Zid) bool {
+	_, found := tp.first[zid]
+	return found
+}
+func (tp *batchTestPort) DeleteZettel(_ context.Context, zid id.Zid) error {
+	if zid == tp.failZid {
+		return errBatchTest
+	}
+	if _, found := tp.first[zid]; !found {
+		return box.ErrZettelNotFound{Zid: zid}
+	}
+	delete(tp.first, zid)
+	return nil
+}
+func (tp *batchTestPort) IsStoredInFirstBox(_ context.Context, zid id.Zid) bool {
+	_, found := tp.first[zid]
+	return found
+}
+
+// batchTestPolicy allows everything, except to delete denyZid.
+type batchTestPolicy struct {
+	auth.Policy
+	denyZid id.Zid
+}
+
+func (batchTestPolicy) CanCreate(_, _ *meta.Meta) bool   { return true }
+func (batchTestPolicy) CanWrite(_, _, _ *meta.Meta) bool { return true }
+func (tp batchTestPolicy) CanDelete(_ *meta.Meta, m *meta.Meta) bool {
+	return m.Zid != tp.denyZid
+}
+
+func makeBatchZettel(zid id.Zid, content string) zettel.Zettel {
+	m := meta.New(zid)
+	m.Set(meta.KeyTitle, meta.Value("Zettel "+content))
+	return zettel.Zettel{Meta: m, Content: zettel.NewContent([]byte(content))}
+}
+
+func newTestBatch(port *batchTestPort, denyZid id.Zid) Batch {
+	return NewBatch(slog.New(slog.DiscardHandler), batchTestPolicy{denyZid: denyZid}, port)
+}
+
+func checkBatchStates(t *testing.T, results []BatchResult, exp ...BatchState) {
+	t.Helper()
+	if len(results) != len(exp) {
+		t.Fatalf("expected %d results, but got %d", len(exp), len(results))
+	}
+	for i, res := range results {
+		if res.State != exp[i] {
+			t.Errorf("result %d: expected state %d, but got %d (%v)", i, exp[i], res.State, res.Err)
+		}
+	}
+}
+
+func checkBatchContent(t *testing.T, m map[id.Zid]zettel.Zettel, zid id.Zid, exp string) {
+	t.Helper()
+	z, found := m[zid]
+	if !found {
+		t.Errorf("zettel %v not found", zid)
+		return
+	}
+	if got := z.Content.AsString(); got != exp {
+		t.Errorf("zettel %v: expected content %q, but got %q", zid, exp, got)
+	}
+}
+
+const (
+	batchFirstZid = id.Zid(20250101000001)
+	batchLowerZid = id.Zid(20250101000002)
+)
+
+func TestBatchInvalid(t *testing.T) {
+	t.Parallel()
+	port := newBatchTestPort()
+	port.first[batchFirstZid] = makeBatchZettel(batchFirstZid, "first")
+	uc := newTestBatch(port, batchFirstZid)
+
+	results, err := uc.Run(context.Background(), []BatchOp{
+		{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "new")},
+		{Kind: BatchDelete, Zid: batchFirstZid},
+		{Kind: BatchUpdate, Zid: batchLowerZid, Zettel: makeBatchZettel(batchLowerZid, "changed")},
+	})
+	var errBatch ErrBatchFailed
+	if !errors.As(err, &errBatch) {
+		t.Fatalf("expected batch error, but got %v", err)
+	}
+	var errNotAllowed *box.ErrNotAllowed
+	if !errors.As(err, &errNotAllowed) {
+		t.Errorf("expected first error to be not allowed, but got %v", errBatch.Err)
+	}
+	checkBatchStates(t, results, BatchStateSkipped, BatchStateInvalid, BatchStateInvalid)
+	if len(port.first) != 1 || port.created != 0 {
+		t.Errorf("no operation should be applied, but got %d zettel / %d created", len(port.first), port.created)
+	}
+	checkBatchContent(t, port.first, batchFirstZid, "first")
+}
+
+func TestBatchInvalidVersion(t *testing.T) {
+	t.Parallel()
+	port := newBatchTestPort()
+	port.first[batchFirstZid] = makeBatchZettel(batchFirstZid, "first")
+	uc := newTestBatch(port, id.Invalid)
+
+	results, err := uc.Run(context.Background(), []BatchOp{
+		{Kind: BatchUpdate, Zid: batchFirstZid, Zettel: makeBatchZettel(batchFirstZid, "changed"), Version: "outdated"},
+	})
+	var errVersion ErrVersionConflict
+	if !errors.As(err, &errVersion) {
+		t.Fatalf("expected version conflict, but got %v", err)
+	}
+	checkBatchStates(t, results, BatchStateInvalid)
+	checkBatchContent(t, port.first, batchFirstZid, "first")
+}
+
+func TestBatchDeleteLower(t *testing.T) {
+	t.Parallel()
+	port := newBatchTestPort()
+	port.lower[batchLowerZid] = makeBatchZettel(batchLowerZid, "lower")
+	uc := newTestBatch(port, id.Invalid)
+
+	results, err := uc.Run(context.Background(), []BatchOp{{Kind: BatchDelete, Zid: batchLowerZid}})
+	if err == nil {
+		t.Fatal("deleting a zettel of a lower box must fail")
+	}
+	checkBatchStates(t, results, BatchStateInvalid)
+	checkBatchContent(t, port.lower, batchLowerZid, "lower")
+}
+
+func TestBatchRollback(t *testing.T) {
+	t.Parallel()
+	port := newBatchTestPort()
+	port.first[batchFirstZid] = makeBatchZettel(batchFirstZid, "first")
+	port.lower[batchLowerZid] = makeBatchZettel(batchLowerZid, "lower")
+	delZid := id.Zid(20250101000003)
+	port.first[delZid] = makeBatchZettel(delZid, "deleted")
+	failZid := id.Zid(20250101000004)
+	port.first[failZid] = makeBatchZettel(failZid, "fail")
+	port.failZid = failZid
+	uc := newTestBatch(port, id.Invalid)
+
+	results, err := uc.Run(context.Background(), []BatchOp{
+		{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "new")},
+		{Kind: BatchUpdate, Zid: batchFirstZid, Zettel: makeBatchZettel(batchFirstZid, "first changed")},
+		{Kind: BatchUpdate, Zid: batchLowerZid, Zettel: makeBatchZettel(batchLowerZid, "lower changed")},
+		{Kind: BatchDelete, Zid: delZid},
+		{Kind: BatchUpdate, Zid: failZid, Zettel: makeBatchZettel(failZid, "fail changed")},
+		{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "never")},
+	})
+	if !errors.Is(err, errBatchTest) {
+		t.Fatalf("expected test failure, but got %v", err)
+	}
+	checkBatchStates(t, results,
+		BatchStateUndone, BatchStateUndone, BatchStateUndone, BatchStateUndone, BatchStateFailed, BatchStateSkipped)
+
+	if len(port.first) != 3 {
+		t.Errorf("expected 3 zettel in first box, but got %d: %v", len(port.first), port.first)
+	}
+	checkBatchContent(t, port.first, batchFirstZid, "first")
+	checkBatchContent(t, port.first, delZid, "deleted")
+	checkBatchContent(t, port.first, failZid, "fail")
+	if _, found := port.first[batchLowerZid]; found {
+		t.Errorf("updated zettel %v of lower box must be deleted from first box", batchLowerZid)
+	}
+	checkBatchContent(t, port.lower, batchLowerZid, "lower")
+}
+
+func TestBatchRollbackLost(t *testing.T) {
+	t.Parallel()
+	port := newBatchTestPort()
+	port.failNew = 2
+	port.failZid = port.nextZid + 1 // Deleting the first created zettel fails
+	uc := newTestBatch(port, id.Invalid)
+
+	results, err := uc.Run(context.Background(), []BatchOp{
+		{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "one")},
+		{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "two")},
+	})
+	if !errors.Is(err, errBatchTest) {
+		t.Fatalf("expected test failure, but got %v", err)
+	}
+	checkBatchStates(t, results, BatchStateLost, BatchStateFailed)
+	if results[0].Zid != port.failZid || !errors.Is(results[0].Err, errBatchTest) {
+		t.Errorf("expected lost zettel %v, but got %v (%v)", port.failZid, results[0].Zid, results[0].Err)
+	}
+}
+
+func TestBatchUnchangedLower(t *testing.T) {
+	t.Parallel()
+	port := newBatchTestPort()
+	port.lower[batchLowerZid] = makeBatchZettel(batchLowerZid, "lower")
+	port.failNew = 1
+	uc := newTestBatch(port, id.Invalid)
+
+	results, err := uc.Run(context.Background(), []BatchOp{
+		{Kind: BatchUpdate, Zid: batchLowerZid, Zettel: makeBatchZettel(batchLowerZid, "lower")},
+		{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "new")},
+	})
+	if !errors.Is(err, errBatchTest) {
+		t.Fatalf("expected test failure, but got %v", err)
+	}
+	checkBatchStates(t, results, BatchStateUndone, BatchStateFailed)
+	if len(port.first) != 0 {
+		t.Errorf("first box must be empty, but got %v", port.first)
+	}
+}
+
+func TestBatchLabels(t *testing.T) {
+	t.Parallel()
+	port := newBatchTestPort()
+	port.first[batchFirstZid] = makeBatchZettel(batchFirstZid, "first")
+	uc := newTestBatch(port, id.Invalid)
+
+	zA := makeBatchZettel(id.Invalid, "See [[${b}]] and ${HOME}")
+	zA.Meta.Set("next", meta.Value("${b}"))
+	results, err := uc.Run(context.Background(), []BatchOp{
+		{Kind: BatchCreate, Zettel: zA, Label: "a"},
+		{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "See [[${a}]]"), Label: "b"},
+		{Kind: BatchUpdate, Zid: batchFirstZid, Zettel: makeBatchZettel(batchFirstZid, "${a} ${b}")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBatchStates(t, results, BatchStateOK, BatchStateOK, BatchStateOK)
+	zidA, zidB := results[0].Zid, results[1].Zid
+	checkBatchContent(t, port.first, zidA, "See [["+zidB.String()+"]] and ${HOME}")
+	checkBatchContent(t, port.first, zidB, "See [["+zidA.String()+"]]")
+	checkBatchContent(t, port.first, batchFirstZid, zidA.String()+" "+zidB.String())
+	if got := port.first[zidA].Meta.GetDefault("next", ""); got != meta.Value(zidB.String()) {
+		t.Errorf("expected metadata next=%v, but got %q", zidB, got)
+	}
+	if got := zA.Meta.GetDefault("next", ""); got != "${b}" {
+		t.Errorf("zettel of operation must not be changed, but got %q", got)
+	}
+}
+
+func TestBatchLabelsInvalid(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name   string
+		labels []string
+		exp    string
+	}{
+		{"syntax", []string{"a b"}, "invalid label"},
+		{"twice", []string{"a", "a"}, "defined twice"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			port := newBatchTestPort()
+			uc := newTestBatch(port, id.Invalid)
+			ops := make([]BatchOp, len(tc.labels))
+			for i, label := range tc.labels {
+				ops[i] = BatchOp{Kind: BatchCreate, Zettel: makeBatchZettel(id.Invalid, "x"), Label: label}
+			}
+			_, err := uc.Run(context.Background(), ops)
+			if err == nil || !strings.Contains(err.Error(), tc.exp) {
+				t.Errorf("expected error %q, but got %v", tc.exp, err)
+			}
+			if port.created != 0 {
+				t.Errorf("no zettel should be created, but got %d", port.created)
+			}
+		})
+	}
+}
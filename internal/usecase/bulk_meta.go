@@ -0,0 +1,275 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// BulkMetaPort is the interface used by this use case.
+//
+// The port must not check the access rights of the current user, because the
+// use case checks them for every zettel.
+type BulkMetaPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	CanUpdateZettel(ctx context.Context, zettel zettel.Zettel) bool
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+}
+
+// BulkMetaOpKind specifies the operation on the metadata of a zettel.
+type BulkMetaOpKind int
+
+// Values for BulkMetaOpKind
+const (
+	_              BulkMetaOpKind = iota
+	BulkMetaAdd                   // Add values to a set, or set a missing value
+	BulkMetaRemove                // Remove values from a set, or remove the key
+	BulkMetaSet                   // Set the value
+	BulkMetaRename                // Replace one value by another
+)
+
+// BulkMetaOp is one operation on the metadata of all selected zettel.
+type BulkMetaOp struct {
+	Kind     BulkMetaOpKind
+	Key      string
+	Value    meta.Value // Value to add, remove, set, or to rename
+	NewValue meta.Value // New value for BulkMetaRename
+}
+
+// BulkMetaState is the state of a zettel after the operations were applied.
+type BulkMetaState int
+
+// Values for BulkMetaState
+const (
+	_                 BulkMetaState = iota
+	BulkMetaChanged                 // Metadata was changed
+	BulkMetaUnchanged               // Operations did not change the metadata
+	BulkMetaDenied                  // Current user is not allowed to change the zettel
+	BulkMetaFailed                  // Zettel could not be changed
+)
+
+// BulkMetaResult is the result for one zettel.
+type BulkMetaResult struct {
+	State BulkMetaState
+	Zid   id.Zid
+	Err   error // Reason for BulkMetaDenied and BulkMetaFailed
+}
+
+// ErrInvalidBulkMetaOp is returned if an operation cannot be applied.
+type ErrInvalidBulkMetaOp struct{ Reason string }
+
+func (err ErrInvalidBulkMetaOp) Error() string { return "invalid metadata operation: " + err.Reason }
+
+// BulkMeta is the data for this use case.
+type BulkMeta struct {
+	logger *slog.Logger
+	policy auth.Policy
+	port   BulkMetaPort
+	mx     *sync.Mutex // Only one bulk operation at a time
+}
+
+// NewBulkMeta creates a new use case.
+func NewBulkMeta(logger *slog.Logger, policy auth.Policy, port BulkMetaPort) BulkMeta {
+	return BulkMeta{logger: logger, policy: policy, port: port, mx: &sync.Mutex{}}
+}
+
+// Run executes the use case. The operations are applied in their given order
+// to the metadata of every given zettel, typically the result of a query.
+// Zettel that the current user is not allowed to change are left untouched.
+// A zettel that cannot be changed does not prevent changes to the other
+// zettel.
+func (uc *BulkMeta) Run(ctx context.Context, metaSeq []*meta.Meta, ops []BulkMetaOp) ([]BulkMetaResult, error) {
+	ops, err := normalizeBulkMetaOps(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.mx.Lock()
+	defer uc.mx.Unlock()
+
+	user := auth.GetCurrentUser(ctx)
+	results := make([]BulkMetaResult, len(metaSeq))
+	numChanged := 0
+	for i, m := range metaSeq {
+		results[i] = uc.apply(ctx, user, m.Zid, ops)
+		if results[i].State == BulkMetaChanged {
+			numChanged++
+		}
+	}
+	uc.logger.Info("Bulk metadata", "zettel", len(metaSeq), "changed", numChanged, "ops", len(ops), logging.User(ctx))
+	return results, nil
+}
+
+func (uc *BulkMeta) apply(ctx context.Context, user *meta.Meta, zid id.Zid, ops []BulkMetaOp) BulkMetaResult {
+	old, err := uc.port.GetZettel(box.NoEnrichContext(ctx), zid)
+	if err != nil {
+		return BulkMetaResult{State: BulkMetaFailed, Zid: zid, Err: err}
+	}
+	m := old.Meta.Clone()
+	changed := false
+	for _, op := range ops {
+		if op.apply(m) {
+			changed = true
+		}
+	}
+	if !changed {
+		return BulkMetaResult{State: BulkMetaUnchanged, Zid: zid}
+	}
+	if !uc.policy.CanWrite(user, old.Meta, m) {
+		return BulkMetaResult{State: BulkMetaDenied, Zid: zid, Err: box.NewErrNotAllowed("Write", user, zid)}
+	}
+	prepareUpdateMeta(m, old.Meta)
+	z := zettel.Zettel{Meta: m, Content: old.Content}
+	if !uc.port.CanUpdateZettel(ctx, z) {
+		return BulkMetaResult{State: BulkMetaFailed, Zid: zid, Err: box.ErrReadOnly}
+	}
+	if err = uc.port.UpdateZettel(ctx, z); err != nil {
+		uc.logger.Info("Bulk metadata update failed", "zid", zid, logging.User(ctx), logging.Err(err))
+		return BulkMetaResult{State: BulkMetaFailed, Zid: zid, Err: err}
+	}
+	return BulkMetaResult{State: BulkMetaChanged, Zid: zid}
+}
+
+// normalizeBulkMetaOps checks all operations and returns them with tags in
+// their normalized form.
+func normalizeBulkMetaOps(ops []BulkMetaOp) ([]BulkMetaOp, error) {
+	if len(ops) == 0 {
+		return nil, ErrInvalidBulkMetaOp{Reason: "no operation given"}
+	}
+	result := make([]BulkMetaOp, len(ops))
+	for i, op := range ops {
+		key := op.Key
+		if !meta.KeyIsValid(key) {
+			return nil, ErrInvalidBulkMetaOp{Reason: "invalid key " + key}
+		}
+		if meta.IsComputed(key) || key == meta.KeyModified {
+			return nil, ErrInvalidBulkMetaOp{Reason: "key " + key + " cannot be changed"}
+		}
+		switch op.Kind {
+		case BulkMetaAdd, BulkMetaSet:
+			if op.Value == "" {
+				return nil, ErrInvalidBulkMetaOp{Reason: "missing value for key " + key}
+			}
+		case BulkMetaRemove:
+		case BulkMetaRename:
+			if op.Value == "" || op.NewValue == "" {
+				return nil, ErrInvalidBulkMetaOp{Reason: "rename of key " + key + " needs a current and a new value"}
+			}
+		default:
+			return nil, ErrInvalidBulkMetaOp{Reason: "unknown operation"}
+		}
+		if meta.Type(key) == meta.TypeTagSet {
+			op.Value, op.NewValue = normalizeTags(op.Value), normalizeTags(op.NewValue)
+		}
+		result[i] = op
+	}
+	return result, nil
+}
+
+func normalizeTags(val meta.Value) meta.Value {
+	var fields []string
+	for s := range val.Fields() {
+		if tag := meta.Value(s).NormalizeTag(); tag != "" {
+			fields = append(fields, string(tag))
+		}
+	}
+	return meta.Value(strings.Join(fields, " "))
+}
+
+// apply executes the operation on the given metadata. It returns true, if the
+// metadata was changed.
+func (op BulkMetaOp) apply(m *meta.Meta) bool {
+	curVal, found := m.Get(op.Key)
+	if !found && (op.Kind == BulkMetaRemove || op.Kind == BulkMetaRename) {
+		return false
+	}
+	isSet := meta.GetDescription(op.Key).Type.IsSet
+	var newVal meta.Value
+	switch op.Kind {
+	case BulkMetaSet:
+		newVal = op.Value
+	case BulkMetaAdd:
+		if !isSet {
+			if found {
+				return false
+			}
+			newVal = op.Value
+			break
+		}
+		fields := slices.Collect(curVal.Fields())
+		for s := range op.Value.Fields() {
+			if !slices.Contains(fields, s) {
+				fields = append(fields, s)
+			}
+		}
+		newVal = meta.Value(strings.Join(fields, " "))
+	case BulkMetaRemove:
+		if isSet && op.Value != "" {
+			removes := slices.Collect(op.Value.Fields())
+			fields := slices.DeleteFunc(slices.Collect(curVal.Fields()), func(s string) bool {
+				return slices.Contains(removes, s)
+			})
+			newVal = meta.Value(strings.Join(fields, " "))
+		} else if op.Value != "" && curVal != op.Value {
+			return false
+		}
+	case BulkMetaRename:
+		if !isSet {
+			if curVal != op.Value {
+				return false
+			}
+			newVal = op.NewValue
+			break
+		}
+		fields := slices.Collect(curVal.Fields())
+		if !slices.Contains(fields, string(op.Value)) {
+			return false
+		}
+		result := make([]string, 0, len(fields))
+		for _, s := range fields {
+			if s == string(op.Value) {
+				s = string(op.NewValue)
+			}
+			if !slices.Contains(result, s) {
+				result = append(result, s)
+			}
+		}
+		newVal = meta.Value(strings.Join(result, " "))
+	}
+
+	if newVal == "" {
+		if !found {
+			return false
+		}
+		m.Delete(op.Key)
+		return true
+	}
+	if found && newVal == curVal {
+		return false
+	}
+	m.Set(op.Key, newVal)
+	return true
+}
@@ -0,0 +1,150 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"t73f.de/r/zsc/domain/meta"
+)
+
+func TestBulkMetaOpApply(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name    string
+		op      BulkMetaOp
+		in      []string
+		changed bool
+		exp     []string
+	}{
+		// Set keys
+		{"add-set", BulkMetaOp{Kind: BulkMetaAdd, Key: meta.KeyTags, Value: "#b #c"},
+			[]string{meta.KeyTags, "#a #b"}, true, []string{meta.KeyTags, "#a #b #c"}},
+		{"add-set-known", BulkMetaOp{Kind: BulkMetaAdd, Key: meta.KeyTags, Value: "#b"},
+			[]string{meta.KeyTags, "#a #b"}, false, []string{meta.KeyTags, "#a #b"}},
+		{"add-set-missing", BulkMetaOp{Kind: BulkMetaAdd, Key: meta.KeyTags, Value: "#a"},
+			nil, true, []string{meta.KeyTags, "#a"}},
+		{"remove-set", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTags, Value: "#a #c"},
+			[]string{meta.KeyTags, "#a #b #c"}, true, []string{meta.KeyTags, "#b"}},
+		{"remove-set-last", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTags, Value: "#a"},
+			[]string{meta.KeyTags, "#a"}, true, nil},
+		{"remove-set-unknown", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTags, Value: "#c"},
+			[]string{meta.KeyTags, "#a #b"}, false, []string{meta.KeyTags, "#a #b"}},
+		{"remove-set-key", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTags},
+			[]string{meta.KeyTags, "#a #b"}, true, nil},
+		{"remove-set-missing", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTags, Value: "#a"},
+			nil, false, nil},
+		{"set-set", BulkMetaOp{Kind: BulkMetaSet, Key: meta.KeyTags, Value: "#c"},
+			[]string{meta.KeyTags, "#a #b"}, true, []string{meta.KeyTags, "#c"}},
+		{"set-set-same", BulkMetaOp{Kind: BulkMetaSet, Key: meta.KeyTags, Value: "#a #b"},
+			[]string{meta.KeyTags, "#a #b"}, false, []string{meta.KeyTags, "#a #b"}},
+		{"rename-set", BulkMetaOp{Kind: BulkMetaRename, Key: meta.KeyTags, Value: "#a", NewValue: "#c"},
+			[]string{meta.KeyTags, "#a #b"}, true, []string{meta.KeyTags, "#c #b"}},
+		{"rename-set-merge", BulkMetaOp{Kind: BulkMetaRename, Key: meta.KeyTags, Value: "#a", NewValue: "#b"},
+			[]string{meta.KeyTags, "#a #b"}, true, []string{meta.KeyTags, "#b"}},
+		{"rename-set-unknown", BulkMetaOp{Kind: BulkMetaRename, Key: meta.KeyTags, Value: "#c", NewValue: "#d"},
+			[]string{meta.KeyTags, "#a #b"}, false, []string{meta.KeyTags, "#a #b"}},
+		{"rename-set-missing", BulkMetaOp{Kind: BulkMetaRename, Key: meta.KeyTags, Value: "#a", NewValue: "#b"},
+			nil, false, nil},
+
+		// Non-set keys
+		{"add", BulkMetaOp{Kind: BulkMetaAdd, Key: meta.KeyTitle, Value: "New"},
+			nil, true, []string{meta.KeyTitle, "New"}},
+		{"add-existing", BulkMetaOp{Kind: BulkMetaAdd, Key: meta.KeyTitle, Value: "New"},
+			[]string{meta.KeyTitle, "Old"}, false, []string{meta.KeyTitle, "Old"}},
+		{"remove", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTitle},
+			[]string{meta.KeyTitle, "Old"}, true, nil},
+		{"remove-value", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTitle, Value: "Old"},
+			[]string{meta.KeyTitle, "Old"}, true, nil},
+		{"remove-other-value", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTitle, Value: "Other"},
+			[]string{meta.KeyTitle, "Old"}, false, []string{meta.KeyTitle, "Old"}},
+		{"remove-missing", BulkMetaOp{Kind: BulkMetaRemove, Key: meta.KeyTitle},
+			nil, false, nil},
+		{"set", BulkMetaOp{Kind: BulkMetaSet, Key: meta.KeyTitle, Value: "New"},
+			[]string{meta.KeyTitle, "Old"}, true, []string{meta.KeyTitle, "New"}},
+		{"set-missing", BulkMetaOp{Kind: BulkMetaSet, Key: meta.KeyTitle, Value: "New"},
+			nil, true, []string{meta.KeyTitle, "New"}},
+		{"set-same", BulkMetaOp{Kind: BulkMetaSet, Key: meta.KeyTitle, Value: "Old"},
+			[]string{meta.KeyTitle, "Old"}, false, []string{meta.KeyTitle, "Old"}},
+		{"rename", BulkMetaOp{Kind: BulkMetaRename, Key: meta.KeyTitle, Value: "Old", NewValue: "New"},
+			[]string{meta.KeyTitle, "Old"}, true, []string{meta.KeyTitle, "New"}},
+		{"rename-other", BulkMetaOp{Kind: BulkMetaRename, Key: meta.KeyTitle, Value: "Other", NewValue: "New"},
+			[]string{meta.KeyTitle, "Old"}, false, []string{meta.KeyTitle, "Old"}},
+		{"rename-missing", BulkMetaOp{Kind: BulkMetaRename, Key: meta.KeyTitle, Value: "Old", NewValue: "New"},
+			nil, false, nil},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := makeTestMeta(1, tc.in...)
+			if changed := tc.op.apply(m); changed != tc.changed {
+				t.Errorf("expected changed=%v, but got %v", tc.changed, changed)
+			}
+			checkTestMeta(t, m, tc.exp...)
+		})
+	}
+}
+
+func TestNormalizeBulkMetaOps(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name string
+		ops  []BulkMetaOp
+		exp  []BulkMetaOp
+		err  bool
+	}{
+		{"empty", nil, nil, true},
+		{"invalid-key", []BulkMetaOp{{Kind: BulkMetaSet, Key: "a b", Value: "x"}}, nil, true},
+		{"computed-key", []BulkMetaOp{{Kind: BulkMetaSet, Key: meta.KeyFolge, Value: "x"}}, nil, true},
+		{"modified", []BulkMetaOp{{Kind: BulkMetaSet, Key: meta.KeyModified, Value: "20250101000000"}}, nil, true},
+		{"unknown-op", []BulkMetaOp{{Key: meta.KeyTitle, Value: "x"}}, nil, true},
+		{"add-no-value", []BulkMetaOp{{Kind: BulkMetaAdd, Key: meta.KeyTitle}}, nil, true},
+		{"set-no-value", []BulkMetaOp{{Kind: BulkMetaSet, Key: meta.KeyTitle}}, nil, true},
+		{"rename-no-new", []BulkMetaOp{{Kind: BulkMetaRename, Key: meta.KeyTitle, Value: "x"}}, nil, true},
+		{"remove-no-value",
+			[]BulkMetaOp{{Kind: BulkMetaRemove, Key: meta.KeyTitle}},
+			[]BulkMetaOp{{Kind: BulkMetaRemove, Key: meta.KeyTitle}}, false},
+		{"tags",
+			[]BulkMetaOp{{Kind: BulkMetaAdd, Key: meta.KeyTags, Value: "#a #B"}},
+			[]BulkMetaOp{{Kind: BulkMetaAdd, Key: meta.KeyTags, Value: "#a #b"}}, false},
+		{"tags-rename",
+			[]BulkMetaOp{{Kind: BulkMetaRename, Key: meta.KeyTags, Value: "#A", NewValue: "#B"}},
+			[]BulkMetaOp{{Kind: BulkMetaRename, Key: meta.KeyTags, Value: "#a", NewValue: "#b"}}, false},
+		{"no-tags",
+			[]BulkMetaOp{{Kind: BulkMetaSet, Key: meta.KeyTitle, Value: "A #B"}},
+			[]BulkMetaOp{{Kind: BulkMetaSet, Key: meta.KeyTitle, Value: "A #B"}}, false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := normalizeBulkMetaOps(tc.ops)
+			if tc.err {
+				if _, isErr := errors.AsType[ErrInvalidBulkMetaOp](err); !isErr {
+					t.Errorf("expected invalid operation, but got %v / %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.exp) {
+				t.Fatalf("expected %v, but got %v", tc.exp, got)
+			}
+			for i, op := range got {
+				if op != tc.exp[i] {
+					t.Errorf("%d: expected %v, but got %v", i, tc.exp[i], op)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+	"t73f.de/r/zsc/sz"
+	"t73f.de/r/zsx"
+	"t73f.de/r/zsx/input"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/collect"
+	"zettelstore.de/z/internal/parser"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// changeRefPort is the part of a port that is needed to change the
+// references of other zettel. GetMeta must return enriched metadata.
+type changeRefPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+	CanUpdateZettel(ctx context.Context, zettel zettel.Zettel) bool
+}
+
+// prepareRefChanges computes all zettel that refer to zettel rz.from, with
+// references changed to rz.to. It returns the changed zettel and their
+// previous version. The zettel rz.from and rz.to are never included. It is an
+// error if the current user is not allowed to change one of the zettel.
+func prepareRefChanges(ctx context.Context, port changeRefPort, policy auth.Policy, rz refZid, op string) (refs, olds []zettel.Zettel, _ error) {
+	refZids, err := referencingZids(ctx, port, rz.fromZid)
+	if err != nil {
+		return nil, nil, err
+	}
+	user := auth.GetCurrentUser(ctx)
+	noEnrichCtx := box.NoEnrichContext(ctx)
+	for _, zid := range refZids {
+		if zid == rz.toZid {
+			continue
+		}
+		ref, err2 := port.GetZettel(noEnrichCtx, zid)
+		if err2 != nil {
+			if _, isErr := errors.AsType[box.ErrZettelNotFound](err2); isErr {
+				continue
+			}
+			return nil, nil, err2
+		}
+		m := ref.Meta.Clone()
+		changedMeta := rz.replaceInMeta(m)
+		content := rz.replaceInContent(ref.Meta, ref.Content)
+		if !changedMeta && content.Equal(&ref.Content) {
+			continue
+		}
+		if !policy.CanWrite(user, ref.Meta, m) {
+			return nil, nil, box.NewErrNotAllowed(op, user, zid)
+		}
+		z := zettel.Zettel{Meta: m, Content: content}
+		prepareUpdateMeta(m, ref.Meta)
+		if !port.CanUpdateZettel(ctx, z) {
+			return nil, nil, fmt.Errorf("zettel %v: %w", zid, box.ErrReadOnly)
+		}
+		refs = append(refs, z)
+		olds = append(olds, ref)
+	}
+	return refs, olds, nil
+}
+
+// referencingZids returns the identifier of all zettel that refer to the
+// given zettel, as recorded by the index: via its content, or via metadata.
+func referencingZids(ctx context.Context, port changeRefPort, zid id.Zid) ([]id.Zid, error) {
+	m, err := port.GetMeta(ctx, zid)
+	if err != nil {
+		return nil, err
+	}
+	var result []id.Zid
+	for key, val := range m.All() {
+		descr := meta.GetDescription(key)
+		if key != meta.KeyBackward && (!descr.IsProperty() || descr.Inverse == "") {
+			continue
+		}
+		for s := range val.Fields() {
+			if refZid, err2 := id.Parse(s); err2 == nil && refZid != zid {
+				result = append(result, refZid)
+			}
+		}
+	}
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+// restoreZettel stores the given zettel in reverse order. It is used to
+// revert changes.
+func restoreZettel(ctx context.Context, logger *slog.Logger, port interface {
+	UpdateZettel(context.Context, zettel.Zettel) error
+}, olds []zettel.Zettel) {
+	for i := len(olds) - 1; i >= 0; i-- {
+		if err := port.UpdateZettel(ctx, olds[i]); err != nil {
+			logger.Error("Unable to restore zettel", "zid", olds[i].Meta.Zid, "err", err)
+		}
+	}
+}
+
+// refZid replaces references to a zettel identifier.
+type refZid struct {
+	fromZid, toZid id.Zid
+	from, to       string
+}
+
+func newRefZid(fromZid, toZid id.Zid) refZid {
+	return refZid{fromZid: fromZid, toZid: toZid, from: fromZid.String(), to: toZid.String()}
+}
+
+// replaceInMeta changes all metadata values of type identifier and
+// identifier set. It returns true, if some value was changed.
+func (rz refZid) replaceInMeta(m *meta.Meta) bool {
+	changes := map[string]meta.Value{}
+	for key, val := range m.All() {
+		if meta.IsComputed(key) {
+			continue
+		}
+		descr := meta.GetDescription(key)
+		if descr.Type != meta.TypeID && descr.Type != meta.TypeIDSet {
+			continue
+		}
+		fields := slices.Collect(val.Fields())
+		if !slices.Contains(fields, rz.from) {
+			continue
+		}
+		result := make([]string, 0, len(fields))
+		for _, s := range fields {
+			if s == rz.from {
+				s = rz.to
+			}
+			if !slices.Contains(result, s) {
+				result = append(result, s)
+			}
+		}
+		changes[key] = meta.Value(strings.Join(result, " "))
+	}
+	for key, val := range changes {
+		m.Set(key, val)
+	}
+	return len(changes) > 0
+}
+
+// replaceInContent changes the references to the identifier in a textual
+// content: links, embedded zettel, and transcluded zettel. Other occurrences,
+// e.g. within normal text or within code, are not changed. Because the syntax
+// tree has no position information, every occurrence is replaced tentatively.
+// The replacement is kept only if the parsed content has fewer references
+// to the identifier afterwards. m specifies the syntax of the content.
+func (rz refZid) replaceInContent(m *meta.Meta, content zettel.Content) zettel.Content {
+	if content.IsBinary() {
+		return content
+	}
+	syntax := string(m.GetDefault(meta.KeySyntax, meta.DefaultSyntax))
+	data := content.AsBytes()
+	numRefs := rz.countRefs(m, syntax, data)
+	if numRefs == 0 {
+		return content
+	}
+	from, to := []byte(rz.from), []byte(rz.to)
+	changed := false
+	for pos := 0; numRefs > 0; {
+		idx := bytes.Index(data[pos:], from)
+		if idx < 0 {
+			break
+		}
+		start, end := pos+idx, pos+idx+len(from)
+		pos = end
+		if (start > 0 && isDigit(data[start-1])) || (end < len(data) && isDigit(data[end])) {
+			continue
+		}
+		newData := slices.Concat(data[:start], to, data[end:])
+		if n := rz.countRefs(m, syntax, newData); n < numRefs {
+			data, numRefs, changed = newData, n, true
+			pos = start + len(to)
+		}
+	}
+	if !changed {
+		return content
+	}
+	return zettel.NewContent(data)
+}
+
+// countRefs returns the number of references to the identifier in the parsed
+// content.
+func (rz refZid) countRefs(m *meta.Meta, syntax string, data []byte) int {
+	block := parser.Parse(input.NewInput(data), m, syntax, nil, nil)
+	count := 0
+	for ref := range collect.ReferenceSeq(block) {
+		if sym, val := zsx.GetReference(ref); sz.SymRefStateZettel.IsEqualSymbol(sym) {
+			if baseVal, _ := sz.SplitFragment(val); baseVal == rz.from {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func isDigit(ch byte) bool { return '0' <= ch && ch <= '9' }
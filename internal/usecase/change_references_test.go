@@ -0,0 +1,114 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/zettel"
+)
+
+const (
+	refFromZid = id.Zid(20250101000001)
+	refToZid   = id.Zid(20260101000002)
+)
+
+func TestReplaceInContent(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name   string
+		syntax meta.Value
+		in     string
+		exp    string
+	}{
+		{"empty", meta.ValueSyntaxZmk, "", ""},
+		{"link", meta.ValueSyntaxZmk, "[[20250101000001]]", "[[20260101000002]]"},
+		{"link-text", meta.ValueSyntaxZmk, "[[Text|20250101000001]]", "[[Text|20260101000002]]"},
+		{"link-text-zid", meta.ValueSyntaxZmk, "[[20250101000001|20250101000001]]", "[[20250101000001|20260101000002]]"},
+		{"link-fragment", meta.ValueSyntaxZmk, "[[20250101000001#frag]]", "[[20260101000002#frag]]"},
+		{"links", meta.ValueSyntaxZmk, "[[20250101000001]] and [[20250101000001]]", "[[20260101000002]] and [[20260101000002]]"},
+		{"embed", meta.ValueSyntaxZmk, "{{20250101000001}}", "{{20260101000002}}"},
+		{"transclusion", meta.ValueSyntaxZmk, "{{{20250101000001}}}", "{{{20260101000002}}}"},
+		{"text", meta.ValueSyntaxZmk, "See 20250101000001.", "See 20250101000001."},
+		{"text-link", meta.ValueSyntaxZmk, "20250101000001: [[20250101000001]]", "20250101000001: [[20260101000002]]"},
+		{"verbatim", meta.ValueSyntaxZmk, "```\n[[20250101000001]]\n```", "```\n[[20250101000001]]\n```"},
+		{"other-zid", meta.ValueSyntaxZmk, "[[20250101000003]]", "[[20250101000003]]"},
+		{"longer", meta.ValueSyntaxZmk, "[[120250101000001]]", "[[120250101000001]]"},
+		{"md-link", meta.ValueSyntaxMarkdown, "[Text](20250101000001)", "[Text](20260101000002)"},
+		{"md-code", meta.ValueSyntaxMarkdown, "`[Text](20250101000001)`", "`[Text](20250101000001)`"},
+		{"text-syntax", meta.ValueSyntaxText, "[[20250101000001]]", "[[20250101000001]]"},
+	}
+	rz := newRefZid(refFromZid, refToZid)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta.New(id.Zid(1))
+			m.Set(meta.KeySyntax, tc.syntax)
+			content := zettel.NewContent([]byte(tc.in))
+			got := rz.replaceInContent(m, content)
+			if s := got.AsString(); s != tc.exp {
+				t.Errorf("%q: expected %q, but got %q", tc.in, tc.exp, s)
+			}
+		})
+	}
+}
+
+func TestReplaceInContentBinary(t *testing.T) {
+	t.Parallel()
+	rz := newRefZid(refFromZid, refToZid)
+	m := meta.New(id.Zid(1))
+	m.Set(meta.KeySyntax, meta.ValueSyntaxZmk)
+	data := []byte("[[20250101000001]]\x00")
+	content := zettel.NewContent(data)
+	if !content.IsBinary() {
+		t.Fatal("content must be binary")
+	}
+	if got := rz.replaceInContent(m, content); !got.Equal(&content) {
+		t.Errorf("binary content must not be changed, but got %q", got.AsString())
+	}
+}
+
+func TestReplaceInMeta(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name    string
+		key     string
+		val     meta.Value
+		exp     meta.Value
+		changed bool
+	}{
+		{"id", meta.KeyPredecessor, "20250101000001", "20260101000002", true},
+		{"id-other", meta.KeyPredecessor, "20250101000003", "20250101000003", false},
+		{"idset", meta.KeyPrecursor, "20250101000003 20250101000001", "20250101000003 20260101000002", true},
+		{"idset-duplicate", meta.KeyPrecursor, "20260101000002 20250101000001", "20260101000002", true},
+		{"idset-other", meta.KeyPrecursor, "20250101000003 120250101000001", "20250101000003 120250101000001", false},
+		{"string", meta.KeyTitle, "20250101000001", "20250101000001", false},
+		{"computed", meta.KeyFolge, "20250101000001", "20250101000001", false},
+	}
+	rz := newRefZid(refFromZid, refToZid)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta.New(id.Zid(1))
+			m.Set(tc.key, tc.val)
+			if changed := rz.replaceInMeta(m); changed != tc.changed {
+				t.Errorf("expected changed=%v, but got %v", tc.changed, changed)
+			}
+			if got := m.GetDefault(tc.key, ""); got != tc.exp {
+				t.Errorf("%s=%q: expected %q, but got %q", tc.key, tc.val, tc.exp, got)
+			}
+		})
+	}
+}
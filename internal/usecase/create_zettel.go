@@ -137,11 +137,15 @@ func (uc *CreateZettel) Run(ctx context.Context, zettel zettel.Zettel) (id.Zid,
 		return m.Zid, nil // TODO: new error: already exists
 	}
 
-	m.Set(meta.KeyCreated, meta.Value(time.Now().Local().Format(id.TimestampLayout)))
-	m.Delete(meta.KeyModified)
-
+	prepareCreateMeta(m)
 	zettel.Content.TrimSpace()
 	zid, err := uc.port.CreateZettel(ctx, zettel)
 	uc.logger.Info("Create zettel", "zid", zid, logging.User(ctx), logging.Err(err))
 	return zid, err
 }
+
+// prepareCreateMeta sets relevant computed, but stored values of a new zettel.
+func prepareCreateMeta(m *meta.Meta) {
+	m.Set(meta.KeyCreated, meta.Value(time.Now().Local().Format(id.TimestampLayout)))
+	m.Delete(meta.KeyModified)
+}
@@ -21,6 +21,7 @@ import (
 	"t73f.de/r/zsc/domain/meta"
 	"t73f.de/r/zsx"
 
+	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/config"
 	"zettelstore.de/z/internal/evaluator"
 	"zettelstore.de/z/internal/parser"
@@ -28,35 +29,68 @@ import (
 	"zettelstore.de/z/internal/zettel"
 )
 
+// EvaluatePort is the interface used by this use case. It must not check
+// access rights.
+type EvaluatePort interface {
+	box.Subject
+
+	// GetMeta retrieves just the metadata of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+}
+
 // Evaluate is the data for this use case.
 type Evaluate struct {
 	rtConfig    config.Config
+	port        EvaluatePort
 	ucGetZettel *GetZettel
 	ucQuery     *Query
+	cache       *evalCache
 }
 
-// NewEvaluate creates a new use case.
-func NewEvaluate(rtConfig config.Config, ucGetZettel *GetZettel, ucQuery *Query) Evaluate {
+// NewEvaluate creates a new use case. Evaluated zettel are cached until a
+// zettel they depend on is changed, as observed by the given port, or until
+// the members of a group are changed, as observed by the groups subject.
+func NewEvaluate(rtConfig config.Config, port EvaluatePort, groups box.Subject, ucGetZettel *GetZettel, ucQuery *Query) Evaluate {
+	cache := newEvalCache()
+	port.RegisterObserver(cache.observe)
+	groups.RegisterObserver(cache.observe)
 	return Evaluate{
 		rtConfig:    rtConfig,
+		port:        port,
 		ucGetZettel: ucGetZettel,
 		ucQuery:     ucQuery,
+		cache:       cache,
 	}
 }
 
 // Run executes the use case.
 func (uc *Evaluate) Run(ctx context.Context, zid id.Zid, syntax string) (*zettel.ParsedZettel, error) {
-	zettel, err := uc.ucGetZettel.Run(ctx, zid, true)
+	gen := uc.cache.generation()
+	z, err := uc.ucGetZettel.Run(ctx, zid, true)
 	if err != nil {
 		return nil, err
 	}
-	return uc.RunZettel(ctx, zettel, syntax), nil
+	key := newEvalKey(ctx, zid, syntax)
+	if zn := uc.cache.get(key, z); zn != nil {
+		return copyParsedZettel(zn), nil
+	}
+
+	entry := &evalEntry{key: key, zettel: zettel.Zettel{Meta: z.Meta.Clone(), Content: z.Content}}
+	er := evalRecorder{uc: uc}
+	entry.zn = uc.runZettel(ctx, &er, z, syntax)
+	entry.deps, entry.hasQuery = er.deps, er.hasQuery
+	uc.cache.put(gen, entry)
+	return copyParsedZettel(entry.zn), nil
 }
 
 // RunZettel executes the use case for a given zettel.
 func (uc *Evaluate) RunZettel(ctx context.Context, zettel zettel.Zettel, syntax string) *zettel.ParsedZettel {
+	return uc.runZettel(ctx, uc, zettel, syntax)
+}
+
+func (uc *Evaluate) runZettel(ctx context.Context, port evaluator.Port, zettel zettel.Zettel, syntax string) *zettel.ParsedZettel {
 	zn := parser.ParseZettel(ctx, zettel, syntax, uc.rtConfig)
-	evaluator.EvaluateZettel(ctx, uc, uc.rtConfig, zn)
+	evaluator.EvaluateZettel(ctx, port, uc.rtConfig, zn)
 	return zn
 }
 
@@ -0,0 +1,190 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/id/idset"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/query"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// evalCacheSize is the maximum number of evaluated zettel that are cached.
+const evalCacheSize = 512
+
+// evalKey identifies an evaluated zettel. The result of an evaluation depends
+// on the user, because transcluded zettel and the results of embedded queries
+// depend on the access rights of the user. If the user was authenticated with
+// a personal access token, the access rights are restricted by the scope and
+// the roles of the token.
+type evalKey struct {
+	zid        id.Zid
+	syntax     string
+	user       id.Zid
+	tokenScope string
+	tokenRoles string
+}
+
+func newEvalKey(ctx context.Context, zid id.Zid, syntax string) evalKey {
+	key := evalKey{zid: zid, syntax: syntax, user: id.Invalid}
+	if user := auth.GetCurrentUser(ctx); user != nil {
+		key.user = user.Zid
+		key.tokenScope = string(user.GetDefault(auth.KeyTokenScope, ""))
+		key.tokenRoles = string(user.GetDefault(auth.KeyTokenRoles, ""))
+	}
+	return key
+}
+
+// evalEntry is one evaluated zettel.
+type evalEntry struct {
+	key      evalKey
+	zettel   zettel.Zettel // Zettel that was evaluated
+	zn       *zettel.ParsedZettel
+	deps     *idset.Set // Zettel that were retrieved during evaluation
+	hasQuery bool       // Evaluation executed a query
+}
+
+// evalCache is a LRU cache of evaluated zettel.
+//
+// An entry is removed if the zettel itself, the user, or a zettel it depends
+// on is changed. Since the result of a query may change with every zettel,
+// entries with embedded queries are removed on every change.
+type evalCache struct {
+	mx      sync.Mutex
+	entries map[evalKey]*list.Element
+	lru     list.List // Most recently used entry first
+	gen     uint64    // Incremented on every change
+}
+
+func newEvalCache() *evalCache {
+	return &evalCache{entries: make(map[evalKey]*list.Element, evalCacheSize)}
+}
+
+// generation returns a value to detect changes between retrieving a zettel and
+// storing its evaluation.
+func (ec *evalCache) generation() uint64 {
+	ec.mx.Lock()
+	defer ec.mx.Unlock()
+	return ec.gen
+}
+
+// get returns the evaluated zettel, if it is still valid for the given zettel.
+func (ec *evalCache) get(key evalKey, z zettel.Zettel) *zettel.ParsedZettel {
+	ec.mx.Lock()
+	defer ec.mx.Unlock()
+	elem, found := ec.entries[key]
+	if !found {
+		return nil
+	}
+	entry := elem.Value.(*evalEntry)
+	if !entry.zettel.Equal(z, true) {
+		ec.remove(elem)
+		return nil
+	}
+	ec.lru.MoveToFront(elem)
+	return entry.zn
+}
+
+// put stores an evaluated zettel, if nothing was changed since gen.
+func (ec *evalCache) put(gen uint64, entry *evalEntry) {
+	ec.mx.Lock()
+	defer ec.mx.Unlock()
+	if gen != ec.gen {
+		return
+	}
+	if elem, found := ec.entries[entry.key]; found {
+		ec.remove(elem)
+	}
+	ec.entries[entry.key] = ec.lru.PushFront(entry)
+	for ec.lru.Len() > evalCacheSize {
+		ec.remove(ec.lru.Back())
+	}
+}
+
+func (ec *evalCache) remove(elem *list.Element) {
+	// Must only be called if ec.mx is locked!
+	delete(ec.entries, ec.lru.Remove(elem).(*evalEntry).key)
+}
+
+// observe removes all entries that depend on a changed zettel.
+func (ec *evalCache) observe(ci box.UpdateInfo) {
+	ec.mx.Lock()
+	defer ec.mx.Unlock()
+	ec.gen++
+	if ci.Reason != box.OnZettel && ci.Reason != box.OnDelete || ci.Zid == id.ZidConfiguration {
+		clear(ec.entries)
+		ec.lru.Init()
+		return
+	}
+	zid := ci.Zid
+	for elem := ec.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*evalEntry); entry.hasQuery || entry.key.zid == zid ||
+			entry.key.user == zid || entry.deps.Contains(zid) {
+			ec.remove(elem)
+		}
+		elem = next
+	}
+}
+
+// evalRecorder records all zettel and queries that are needed to evaluate
+// a zettel.
+type evalRecorder struct {
+	uc       *Evaluate
+	deps     *idset.Set
+	hasQuery bool
+}
+
+func (er *evalRecorder) GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error) {
+	er.deps = er.deps.Add(zid)
+	z, err := er.uc.GetZettel(ctx, zid)
+	m := z.Meta
+	if _, isErr := errors.AsType[*box.ErrNotAllowed](err); isErr {
+		// The access control list is needed, even if the user is not
+		// allowed to read the zettel.
+		m, _ = er.uc.port.GetMeta(box.NoEnrichContext(ctx), zid)
+	}
+	if m != nil {
+		// If a group of the access control list changes, the user may
+		// be allowed to read the zettel, or not any more.
+		principals, _ := auth.GetACL(m, auth.KeyReadACL)
+		for _, principal := range principals {
+			er.deps = er.deps.Add(principal)
+		}
+	}
+	return z, err
+}
+
+func (er *evalRecorder) QueryMeta(ctx context.Context, q *query.Query) ([]*meta.Meta, error) {
+	er.hasQuery = true
+	return er.uc.QueryMeta(ctx, q)
+}
+
+// copyParsedZettel returns a copy of the evaluated zettel that the caller may
+// change without changing the cached value.
+func copyParsedZettel(zn *zettel.ParsedZettel) *zettel.ParsedZettel {
+	result := *zn
+	result.Meta = zn.Meta.Clone()
+	result.InhMeta = zn.InhMeta.Clone()
+	return &result
+}
@@ -0,0 +1,226 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/id/idset"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/zettel"
+)
+
+func makeEvalEntry(zid, user id.Zid, deps *idset.Set, hasQuery bool) *evalEntry {
+	return &evalEntry{
+		key:      evalKey{zid: zid, syntax: meta.ValueSyntaxZmk, user: user},
+		zettel:   makeEvalZettel(zid, "content"),
+		zn:       &zettel.ParsedZettel{},
+		deps:     deps,
+		hasQuery: hasQuery,
+	}
+}
+
+func makeEvalZettel(zid id.Zid, content string) zettel.Zettel {
+	return zettel.Zettel{Meta: meta.New(zid), Content: zettel.NewContent([]byte(content))}
+}
+
+// isCached returns true, if the entry is stored in the cache.
+func isCached(ec *evalCache, entry *evalEntry) bool {
+	return ec.get(entry.key, entry.zettel) == entry.zn
+}
+
+func TestEvalCacheObserve(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name string
+		ci   box.UpdateInfo
+		exp  []bool // Expected remaining entries: zettel, dependency, user, query, other
+	}{
+		{"zettel", box.UpdateInfo{Reason: box.OnZettel, Zid: 1}, []bool{false, true, true, false, true}},
+		{"dependency", box.UpdateInfo{Reason: box.OnZettel, Zid: 11}, []bool{true, false, true, false, true}},
+		{"principal", box.UpdateInfo{Reason: box.OnDelete, Zid: 12}, []bool{true, false, true, false, true}},
+		{"user", box.UpdateInfo{Reason: box.OnZettel, Zid: 13}, []bool{true, true, false, false, true}},
+		{"unrelated", box.UpdateInfo{Reason: box.OnZettel, Zid: 99}, []bool{true, true, true, false, true}},
+		{"configuration", box.UpdateInfo{Reason: box.OnZettel, Zid: id.ZidConfiguration}, []bool{false, false, false, false, false}},
+		{"reload", box.UpdateInfo{Reason: box.OnReload}, []bool{false, false, false, false, false}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ec := newEvalCache()
+			entries := []*evalEntry{
+				makeEvalEntry(1, id.Invalid, nil, false),
+				makeEvalEntry(2, id.Invalid, idset.New(11, 12), false),
+				makeEvalEntry(3, 13, nil, false),
+				makeEvalEntry(4, id.Invalid, nil, true),
+				makeEvalEntry(5, id.Invalid, idset.New(14), false),
+			}
+			for _, entry := range entries {
+				ec.put(ec.generation(), entry)
+			}
+			ec.observe(tc.ci)
+			for i, entry := range entries {
+				if got := isCached(ec, entry); got != tc.exp[i] {
+					t.Errorf("entry %v: expected to be cached=%v, but got %v", entry.key.zid, tc.exp[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestEvalCacheChangedZettel(t *testing.T) {
+	t.Parallel()
+	ec := newEvalCache()
+	entry := makeEvalEntry(1, id.Invalid, nil, false)
+	ec.put(ec.generation(), entry)
+	if zn := ec.get(entry.key, makeEvalZettel(1, "changed")); zn != nil {
+		t.Error("evaluation of changed zettel must not be returned")
+	}
+	if isCached(ec, entry) {
+		t.Error("evaluation of changed zettel must be removed")
+	}
+}
+
+func TestEvalCacheGeneration(t *testing.T) {
+	t.Parallel()
+	ec := newEvalCache()
+	gen := ec.generation()
+	ec.observe(box.UpdateInfo{Reason: box.OnZettel, Zid: 99})
+	entry := makeEvalEntry(1, id.Invalid, nil, false)
+	ec.put(gen, entry)
+	if isCached(ec, entry) {
+		t.Error("evaluation must not be stored after a change was observed")
+	}
+	ec.put(ec.generation(), entry)
+	if !isCached(ec, entry) {
+		t.Error("evaluation must be stored if nothing changed")
+	}
+}
+
+func TestEvalCacheSize(t *testing.T) {
+	t.Parallel()
+	ec := newEvalCache()
+	entries := make([]*evalEntry, evalCacheSize+2)
+	for i := range entries {
+		entries[i] = makeEvalEntry(id.Zid(i+1), id.Invalid, nil, false)
+	}
+	for _, entry := range entries[:evalCacheSize] {
+		ec.put(ec.generation(), entry)
+	}
+
+	// Using the first entry makes the second one the least recently used.
+	if !isCached(ec, entries[0]) {
+		t.Fatal("first entry must be cached")
+	}
+	ec.put(ec.generation(), entries[evalCacheSize])
+	if got := len(ec.entries); got != evalCacheSize {
+		t.Errorf("expected %d entries, but got %d", evalCacheSize, got)
+	}
+	if !isCached(ec, entries[0]) {
+		t.Error("recently used entry must not be removed")
+	}
+	if isCached(ec, entries[1]) {
+		t.Error("least recently used entry must be removed")
+	}
+
+	// Storing an existing key again must not remove another entry.
+	ec.put(ec.generation(), makeEvalEntry(entries[0].key.zid, id.Invalid, nil, false))
+	if got := len(ec.entries); got != evalCacheSize {
+		t.Errorf("expected %d entries after replace, but got %d", evalCacheSize, got)
+	}
+	if !isCached(ec, entries[2]) {
+		t.Error("replacing an entry must not remove another one")
+	}
+}
+
+func TestNewEvalKey(t *testing.T) {
+	t.Parallel()
+	user := meta.New(13)
+	readUser := auth.ScopeUser(user, &auth.PersonalToken{ReadOnly: true})
+	roleUser := auth.ScopeUser(user, &auth.PersonalToken{Roles: []string{"project"}})
+	ctxs := []context.Context{
+		context.Background(),
+		auth.UpdateContext(context.Background(), user, nil),
+		auth.UpdateContext(context.Background(), auth.ScopeUser(user, &auth.PersonalToken{}), nil),
+		auth.UpdateContext(context.Background(), readUser, nil),
+		auth.UpdateContext(context.Background(), roleUser, nil),
+	}
+	keys := make(map[evalKey]int, len(ctxs))
+	for i, ctx := range ctxs {
+		key := newEvalKey(ctx, 1, meta.ValueSyntaxZmk)
+		if j, found := keys[key]; found {
+			t.Errorf("context %d and %d must result in different keys, but got %v", j, i, key)
+		}
+		keys[key] = i
+	}
+}
+
+// evalTestPort stores some zettel, where some of them must not be read by the
+// current user.
+type evalTestPort struct {
+	zettel  map[id.Zid]*meta.Meta
+	allowed map[id.Zid]bool
+}
+
+func (*evalTestPort) RegisterObserver(box.UpdateFunc) {}
+
+func (tp *evalTestPort) GetMeta(_ context.Context, zid id.Zid) (*meta.Meta, error) {
+	if m, found := tp.zettel[zid]; found {
+		return m, nil
+	}
+	return nil, box.ErrZettelNotFound{Zid: zid}
+}
+
+func (tp *evalTestPort) GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error) {
+	m, err := tp.GetMeta(ctx, zid)
+	if err != nil {
+		return zettel.Zettel{}, err
+	}
+	if !tp.allowed[zid] {
+		return zettel.Zettel{}, box.NewErrNotAllowed("GetZettel", nil, zid)
+	}
+	return zettel.Zettel{Meta: m, Content: zettel.NewContent(nil)}, nil
+}
+
+func TestEvalRecorderACL(t *testing.T) {
+	t.Parallel()
+	tp := &evalTestPort{
+		zettel: map[id.Zid]*meta.Meta{
+			1: makeTestMeta(1, auth.KeyReadACL, "00000000000011"),
+			2: makeTestMeta(2, auth.KeyReadACL, "00000000000012 00000000000013"),
+		},
+		allowed: map[id.Zid]bool{1: true},
+	}
+	ucGetZettel := NewGetZettel(tp)
+	uc := Evaluate{port: tp, ucGetZettel: &ucGetZettel}
+	er := evalRecorder{uc: &uc}
+	ctx := context.Background()
+	if _, err := er.GetZettel(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := er.GetZettel(ctx, 2); err == nil {
+		t.Fatal("zettel 2 must not be readable")
+	}
+	if _, err := er.GetZettel(ctx, 3); err == nil {
+		t.Fatal("zettel 3 must not exist")
+	}
+	exp := idset.New(1, 2, 3, 11, 12, 13)
+	if newDeps, remDeps := er.deps.Diff(exp); !newDeps.IsEmpty() || !remDeps.IsEmpty() {
+		t.Errorf("expected dependencies %v, but got %v", exp, er.deps)
+	}
+}
@@ -15,6 +15,8 @@ package usecase
 
 import (
 	"context"
+	"strings"
+	"unicode"
 
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
@@ -69,6 +71,47 @@ func (uc GetUser) Run(ctx context.Context, ident string) (*meta.Meta, error) {
 	return metaList[len(metaList)-1], nil
 }
 
+// GetUserByIdent executes the use case, but returns a user only if its user
+// identification matches exactly. It is used for users that were
+// authenticated by a trusted proxy.
+func (uc GetUser) GetUserByIdent(ctx context.Context, ident string) (*meta.Meta, error) {
+	if strings.ContainsFunc(ident, unicode.IsSpace) {
+		return nil, nil
+	}
+	user, err := uc.Run(ctx, ident)
+	if err != nil || user == nil || string(user.GetDefault(meta.KeyUserID, "")) != ident {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserBySubject returns the user zettel that stores the given issuer and
+// subject of an OpenID Connect provider. It returns nil, if no user zettel or
+// more than one user zettel matches.
+func (uc GetUser) GetUserBySubject(ctx context.Context, issuer, subject string) (*meta.Meta, error) {
+	if issuer == "" || subject == "" || strings.ContainsFunc(subject, unicode.IsSpace) {
+		return nil, nil
+	}
+	q := query.Parse(auth.KeyOIDCSubject + webapi.SearchOperatorEqual + subject)
+	metaList, err := uc.port.SelectMeta(box.NoEnrichContext(ctx), nil, q)
+	if err != nil {
+		return nil, err
+	}
+	var result *meta.Meta
+	for _, m := range metaList {
+		if _, isUser := m.Get(meta.KeyUserID); !isUser ||
+			string(m.GetDefault(auth.KeyOIDCIssuer, "")) != issuer ||
+			string(m.GetDefault(auth.KeyOIDCSubject, "")) != subject {
+			continue
+		}
+		if result != nil {
+			return nil, nil
+		}
+		result = m
+	}
+	return result, nil
+}
+
 // Use case: return a user identified by zettel id and assert given ident value.
 // -----------------------------------------------------------------------------
 
@@ -0,0 +1,133 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/query"
+)
+
+// userTestAuthz only knows the owner.
+type userTestAuthz struct{ auth.AuthzManager }
+
+func (userTestAuthz) Owner() id.Zid { return 1 }
+
+// userTestPort stores user zettel. Its search is very loose: a search for a
+// user identification returns all users whose user identification contains
+// the search value, every other search returns all zettel.
+type userTestPort struct{ tokenTestPort }
+
+func (up userTestPort) SelectMeta(_ context.Context, _ []*meta.Meta, q *query.Query) ([]*meta.Meta, error) {
+	ident := ""
+	if s, found := strings.CutPrefix(q.String(), meta.KeyUserID+":"); found {
+		ident, _, _ = strings.Cut(s, " ")
+	}
+	var result []*meta.Meta
+	for _, zid := range slices.Sorted(maps.Keys(up.tokenTestPort)) {
+		if m := up.tokenTestPort[zid]; strings.Contains(string(m.GetDefault(meta.KeyUserID, "")), ident) {
+			result = append(result, m.Clone())
+		}
+	}
+	return result, nil
+}
+
+func TestGetUserByIdent(t *testing.T) {
+	t.Parallel()
+	uc := NewGetUser(userTestAuthz{}, userTestPort{tokenTestPort{
+		1:  makeTestMeta(1, meta.KeyUserID, "owner"),
+		13: makeTestMeta(13, meta.KeyUserID, "user"),
+		14: makeTestMeta(14, meta.KeyUserID, "superuser"),
+	}})
+	testcases := []struct {
+		ident string
+		exp   id.Zid
+	}{
+		{"owner", 1},
+		{"user", 13},
+		{"superuser", 14},
+		{"own", id.Invalid},
+		{"super", id.Invalid},
+		{"owner ", id.Invalid},
+		{"user owner", id.Invalid},
+		{"unknown", id.Invalid},
+		{"", id.Invalid},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.ident, func(t *testing.T) {
+			user, err := uc.GetUserByIdent(context.Background(), tc.ident)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := id.Invalid
+			if user != nil {
+				got = user.Zid
+			}
+			if got != tc.exp {
+				t.Errorf("expected user %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestGetUserBySubject(t *testing.T) {
+	t.Parallel()
+	const issuer = "https://login.example.com"
+	uc := NewGetUser(userTestAuthz{}, userTestPort{tokenTestPort{
+		1:  makeTestMeta(1, meta.KeyUserID, "owner"),
+		13: makeTestMeta(13, meta.KeyUserID, "user", auth.KeyOIDCIssuer, issuer, auth.KeyOIDCSubject, "1234"),
+		14: makeTestMeta(14, meta.KeyUserID, "other", auth.KeyOIDCIssuer, "https://evil.example", auth.KeyOIDCSubject, "5678"),
+		15: makeTestMeta(15, auth.KeyOIDCIssuer, issuer, auth.KeyOIDCSubject, "5678"),
+		16: makeTestMeta(16, meta.KeyUserID, "twin1", auth.KeyOIDCIssuer, issuer, auth.KeyOIDCSubject, "9999"),
+		17: makeTestMeta(17, meta.KeyUserID, "twin2", auth.KeyOIDCIssuer, issuer, auth.KeyOIDCSubject, "9999"),
+	}})
+	testcases := []struct {
+		name    string
+		issuer  string
+		subject string
+		exp     id.Zid
+	}{
+		{"match", issuer, "1234", 13},
+		{"prefix", issuer, "123", id.Invalid},
+		{"other-issuer", issuer + "/other", "1234", id.Invalid},
+		{"no-user", issuer, "5678", id.Invalid},
+		{"ambiguous", issuer, "9999", id.Invalid},
+		{"no-issuer", "", "1234", id.Invalid},
+		{"no-subject", issuer, "", id.Invalid},
+		{"space", issuer, "1234 5678", id.Invalid},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := uc.GetUserBySubject(context.Background(), tc.issuer, tc.subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := id.Invalid
+			if user != nil {
+				got = user.Zid
+			}
+			if got != tc.exp {
+				t.Errorf("expected user %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// MergeZettelPort is the interface used by this use case.
+//
+// The port must not check the access rights of the current user, because the
+// use case checks them in advance. GetMeta must return metadata that is
+// enriched with the references of other zettel.
+type MergeZettelPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+	CanUpdateZettel(ctx context.Context, zettel zettel.Zettel) bool
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+	CanDeleteZettel(ctx context.Context, zid id.Zid) bool
+	DeleteZettel(ctx context.Context, zid id.Zid) error
+}
+
+// MergeMetaRule specifies how the metadata of both zettel are combined.
+type MergeMetaRule int
+
+// Values for MergeMetaRule
+const (
+	MergeMetaTarget MergeMetaRule = iota // Target values win, set values are combined
+	MergeMetaSource                      // Source values win, set values are combined
+	MergeMetaKeep                        // Metadata of the target is not changed
+)
+
+// MergeContentRule specifies the content of the merged zettel.
+type MergeContentRule int
+
+// Values for MergeContentRule
+const (
+	MergeContentAppend MergeContentRule = iota // Content of source is appended to content of target
+	MergeContentTarget                         // Content of target is used
+	MergeContentSource                         // Content of source is used
+)
+
+// MergeOptions control how two zettel are merged.
+type MergeOptions struct {
+	Meta    MergeMetaRule
+	Content MergeContentRule
+	Archive bool // Source is archived instead of deleted
+}
+
+// ErrCannotMerge is returned, if the zettel cannot be merged with the given
+// options.
+type ErrCannotMerge struct{ Reason string }
+
+func (err ErrCannotMerge) Error() string { return "cannot merge zettel: " + err.Reason }
+
+// MergeZettel is the data for this use case.
+type MergeZettel struct {
+	logger *slog.Logger
+	policy auth.Policy
+	port   MergeZettelPort
+	mx     *sync.Mutex // Only one merge at a time
+}
+
+// NewMergeZettel creates a new use case.
+func NewMergeZettel(logger *slog.Logger, policy auth.Policy, port MergeZettelPort) MergeZettel {
+	return MergeZettel{logger: logger, policy: policy, port: port, mx: &sync.Mutex{}}
+}
+
+// Run executes the use case. The source zettel is merged into the target
+// zettel, according to the given options. All references to the source in
+// other zettel are changed to the target. Afterwards, the source zettel is
+// deleted or archived.
+//
+// Run returns the identifier of all other zettel that were changed. If one of
+// the changes fails, all changes made so far are reverted.
+func (uc *MergeZettel) Run(ctx context.Context, srcZid, tgtZid id.Zid, opts MergeOptions) ([]id.Zid, error) {
+	uc.mx.Lock()
+	defer uc.mx.Unlock()
+
+	plan, err := uc.prepare(ctx, srcZid, tgtZid, opts)
+	if err != nil {
+		uc.logger.Info("Merge zettel not possible", "zid", srcZid, "target", tgtZid, logging.User(ctx), logging.Err(err))
+		return nil, err
+	}
+
+	updates := make([]zettel.Zettel, 0, len(plan.refs)+2)
+	updates = append(updates, plan.merged)
+	updates = append(updates, plan.refs...)
+	if opts.Archive {
+		updates = append(updates, plan.archived)
+	}
+	for i, z := range updates {
+		if err = uc.port.UpdateZettel(ctx, z); err != nil {
+			uc.rollback(ctx, plan, i)
+			uc.logger.Info("Merge zettel failed", "zid", srcZid, "target", tgtZid, logging.User(ctx), logging.Err(err))
+			return nil, err
+		}
+	}
+	if !opts.Archive {
+		if err = uc.port.DeleteZettel(ctx, srcZid); err != nil {
+			uc.rollback(ctx, plan, len(updates))
+			uc.logger.Info("Merge zettel failed", "zid", srcZid, "target", tgtZid, logging.User(ctx), logging.Err(err))
+			return nil, err
+		}
+	}
+
+	result := make([]id.Zid, len(plan.refs))
+	for i, z := range plan.refs {
+		result[i] = z.Meta.Zid
+	}
+	uc.logger.Info("Merge zettel", "zid", srcZid, "target", tgtZid, "refs", len(result), "archive", opts.Archive, logging.User(ctx))
+	return result, nil
+}
+
+// mergePlan contains all zettel that must be stored to merge two zettel.
+type mergePlan struct {
+	merged    zettel.Zettel   // Target zettel, with merged data
+	oldTarget zettel.Zettel   // Previous version of target zettel
+	archived  zettel.Zettel   // Archived version of source zettel
+	refs      []zettel.Zettel // Referencing zettel with changed references
+	olds      []zettel.Zettel // Previous version of refs
+}
+
+// prepare checks that the merge is allowed and possible. It computes all
+// zettel that must be stored.
+func (uc *MergeZettel) prepare(ctx context.Context, srcZid, tgtZid id.Zid, opts MergeOptions) (mergePlan, error) {
+	var plan mergePlan
+	if !srcZid.IsValid() {
+		return plan, box.ErrInvalidZid{Zid: srcZid.String()}
+	}
+	if !tgtZid.IsValid() || tgtZid == srcZid {
+		return plan, box.ErrInvalidZid{Zid: tgtZid.String()}
+	}
+	noEnrichCtx := box.NoEnrichContext(ctx)
+	src, err := uc.port.GetZettel(noEnrichCtx, srcZid)
+	if err != nil {
+		return plan, err
+	}
+	tgt, err := uc.port.GetZettel(noEnrichCtx, tgtZid)
+	if err != nil {
+		return plan, err
+	}
+	user := auth.GetCurrentUser(ctx)
+	if !uc.policy.CanRead(user, src.Meta) {
+		return plan, box.NewErrNotAllowed("Merge", user, srcZid)
+	}
+	rz := newRefZid(srcZid, tgtZid)
+
+	merged, err := mergeZettel(src, tgt, opts, rz)
+	if err != nil {
+		return plan, err
+	}
+	if !uc.policy.CanWrite(user, tgt.Meta, merged.Meta) {
+		return plan, box.NewErrNotAllowed("Merge", user, tgtZid)
+	}
+	if !uc.port.CanUpdateZettel(ctx, merged) {
+		return plan, box.ErrReadOnly
+	}
+	plan.merged, plan.oldTarget = merged, tgt
+
+	if opts.Archive {
+		plan.archived = makeArchivedZettel(src, tgtZid)
+		if !uc.policy.CanWrite(user, src.Meta, plan.archived.Meta) {
+			return plan, box.NewErrNotAllowed("Merge", user, srcZid)
+		}
+		if !uc.port.CanUpdateZettel(ctx, plan.archived) {
+			return plan, box.ErrReadOnly
+		}
+	} else {
+		if !uc.policy.CanDelete(user, src.Meta) {
+			return plan, box.NewErrNotAllowed("Merge", user, srcZid)
+		}
+		if !uc.port.CanDeleteZettel(ctx, srcZid) {
+			return plan, box.ErrReadOnly
+		}
+	}
+
+	plan.refs, plan.olds, err = prepareRefChanges(ctx, uc.port, uc.policy, rz, "Merge")
+	return plan, err
+}
+
+// rollback restores the state before the merge. Only the first numUpdates
+// zettel of the plan were stored.
+func (uc *MergeZettel) rollback(ctx context.Context, plan mergePlan, numUpdates int) {
+	if numUpdates > 0 {
+		restoreZettel(ctx, uc.logger, uc.port, plan.olds[:min(numUpdates-1, len(plan.refs))])
+		restoreZettel(ctx, uc.logger, uc.port, []zettel.Zettel{plan.oldTarget})
+	}
+}
+
+// mergeZettel computes the merged target zettel.
+func mergeZettel(src, tgt zettel.Zettel, opts MergeOptions, rz refZid) (zettel.Zettel, error) {
+	m := tgt.Meta.Clone()
+	if opts.Meta != MergeMetaKeep {
+		mergeMeta(m, src.Meta, opts.Meta == MergeMetaSource)
+	}
+
+	content := tgt.Content
+	switch opts.Content {
+	case MergeContentAppend:
+		if src.Content.Length() == 0 {
+			break
+		}
+		if tgt.Content.Length() == 0 {
+			content = src.Content
+			m.Set(meta.KeySyntax, src.Meta.GetDefault(meta.KeySyntax, meta.DefaultSyntax))
+			break
+		}
+		if src.Content.IsBinary() || tgt.Content.IsBinary() {
+			return zettel.Zettel{}, ErrCannotMerge{Reason: "binary content cannot be appended"}
+		}
+		srcSyntax := src.Meta.GetDefault(meta.KeySyntax, meta.DefaultSyntax)
+		if tgtSyntax := tgt.Meta.GetDefault(meta.KeySyntax, meta.DefaultSyntax); srcSyntax != tgtSyntax {
+			return zettel.Zettel{}, ErrCannotMerge{
+				Reason: fmt.Sprintf("content syntax %q cannot be appended to syntax %q", srcSyntax, tgtSyntax)}
+		}
+		var buf bytes.Buffer
+		buf.Write(bytes.TrimRight(tgt.Content.AsBytes(), " \t\r\n"))
+		buf.WriteString("\n\n")
+		buf.Write(src.Content.AsBytes())
+		content = zettel.NewContent(buf.Bytes())
+		m.Set(meta.KeySyntax, srcSyntax)
+	case MergeContentSource:
+		content = src.Content
+		m.Set(meta.KeySyntax, src.Meta.GetDefault(meta.KeySyntax, meta.DefaultSyntax))
+	}
+
+	// References from target to source, and from source to itself, now
+	// refer to the merged zettel.
+	rz.replaceInMeta(m)
+	removeSelfRefs(m)
+	prepareUpdateMeta(m, tgt.Meta)
+	return zettel.Zettel{Meta: m, Content: rz.replaceInContent(m, content)}, nil
+}
+
+// mergeMeta adds the metadata of the source to the metadata of the target.
+// Values of set types are combined, other values of the source are only
+// used if the target has no such value, or if preferSource is true. The
+// syntax is determined by the content of the merged zettel.
+func mergeMeta(m, srcMeta *meta.Meta, preferSource bool) {
+	for key, val := range srcMeta.All() {
+		switch key {
+		case meta.KeyCreated, meta.KeyModified, meta.KeySyntax:
+			continue
+		}
+		if meta.IsComputed(key) {
+			continue
+		}
+		tgtVal, found := m.Get(key)
+		if !found {
+			m.Set(key, val)
+			continue
+		}
+		if meta.GetDescription(key).Type.IsSet {
+			fields := slices.Collect(tgtVal.Fields())
+			for s := range val.Fields() {
+				if !slices.Contains(fields, s) {
+					fields = append(fields, s)
+				}
+			}
+			m.Set(key, meta.Value(strings.Join(fields, " ")))
+			continue
+		}
+		if preferSource {
+			m.Set(key, val)
+		}
+	}
+}
+
+// removeSelfRefs removes all references of a zettel to itself from metadata.
+func removeSelfRefs(m *meta.Meta) {
+	self := m.Zid.String()
+	changes := map[string]meta.Value{}
+	for key, val := range m.All() {
+		if meta.IsComputed(key) {
+			continue
+		}
+		descr := meta.GetDescription(key)
+		if descr.Type != meta.TypeID && descr.Type != meta.TypeIDSet {
+			continue
+		}
+		fields := slices.Collect(val.Fields())
+		if !slices.Contains(fields, self) {
+			continue
+		}
+		changes[key] = meta.Value(strings.Join(slices.DeleteFunc(fields, func(s string) bool { return s == self }), " "))
+	}
+	for key, val := range changes {
+		if val == "" {
+			m.Delete(key)
+		} else {
+			m.Set(key, val)
+		}
+	}
+}
+
+// makeArchivedZettel creates the version of the source zettel that is kept,
+// if it is archived. It is only visible in expert mode. If possible, its
+// content refers to the target zettel.
+func makeArchivedZettel(src zettel.Zettel, tgtZid id.Zid) zettel.Zettel {
+	m := src.Meta.Clone()
+	m.Set(meta.KeyVisibility, meta.ValueVisibilityExpert)
+	prepareUpdateMeta(m, src.Meta)
+	if m.GetDefault(meta.KeySyntax, meta.DefaultSyntax) != meta.ValueSyntaxZmk || src.Content.IsBinary() {
+		return zettel.Zettel{Meta: m, Content: src.Content}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "This zettel was merged into [[%v]].\n\n", tgtZid)
+	buf.Write(src.Content.AsBytes())
+	return zettel.Zettel{Meta: m, Content: zettel.NewContent(buf.Bytes())}
+}
@@ -0,0 +1,121 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"maps"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+)
+
+func makeTestMeta(zid id.Zid, keyVals ...string) *meta.Meta {
+	m := meta.New(zid)
+	for i := 0; i < len(keyVals); i += 2 {
+		m.Set(keyVals[i], meta.Value(keyVals[i+1]))
+	}
+	return m
+}
+
+func checkTestMeta(t *testing.T, m *meta.Meta, keyVals ...string) {
+	t.Helper()
+	exp := map[string]meta.Value{}
+	for i := 0; i < len(keyVals); i += 2 {
+		exp[keyVals[i]] = meta.Value(keyVals[i+1])
+	}
+	got := map[string]meta.Value{}
+	for key, val := range m.All() {
+		if key != meta.KeyID {
+			got[key] = val
+		}
+	}
+	if !maps.Equal(got, exp) {
+		t.Errorf("expected metadata %v, but got %v", exp, got)
+	}
+}
+
+func TestMergeMeta(t *testing.T) {
+	t.Parallel()
+	newTarget := func() *meta.Meta {
+		return makeTestMeta(1,
+			meta.KeyTitle, "Target",
+			meta.KeyTags, "#a #b",
+			meta.KeySyntax, "zmk",
+			meta.KeyCreated, "20250101000000",
+		)
+	}
+	src := makeTestMeta(2,
+		meta.KeyTitle, "Source",
+		meta.KeyTags, "#b #c",
+		meta.KeySyntax, "md",
+		meta.KeyCreated, "20240101000000",
+		meta.KeyModified, "20240202000000",
+		meta.KeyFolge, "20250101000003",
+		meta.KeyPrecursor, "20250101000004",
+		"author", "Someone",
+	)
+
+	m := newTarget()
+	mergeMeta(m, src, false)
+	checkTestMeta(t, m,
+		meta.KeyTitle, "Target",
+		meta.KeyTags, "#a #b #c",
+		meta.KeySyntax, "zmk",
+		meta.KeyCreated, "20250101000000",
+		meta.KeyPrecursor, "20250101000004",
+		"author", "Someone",
+	)
+
+	m = newTarget()
+	mergeMeta(m, src, true)
+	checkTestMeta(t, m,
+		meta.KeyTitle, "Source",
+		meta.KeyTags, "#a #b #c",
+		meta.KeySyntax, "zmk",
+		meta.KeyCreated, "20250101000000",
+		meta.KeyPrecursor, "20250101000004",
+		"author", "Someone",
+	)
+}
+
+func TestRemoveSelfRefs(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name string
+		in   []string
+		exp  []string
+	}{
+		{"nothing", nil, nil},
+		{"no-self",
+			[]string{meta.KeyPrecursor, "20250101000002", meta.KeyPredecessor, "20250101000003"},
+			[]string{meta.KeyPrecursor, "20250101000002", meta.KeyPredecessor, "20250101000003"}},
+		{"id",
+			[]string{meta.KeyPredecessor, "20250101000001", meta.KeyTitle, "20250101000001"},
+			[]string{meta.KeyTitle, "20250101000001"}},
+		{"idset",
+			[]string{meta.KeyPrecursor, "20250101000002 20250101000001 20250101000003"},
+			[]string{meta.KeyPrecursor, "20250101000002 20250101000003"}},
+		{"idset-only-self",
+			[]string{meta.KeyPrecursor, "20250101000001", meta.KeyPrequel, "20250101000001 20250101000002"},
+			[]string{meta.KeyPrequel, "20250101000002"}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := makeTestMeta(20250101000001, tc.in...)
+			removeSelfRefs(m)
+			checkTestMeta(t, m, tc.exp...)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/impl"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// PersonalTokenPort is the interface used by the use cases for personal access
+// tokens. It must not check access rights, because a user is not allowed to
+// change the personal access tokens of its user zettel directly.
+type PersonalTokenPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+}
+
+// ErrTokenNotAllowed is returned if an operation is not allowed for the current
+// user, because it is not authenticated or because it was authenticated by a
+// personal access token.
+var ErrTokenNotAllowed = errors.New("operation not allowed for personal access tokens")
+
+// ErrPersonalTokenData is returned if the data of a new personal access token
+// is not valid.
+type ErrPersonalTokenData struct{ Reason string }
+
+func (err ErrPersonalTokenData) Error() string { return "invalid personal access token: " + err.Reason }
+
+// ----- Create a personal access token ----------
+
+// CreatePersonalToken is the data for this use case.
+type CreatePersonalToken struct {
+	logger *slog.Logger
+	port   PersonalTokenPort
+}
+
+// NewCreatePersonalToken creates a new use case.
+func NewCreatePersonalToken(logger *slog.Logger, port PersonalTokenPort) CreatePersonalToken {
+	return CreatePersonalToken{logger: logger, port: port}
+}
+
+// Run executes the use case. It stores a new personal access token for the
+// current user and returns the token. The token itself is not stored and
+// cannot be retrieved later.
+func (uc *CreatePersonalToken) Run(ctx context.Context, pt auth.PersonalToken) ([]byte, error) {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		return nil, ErrTokenNotAllowed
+	}
+	pt.Name = strings.Join(strings.Fields(pt.Name), " ")
+	if pt.Name == "" {
+		return nil, ErrPersonalTokenData{Reason: "missing name"}
+	}
+	if pt.IsExpired(time.Now()) {
+		return nil, ErrPersonalTokenData{Reason: "already expired"}
+	}
+	for _, role := range pt.Roles {
+		if role == "" || strings.ContainsAny(role, " ,") {
+			return nil, ErrPersonalTokenData{Reason: "invalid role " + role}
+		}
+	}
+
+	tokenID, secret := impl.NewPersonalTokenSecret()
+	pt.ID, pt.Hash = tokenID, impl.HashPersonalTokenSecret(secret)
+	err := updateUserZettel(ctx, uc.port, user.Zid, func(m *meta.Meta) { m.Set(pt.Key(), pt.Value()) })
+	uc.logger.Info("Create personal token", "token", tokenID, "name", pt.Name, logging.User(ctx), logging.Err(err))
+	if err != nil {
+		return nil, err
+	}
+	return auth.MakePersonalToken(user.Zid, tokenID, secret), nil
+}
+
+// ----- Revoke a personal access token ----------
+
+// RevokePersonalToken is the data for this use case.
+type RevokePersonalToken struct {
+	logger *slog.Logger
+	port   PersonalTokenPort
+}
+
+// NewRevokePersonalToken creates a new use case.
+func NewRevokePersonalToken(logger *slog.Logger, port PersonalTokenPort) RevokePersonalToken {
+	return RevokePersonalToken{logger: logger, port: port}
+}
+
+// Run executes the use case. The given personal access token of the current
+// user cannot be used anymore.
+func (uc *RevokePersonalToken) Run(ctx context.Context, tokenID string) error {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		return ErrTokenNotAllowed
+	}
+	err := updateUserZettel(ctx, uc.port, user.Zid, func(m *meta.Meta) {
+		m.Delete(auth.KeyPrefixPersonalToken + tokenID)
+	})
+	uc.logger.Info("Revoke personal token", "token", tokenID, logging.User(ctx), logging.Err(err))
+	return err
+}
+
+// userZettelPort is the interface used to change a user zettel.
+type userZettelPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+}
+
+// mxUserZettel serializes all changes to user zettel, which are done by
+// updateUserZettel.
+var mxUserZettel sync.Mutex
+
+func updateUserZettel(ctx context.Context, port userZettelPort, zid id.Zid, change func(*meta.Meta)) error {
+	mxUserZettel.Lock()
+	defer mxUserZettel.Unlock()
+	oldZettel, err := port.GetZettel(box.NoEnrichContext(ctx), zid)
+	if err != nil {
+		return err
+	}
+	m := oldZettel.Meta.Clone()
+	change(m)
+	if m.Equal(oldZettel.Meta, true) {
+		return nil
+	}
+	prepareUpdateMeta(m, oldZettel.Meta)
+	return port.UpdateZettel(ctx, zettel.Zettel{Meta: m, Content: oldZettel.Content})
+}
+
+// ----- Retrieve the user of a personal access token ----------
+
+// GetUserByPersonalTokenPort is the interface used by this use case.
+type GetUserByPersonalTokenPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+}
+
+// GetUserByPersonalToken is the data for this use case.
+type GetUserByPersonalToken struct {
+	port GetUserByPersonalTokenPort
+}
+
+// NewGetUserByPersonalToken creates a new use case.
+func NewGetUserByPersonalToken(port GetUserByPersonalTokenPort) GetUserByPersonalToken {
+	return GetUserByPersonalToken{port: port}
+}
+
+// ErrInvalidPersonalToken is returned if a personal access token is unknown,
+// revoked, or expired.
+var ErrInvalidPersonalToken = errors.New("invalid personal access token")
+
+// GetUserByToken executes the use case. It returns the user metadata,
+// restricted by the scope of the token, and data about the token.
+func (uc GetUserByPersonalToken) GetUserByToken(ctx context.Context, token []byte) (*meta.Meta, *auth.TokenData, error) {
+	zid, tokenID, secret, ok := auth.SplitPersonalToken(token)
+	if !ok {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+	userZettel, err := uc.port.GetZettel(box.NoEnrichContext(ctx), zid)
+	if err != nil {
+		return nil, nil, err
+	}
+	userMeta := userZettel.Meta
+	ident, found := userMeta.Get(meta.KeyUserID)
+	if !found || ident == "" {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+	pt, found := auth.GetPersonalToken(userMeta, tokenID)
+	if !found || !impl.ComparePersonalTokenSecret(pt.Hash, secret) {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+	now := time.Now().Round(time.Second)
+	if pt.IsExpired(now) {
+		return nil, nil, ErrInvalidPersonalToken
+	}
+	return auth.ScopeUser(userMeta, &pt), &auth.TokenData{
+		Token:   token,
+		Now:     now,
+		Expires: pt.Expires,
+		Ident:   string(ident),
+		Zid:     zid,
+	}, nil
+}
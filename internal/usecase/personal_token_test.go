@@ -0,0 +1,91 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/impl"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// tokenTestPort stores some zettel in memory.
+type tokenTestPort map[id.Zid]*meta.Meta
+
+func (tp tokenTestPort) GetZettel(_ context.Context, zid id.Zid) (zettel.Zettel, error) {
+	m, found := tp[zid]
+	if !found {
+		return zettel.Zettel{}, box.ErrZettelNotFound{Zid: zid}
+	}
+	return zettel.Zettel{Meta: m.Clone(), Content: zettel.NewContent(nil)}, nil
+}
+
+func TestGetUserByPersonalToken(t *testing.T) {
+	t.Parallel()
+	const secret = "SECRET"
+	now := time.Now()
+	addToken := func(m *meta.Meta, tokenID string, expires time.Time, roles ...string) {
+		pt := auth.PersonalToken{
+			ID: tokenID, Name: tokenID, Hash: impl.HashPersonalTokenSecret(secret),
+			Expires: expires, ReadOnly: true, Roles: roles,
+		}
+		m.Set(pt.Key(), pt.Value())
+	}
+	user := makeTestMeta(13, meta.KeyUserID, "user")
+	addToken(user, "valid", now.Add(time.Hour), "project")
+	addToken(user, "expired", now.Add(-time.Hour))
+	noIdent := makeTestMeta(14)
+	addToken(noIdent, "valid", now.Add(time.Hour))
+	uc := NewGetUserByPersonalToken(tokenTestPort{13: user, 14: noIdent})
+
+	token := auth.MakePersonalToken(13, "valid", secret)
+	got, data, err := uc.GetUserByToken(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestMeta(t, got, meta.KeyUserID, "user", auth.KeyTokenScope, auth.TokenScopeRead, auth.KeyTokenRoles, "project")
+	if data.Zid != 13 || data.Ident != "user" || string(data.Token) != string(token) {
+		t.Errorf("unexpected token data %v", data)
+	}
+
+	testcases := []struct {
+		name  string
+		token []byte
+	}{
+		{"malformed", []byte("zspat_00000000000013_valid")},
+		{"other", []byte("zettelstore-token")},
+		{"secret", auth.MakePersonalToken(13, "valid", "OTHER")},
+		{"expired", auth.MakePersonalToken(13, "expired", secret)},
+		{"revoked", auth.MakePersonalToken(13, "revoked", secret)},
+		{"ident", auth.MakePersonalToken(14, "valid", secret)},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := uc.GetUserByToken(context.Background(), tc.token); !errors.Is(err, ErrInvalidPersonalToken) {
+				t.Errorf("expected error %v, but got %v", ErrInvalidPersonalToken, err)
+			}
+		})
+	}
+	if _, _, err = uc.GetUserByToken(context.Background(), auth.MakePersonalToken(15, "valid", secret)); err == nil {
+		t.Error("token of a not existing user must not be accepted")
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	"t73f.de/r/zsc/domain/id"
 
+	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/logging"
 )
 
@@ -71,3 +72,29 @@ func (uc *ReIndex) Run(ctx context.Context, zid id.Zid) error {
 	uc.logger.Info("ReIndex zettel", "zid", zid, logging.User(ctx), logging.Err(err))
 	return err
 }
+
+// ----- Check the consistency of the index ----------
+
+// CheckIndexPort is the interface used by this use case.
+type CheckIndexPort interface {
+	CheckIndex(ctx context.Context, repair bool) ([]box.IndexProblem, error)
+}
+
+// CheckIndex is the data for this use case.
+type CheckIndex struct {
+	logger *slog.Logger
+	port   CheckIndexPort
+}
+
+// NewCheckIndex creates a new use case.
+func NewCheckIndex(logger *slog.Logger, port CheckIndexPort) CheckIndex {
+	return CheckIndex{logger: logger, port: port}
+}
+
+// Run executes the use case. If repair is true, the detected problems are
+// repaired.
+func (uc *CheckIndex) Run(ctx context.Context, repair bool) ([]box.IndexProblem, error) {
+	problems, err := uc.port.CheckIndex(ctx, repair)
+	uc.logger.Info("Check index", "problems", len(problems), "repair", repair, logging.User(ctx), logging.Err(err))
+	return problems, err
+}
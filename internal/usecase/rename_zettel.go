@@ -0,0 +1,200 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// RenameZettelPort is the interface used by this use case.
+//
+// The port must not check the access rights of the current user, because the
+// use case checks them in advance. GetMeta must return metadata that is
+// enriched with the references of other zettel.
+type RenameZettelPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+	CanUpdateZettel(ctx context.Context, zettel zettel.Zettel) bool
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+	CanDeleteZettel(ctx context.Context, zid id.Zid) bool
+	DeleteZettel(ctx context.Context, zid id.Zid) error
+}
+
+// RenameZettel is the data for this use case.
+type RenameZettel struct {
+	logger *slog.Logger
+	policy auth.Policy
+	port   RenameZettelPort
+	mx     *sync.Mutex // Only one rename at a time
+}
+
+// ErrZidInUse is returned if a zettel should get an identifier that is
+// already used by another zettel.
+type ErrZidInUse struct{ Zid id.Zid }
+
+func (err ErrZidInUse) Error() string { return "zettel identifier already in use: " + err.Zid.String() }
+
+// NewRenameZettel creates a new use case.
+func NewRenameZettel(logger *slog.Logger, policy auth.Policy, port RenameZettelPort) RenameZettel {
+	return RenameZettel{logger: logger, policy: policy, port: port, mx: &sync.Mutex{}}
+}
+
+// Run executes the use case. The zettel with identifier curZid is stored
+// under the identifier newZid. All references to curZid, in the content and
+// in the metadata of other zettel, are changed to newZid. If withStub is
+// true, a zettel that refers to the renamed zettel is left under curZid,
+// otherwise the zettel with identifier curZid is deleted.
+//
+// Run returns the identifier of all other zettel that were changed. If one of
+// the changes fails, all changes made so far are reverted.
+func (uc *RenameZettel) Run(ctx context.Context, curZid, newZid id.Zid, withStub bool) ([]id.Zid, error) {
+	uc.mx.Lock()
+	defer uc.mx.Unlock()
+
+	plan, err := uc.prepare(ctx, curZid, newZid, withStub)
+	if err != nil {
+		uc.logger.Info("Rename zettel not possible", "zid", curZid, "new", newZid, logging.User(ctx), logging.Err(err))
+		return nil, err
+	}
+
+	// The renamed zettel is created first, so that all references are valid
+	// at any time.
+	updates := make([]zettel.Zettel, 0, len(plan.refs)+2)
+	updates = append(updates, plan.renamed)
+	updates = append(updates, plan.refs...)
+	if withStub {
+		updates = append(updates, plan.stub)
+	}
+	for i, z := range updates {
+		if err = uc.port.UpdateZettel(ctx, z); err != nil {
+			uc.rollback(ctx, plan, i)
+			uc.logger.Info("Rename zettel failed", "zid", curZid, "new", newZid, logging.User(ctx), logging.Err(err))
+			return nil, err
+		}
+	}
+	if !withStub {
+		if err = uc.port.DeleteZettel(ctx, curZid); err != nil {
+			uc.rollback(ctx, plan, len(updates))
+			uc.logger.Info("Rename zettel failed", "zid", curZid, "new", newZid, logging.User(ctx), logging.Err(err))
+			return nil, err
+		}
+	}
+
+	result := make([]id.Zid, len(plan.refs))
+	for i, z := range plan.refs {
+		result[i] = z.Meta.Zid
+	}
+	uc.logger.Info("Rename zettel", "zid", curZid, "new", newZid, "refs", len(result), "stub", withStub, logging.User(ctx))
+	return result, nil
+}
+
+// renamePlan contains all zettel that must be stored to rename a zettel.
+type renamePlan struct {
+	renamed zettel.Zettel   // Zettel under its new identifier
+	stub    zettel.Zettel   // Zettel that is left under the old identifier
+	refs    []zettel.Zettel // Referencing zettel with changed references
+	olds    []zettel.Zettel // Previous version of refs
+}
+
+// prepare checks that the rename is allowed and possible. It computes all
+// zettel that must be stored.
+func (uc *RenameZettel) prepare(ctx context.Context, curZid, newZid id.Zid, withStub bool) (renamePlan, error) {
+	var plan renamePlan
+	if !curZid.IsValid() {
+		return plan, box.ErrInvalidZid{Zid: curZid.String()}
+	}
+	if !newZid.IsValid() || newZid == curZid {
+		return plan, box.ErrInvalidZid{Zid: newZid.String()}
+	}
+	noEnrichCtx := box.NoEnrichContext(ctx)
+	if _, err := uc.port.GetZettel(noEnrichCtx, newZid); err == nil {
+		return plan, ErrZidInUse{Zid: newZid}
+	} else if _, isErr := errors.AsType[box.ErrZettelNotFound](err); !isErr {
+		return plan, err
+	}
+	old, err := uc.port.GetZettel(noEnrichCtx, curZid)
+	if err != nil {
+		return plan, err
+	}
+	user := auth.GetCurrentUser(ctx)
+	rz := newRefZid(curZid, newZid)
+
+	newMeta := old.Meta.Clone()
+	newMeta.Zid = newZid
+	rz.replaceInMeta(newMeta)
+	prepareUpdateMeta(newMeta, old.Meta)
+	plan.renamed = zettel.Zettel{Meta: newMeta, Content: rz.replaceInContent(old.Meta, old.Content)}
+	if !uc.policy.CanCreate(user, newMeta) {
+		return plan, box.NewErrNotAllowed("Rename", user, curZid)
+	}
+	if !uc.port.CanUpdateZettel(ctx, plan.renamed) {
+		return plan, box.ErrReadOnly
+	}
+	if withStub {
+		plan.stub = makeRenameStub(old.Meta, newZid)
+		if !uc.policy.CanWrite(user, old.Meta, plan.stub.Meta) {
+			return plan, box.NewErrNotAllowed("Rename", user, curZid)
+		}
+		if !uc.port.CanUpdateZettel(ctx, plan.stub) {
+			return plan, box.ErrReadOnly
+		}
+	} else {
+		if !uc.policy.CanDelete(user, old.Meta) {
+			return plan, box.NewErrNotAllowed("Rename", user, curZid)
+		}
+		if !uc.port.CanDeleteZettel(ctx, curZid) {
+			return plan, box.ErrReadOnly
+		}
+	}
+
+	plan.refs, plan.olds, err = prepareRefChanges(ctx, uc.port, uc.policy, rz, "Rename")
+	return plan, err
+}
+
+// rollback restores the state before the rename. Only the first numUpdates
+// zettel of the plan were stored.
+func (uc *RenameZettel) rollback(ctx context.Context, plan renamePlan, numUpdates int) {
+	if numUpdates > 0 {
+		restoreZettel(ctx, uc.logger, uc.port, plan.olds[:min(numUpdates-1, len(plan.refs))])
+		if err := uc.port.DeleteZettel(ctx, plan.renamed.Meta.Zid); err != nil {
+			uc.logger.Error("Unable to remove renamed zettel", "zid", plan.renamed.Meta.Zid, "err", err)
+		}
+	}
+}
+
+// makeRenameStub creates the zettel that is left under the old identifier.
+func makeRenameStub(oldMeta *meta.Meta, newZid id.Zid) zettel.Zettel {
+	m := meta.New(oldMeta.Zid)
+	for _, key := range []string{meta.KeyTitle, meta.KeyCreated, meta.KeyVisibility} {
+		if val, found := oldMeta.Get(key); found {
+			m.Set(key, val)
+		}
+	}
+	m.Set(meta.KeySyntax, meta.ValueSyntaxZmk)
+	prepareUpdateMeta(m, oldMeta)
+	content := fmt.Sprintf("This zettel was renamed to [[%v]].", newZid)
+	return zettel.Zettel{Meta: m, Content: zettel.NewContent([]byte(content))}
+}
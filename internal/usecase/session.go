@@ -0,0 +1,171 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// SessionPort is the interface used by the use cases to revoke sessions. It
+// must not check access rights, because a session must be revocable even if
+// the user zettel is read-only.
+type SessionPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+}
+
+// ----- List active sessions ----------
+
+// ListSessions is the data for this use case.
+type ListSessions struct {
+	sessions auth.SessionManager
+	authz    auth.AuthzManager
+}
+
+// NewListSessions creates a new use case.
+func NewListSessions(sessions auth.SessionManager, authz auth.AuthzManager) ListSessions {
+	return ListSessions{sessions: sessions, authz: authz}
+}
+
+// Run executes the use case. The owner gets all active sessions, every other
+// user gets only its own sessions.
+func (uc ListSessions) Run(ctx context.Context) ([]auth.SessionData, error) {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		return nil, ErrTokenNotAllowed
+	}
+	sessions := uc.sessions.Sessions()
+	if uc.authz.IsOwner(user.Zid) {
+		return sessions, nil
+	}
+	return slices.DeleteFunc(sessions, func(sd auth.SessionData) bool { return sd.Zid != user.Zid }), nil
+}
+
+// ----- Revoke a session ----------
+
+// RevokeSession is the data for this use case.
+type RevokeSession struct {
+	logger   *slog.Logger
+	port     SessionPort
+	sessions auth.SessionManager
+	authz    auth.AuthzManager
+}
+
+// NewRevokeSession creates a new use case.
+func NewRevokeSession(logger *slog.Logger, port SessionPort, sessions auth.SessionManager, authz auth.AuthzManager) RevokeSession {
+	return RevokeSession{logger: logger, port: port, sessions: sessions, authz: authz}
+}
+
+// Run executes the use case. A user may revoke its own sessions, the owner
+// may revoke all sessions. Revoking an unknown session is not an error,
+// because it has already ended.
+func (uc *RevokeSession) Run(ctx context.Context, session string) error {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		return ErrTokenNotAllowed
+	}
+	sessions := uc.sessions.Sessions()
+	idx := slices.IndexFunc(sessions, func(sd auth.SessionData) bool { return sd.Session == session })
+	if idx < 0 {
+		return nil
+	}
+	sd := sessions[idx]
+	if sd.Zid != user.Zid && !uc.authz.IsOwner(user.Zid) {
+		return box.NewErrNotAllowed("RevokeSession", user, sd.Zid)
+	}
+
+	uc.sessions.RevokeSession(session, sd.Expires)
+	var err error
+	if !uc.authz.IsReadonly() {
+		err = updateUserZettel(ctx, uc.port, sd.Zid, func(m *meta.Meta) {
+			auth.RevokeSession(m, session, sd.Expires, time.Now())
+		})
+	}
+	uc.logger.Info("Revoke session", "session", session, "ident", sd.Ident, logging.User(ctx), logging.Err(err))
+	return err
+}
+
+// ----- End the current session ----------
+
+// Logout is the data for this use case.
+type Logout struct {
+	logger   *slog.Logger
+	sessions auth.SessionManager
+}
+
+// NewLogout creates a new use case.
+func NewLogout(logger *slog.Logger, sessions auth.SessionManager) Logout {
+	return Logout{logger: logger, sessions: sessions}
+}
+
+// Run executes the use case. The current session ends. In contrast to
+// RevokeSession, the user zettel is not changed, so the session is revoked
+// until the next restart only.
+func (uc *Logout) Run(ctx context.Context) {
+	authData := auth.GetAuthData(ctx)
+	if authData == nil || authData.Session == "" {
+		return
+	}
+	uc.sessions.RevokeSession(authData.Session, authData.Expires)
+	uc.logger.Info("Logout", "session", authData.Session, logging.User(ctx))
+}
+
+// ----- Revoke all sessions of a user ----------
+
+// RevokeAllSessions is the data for this use case.
+type RevokeAllSessions struct {
+	logger   *slog.Logger
+	port     SessionPort
+	sessions auth.SessionManager
+	authz    auth.AuthzManager
+}
+
+// NewRevokeAllSessions creates a new use case.
+func NewRevokeAllSessions(logger *slog.Logger, port SessionPort, sessions auth.SessionManager, authz auth.AuthzManager) RevokeAllSessions {
+	return RevokeAllSessions{logger: logger, port: port, sessions: sessions, authz: authz}
+}
+
+// Run executes the use case. All sessions of the user with the given zettel
+// identifier end, including the current session, if it belongs to that user.
+func (uc *RevokeAllSessions) Run(ctx context.Context, zid id.Zid) error {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		return ErrTokenNotAllowed
+	}
+	if zid != user.Zid && !uc.authz.IsOwner(user.Zid) {
+		return box.NewErrNotAllowed("RevokeAllSessions", user, zid)
+	}
+
+	// Tokens issued within the current second are revoked too, because the
+	// time of issue is truncated to seconds.
+	now := time.Now().Truncate(time.Second)
+	uc.sessions.RevokeUserSessions(zid, now)
+	var err error
+	if !uc.authz.IsReadonly() {
+		err = updateUserZettel(ctx, uc.port, zid, func(m *meta.Meta) { auth.RevokeAllSessions(m, now) })
+	}
+	uc.logger.Info("Revoke all sessions", "zid", zid, logging.User(ctx), logging.Err(err))
+	return err
+}
@@ -0,0 +1,87 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// sessionTestManager knows some sessions and records revoked ones.
+type sessionTestManager struct {
+	sessions []auth.SessionData
+	revoked  []string
+}
+
+func (sm *sessionTestManager) Sessions() []auth.SessionData { return slices.Clone(sm.sessions) }
+func (sm *sessionTestManager) RevokeSession(session string, _ time.Time) {
+	sm.revoked = append(sm.revoked, session)
+}
+func (*sessionTestManager) RevokeUserSessions(id.Zid, time.Time) {}
+
+// sessionTestAuthz allows to change zettel.
+type sessionTestAuthz struct{ oidcTestAuthz }
+
+func (sessionTestAuthz) IsReadonly() bool { return false }
+
+// sessionTestPort counts the updates of zettel.
+type sessionTestPort struct {
+	tokenTestPort
+	updates *int
+}
+
+func (sp sessionTestPort) UpdateZettel(_ context.Context, z zettel.Zettel) error {
+	*sp.updates++
+	sp.tokenTestPort[z.Meta.Zid] = z.Meta.Clone()
+	return nil
+}
+
+func TestLogout(t *testing.T) {
+	t.Parallel()
+	expires := time.Now().Add(time.Hour)
+	user := makeTestMeta(13, meta.KeyUserID, "user")
+	sm := &sessionTestManager{sessions: []auth.SessionData{
+		{Session: "current", Zid: 13, Expires: expires},
+		{Session: "other", Zid: 13, Expires: expires},
+	}}
+	var updates int
+	port := sessionTestPort{tokenTestPort{13: user}, &updates}
+	ctx := auth.UpdateContext(context.Background(), user, &auth.TokenData{Session: "current", Zid: 13, Expires: expires})
+
+	ucLogout := NewLogout(slog.New(slog.DiscardHandler), sm)
+	ucLogout.Run(ctx)
+	if !slices.Equal(sm.revoked, []string{"current"}) || updates != 0 {
+		t.Errorf("logout must revoke session in memory only, but revoked=%v, updates=%d", sm.revoked, updates)
+	}
+
+	ucRevoke := NewRevokeSession(slog.New(slog.DiscardHandler), port, sm, sessionTestAuthz{})
+	if err := ucRevoke.Run(ctx, "other"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(sm.revoked, []string{"current", "other"}) || updates != 1 {
+		t.Errorf("revocation must be stored, but revoked=%v, updates=%d", sm.revoked, updates)
+	}
+	if _, found := port.tokenTestPort[13].Get(auth.KeyPrefixRevokedSession + "other"); !found {
+		t.Error("revoked session not stored in user zettel")
+	}
+}
@@ -0,0 +1,167 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/query"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// ErrInvalidShareLink is returned if a share link is malformed, expired, or if
+// its issuer is no longer allowed to share the zettel.
+var ErrInvalidShareLink = errors.New("invalid or expired share link")
+
+// ErrShareData is returned if the data of a new share link is not valid.
+type ErrShareData struct{ Reason string }
+
+func (err ErrShareData) Error() string { return "invalid share link: " + err.Reason }
+
+// ----- Create a share link ----------
+
+// CreateShareLinkPort is the interface used by this use case.
+type CreateShareLinkPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+}
+
+// CreateShareLink is the data for this use case.
+type CreateShareLink struct {
+	logger *slog.Logger
+	shares auth.ShareManager
+	policy auth.Policy
+	port   CreateShareLinkPort
+}
+
+// NewCreateShareLink creates a new use case.
+func NewCreateShareLink(logger *slog.Logger, shares auth.ShareManager, policy auth.Policy, port CreateShareLinkPort) CreateShareLink {
+	return CreateShareLink{logger: logger, shares: shares, policy: policy, port: port}
+}
+
+// Run executes the use case. It returns a token that allows everybody to read
+// the given zettel until it expires. If transclusions is true, the token
+// allows to read all transcluded zettel too.
+func (uc *CreateShareLink) Run(ctx context.Context, zid id.Zid, expires time.Time, transclusions bool) ([]byte, error) {
+	z, err := uc.port.GetZettel(ctx, zid)
+	if err != nil {
+		return nil, err
+	}
+	user := auth.GetCurrentUser(ctx)
+	if !uc.policy.CanShare(user, z.Meta) {
+		return nil, box.NewErrNotAllowed("Share", user, zid)
+	}
+	now := time.Now().Round(time.Second)
+	if !expires.After(now) {
+		return nil, ErrShareData{Reason: "already expired"}
+	}
+	token, err := uc.shares.GetShareToken(auth.ShareData{
+		Zid:           zid,
+		Issuer:        user.Zid,
+		Issued:        now,
+		Expires:       expires,
+		Transclusions: transclusions,
+	})
+	uc.logger.Info("Create share link", "zid", zid, "expires", expires.Local().Format(time.DateTime),
+		"transclusions", transclusions, logging.User(ctx), logging.Err(err))
+	return token, err
+}
+
+// ----- Retrieve a shared zettel ----------
+
+// GetSharedZettelPort is the interface used by this use case.
+type GetSharedZettelPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+}
+
+// GetSharedZettel is the data for this use case.
+type GetSharedZettel struct {
+	shares   auth.ShareManager
+	policy   auth.Policy
+	port     GetSharedZettelPort
+	evaluate *Evaluate
+}
+
+// NewGetSharedZettel creates a new use case. The port must not check any
+// access rights, because the rights are derived from the share token.
+func NewGetSharedZettel(shares auth.ShareManager, policy auth.Policy, port GetSharedZettelPort, evaluate *Evaluate) GetSharedZettel {
+	return GetSharedZettel{shares: shares, policy: policy, port: port, evaluate: evaluate}
+}
+
+// Run executes the use case. It returns the evaluated zettel and the data of
+// the share token. All zettel are read with the access rights of the user who
+// issued the token. If this user is no longer allowed to share the zettel,
+// the token is invalid.
+func (uc *GetSharedZettel) Run(ctx context.Context, zid id.Zid, token []byte, syntax string) (*zettel.ParsedZettel, auth.ShareData, error) {
+	sd, err := uc.shares.CheckShareToken(token)
+	if err != nil || sd.Zid != zid {
+		return nil, sd, ErrInvalidShareLink
+	}
+	issuer, err := uc.port.GetZettel(box.NoEnrichContext(ctx), sd.Issuer)
+	if err != nil || !issuer.Meta.Has(meta.KeyUserID) {
+		return nil, sd, ErrInvalidShareLink
+	}
+	issuerCtx := auth.UpdateContext(ctx, issuer.Meta, nil)
+	z, err := uc.evaluate.GetZettel(issuerCtx, zid)
+	if err != nil {
+		if errors.Is(err, &box.ErrNotAllowed{}) {
+			return nil, sd, ErrInvalidShareLink
+		}
+		return nil, sd, err
+	}
+	if !uc.policy.CanShare(issuer.Meta, z.Meta) {
+		return nil, sd, ErrInvalidShareLink
+	}
+	port := sharePort{uc: uc, issuer: issuer.Meta, transclusions: sd.Transclusions}
+	return uc.evaluate.runZettel(issuerCtx, &port, z, syntax), sd, nil
+}
+
+// sharePort is used to evaluate a shared zettel. Other zettel are only
+// accessible if the token allows transclusions and the issuer of the token is
+// allowed to share them.
+type sharePort struct {
+	uc            *GetSharedZettel
+	issuer        *meta.Meta
+	transclusions bool
+}
+
+func (sp *sharePort) GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error) {
+	if sp.transclusions {
+		z, err := sp.uc.evaluate.GetZettel(ctx, zid)
+		if err != nil || sp.uc.policy.CanShare(sp.issuer, z.Meta) {
+			return z, err
+		}
+	}
+	return zettel.Zettel{}, box.NewErrNotAllowed("Share", nil, zid)
+}
+
+func (sp *sharePort) QueryMeta(ctx context.Context, q *query.Query) ([]*meta.Meta, error) {
+	if !sp.transclusions {
+		return nil, box.NewErrNotAllowed("Share", nil, id.Invalid)
+	}
+	ml, err := sp.uc.evaluate.QueryMeta(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(ml, func(m *meta.Meta) bool { return !sp.uc.policy.CanShare(sp.issuer, m) }), nil
+}
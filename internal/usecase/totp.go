@@ -0,0 +1,174 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/impl"
+	"zettelstore.de/z/internal/config"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/zettel"
+)
+
+// TOTPPort is the interface used by the use cases for time-based one-time
+// passwords. It must not check access rights, because a user is not allowed
+// to change the relevant metadata of its user zettel directly.
+type TOTPPort interface {
+	GetZettel(ctx context.Context, zid id.Zid) (zettel.Zettel, error)
+	UpdateZettel(ctx context.Context, zettel zettel.Zettel) error
+}
+
+// ErrInvalidOTP is returned if a one-time password is not valid.
+var ErrInvalidOTP = errors.New("invalid one-time password")
+
+// ErrTOTPEnabled is returned if one-time passwords should be enabled, but
+// they are already enabled.
+var ErrTOTPEnabled = errors.New("one-time passwords are already enabled")
+
+// ErrTOTPRequired is returned if one-time passwords should be disabled, but
+// they are required for the user.
+var ErrTOTPRequired = errors.New("one-time passwords are required")
+
+// ErrTOTPEnrolment is returned if a user logs in with a valid credential, but
+// one-time passwords are required for the user and are not enabled yet.
+var ErrTOTPEnrolment = errors.New("one-time passwords must be enabled")
+
+// ----- Enable one-time passwords ----------
+
+// EnableTOTP is the data for this use case.
+type EnableTOTP struct {
+	logger *slog.Logger
+	port   TOTPPort
+}
+
+// NewEnableTOTP creates a new use case.
+func NewEnableTOTP(logger *slog.Logger, port TOTPPort) EnableTOTP {
+	return EnableTOTP{logger: logger, port: port}
+}
+
+// Run executes the use case. The given code must be valid for the given
+// secret, to make sure that the user has stored the secret in an
+// authenticator app. It returns the recovery codes, which are shown only
+// once.
+func (uc *EnableTOTP) Run(ctx context.Context, secret, code string) ([]string, error) {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		return nil, ErrTokenNotAllowed
+	}
+	if auth.HasTOTP(user) {
+		return nil, ErrTOTPEnabled
+	}
+	if _, ok := impl.CheckTOTP(secret, code, time.Now()); !ok {
+		return nil, ErrInvalidOTP
+	}
+
+	codes, err := storeTOTP(ctx, uc.port, user.Zid, secret)
+	uc.logger.Info("Enable one-time passwords", logging.User(ctx), logging.Err(err))
+	return codes, err
+}
+
+// storeTOTP stores the secret and the hashes of new recovery codes in the
+// user zettel. It returns the recovery codes.
+func storeTOTP(ctx context.Context, port TOTPPort, zid id.Zid, secret string) ([]string, error) {
+	codes := impl.NewRecoveryCodes()
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = impl.HashRecoveryCode(code)
+	}
+	err := updateUserZettel(ctx, port, zid, func(m *meta.Meta) {
+		m.Set(auth.KeyTOTPSecret, meta.Value(secret))
+		m.Set(auth.KeyTOTPRecovery, meta.Value(strings.Join(hashes, " ")))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ----- Disable one-time passwords ----------
+
+// DisableTOTP is the data for this use case.
+type DisableTOTP struct {
+	logger     *slog.Logger
+	port       TOTPPort
+	authz      auth.AuthzManager
+	authConfig config.AuthConfig
+}
+
+// NewDisableTOTP creates a new use case.
+func NewDisableTOTP(logger *slog.Logger, port TOTPPort, authz auth.AuthzManager, authConfig config.AuthConfig) DisableTOTP {
+	return DisableTOTP{logger: logger, port: port, authz: authz, authConfig: authConfig}
+}
+
+// Run executes the use case. The given code must be a valid one-time password
+// or an unused recovery code of the current user.
+func (uc *DisableTOTP) Run(ctx context.Context, code string) error {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		return ErrTokenNotAllowed
+	}
+	if !auth.HasTOTP(user) {
+		return nil
+	}
+	if uc.authConfig.IsTOTPRequired(uc.authz.GetUserRole(user)) {
+		return ErrTOTPRequired
+	}
+	secret, _ := user.Get(auth.KeyTOTPSecret)
+	if _, ok := impl.CheckTOTP(string(secret), code, time.Now()); !ok {
+		recovery, _ := user.Get(auth.KeyTOTPRecovery)
+		if !slices.Contains(slices.Collect(recovery.Fields()), impl.HashRecoveryCode(code)) {
+			return ErrInvalidOTP
+		}
+	}
+
+	err := updateUserZettel(ctx, uc.port, user.Zid, func(m *meta.Meta) {
+		m.Delete(auth.KeyTOTPSecret)
+		m.Delete(auth.KeyTOTPRecovery)
+	})
+	uc.logger.Info("Disable one-time passwords", logging.User(ctx), logging.Err(err))
+	return err
+}
+
+// useRecoveryCode removes the given recovery code from the user zettel. It
+// returns true, if the code was found.
+func useRecoveryCode(ctx context.Context, port TOTPPort, zid id.Zid, code string) (bool, error) {
+	if strings.TrimSpace(code) == "" {
+		return false, nil
+	}
+	hash := impl.HashRecoveryCode(code)
+	found := false
+	err := updateUserZettel(ctx, port, zid, func(m *meta.Meta) {
+		val, _ := m.Get(auth.KeyTOTPRecovery)
+		hashes := slices.Collect(val.Fields())
+		if idx := slices.Index(hashes, hash); idx >= 0 {
+			found = true
+			if hashes = slices.Delete(hashes, idx, idx+1); len(hashes) > 0 {
+				m.Set(auth.KeyTOTPRecovery, meta.Value(strings.Join(hashes, " ")))
+			} else {
+				m.Delete(auth.KeyTOTPRecovery)
+			}
+		}
+	})
+	return found, err
+}
@@ -15,7 +15,9 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sync"
 
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
@@ -38,27 +40,56 @@ type UpdateZettelPort interface {
 type UpdateZettel struct {
 	logger *slog.Logger
 	port   UpdateZettelPort
+	mx     *sync.Mutex // Check of version and update must not be interrupted
 }
 
 // NewUpdateZettel creates a new use case.
 func NewUpdateZettel(logger *slog.Logger, port UpdateZettelPort) UpdateZettel {
-	return UpdateZettel{logger: logger, port: port}
+	return UpdateZettel{logger: logger, port: port, mx: &sync.Mutex{}}
 }
 
-// Run executes the use case.
-func (uc *UpdateZettel) Run(ctx context.Context, zettel zettel.Zettel, hasContent bool) error {
+// ErrVersionConflict is returned, if the zettel was changed since the given
+// version was retrieved.
+type ErrVersionConflict struct {
+	Current zettel.Zettel // The zettel, as it is currently stored
+}
+
+func (err ErrVersionConflict) Error() string {
+	return fmt.Sprintf("zettel %v was changed in the meantime", err.Current.Meta.Zid)
+}
+
+// Run executes the use case. If version is not empty, the zettel is only
+// updated if the stored zettel still has this version.
+func (uc *UpdateZettel) Run(ctx context.Context, zettel zettel.Zettel, version string, hasContent bool) error {
+	uc.mx.Lock()
+	defer uc.mx.Unlock()
 	m := zettel.Meta
 	oldZettel, err := uc.port.GetZettel(box.NoEnrichContext(ctx), m.Zid)
 	if err != nil {
 		return err
 	}
+	if version != "" && version != oldZettel.Version() {
+		uc.logger.Info("Update zettel conflicts", "zid", m.Zid, logging.User(ctx))
+		return ErrVersionConflict{Current: oldZettel}
+	}
 	if zettel.Equal(oldZettel, false) {
 		return nil
 	}
 
-	// Update relevant computed, but stored values.
+	prepareUpdateMeta(m, oldZettel.Meta)
+	if !hasContent {
+		zettel.Content = oldZettel.Content
+	}
+	zettel.Content.TrimSpace()
+	err = uc.port.UpdateZettel(ctx, zettel)
+	uc.logger.Info("Update zettel", "zid", m.Zid, logging.User(ctx), logging.Err(err))
+	return err
+}
+
+// prepareUpdateMeta updates relevant computed, but stored values.
+func prepareUpdateMeta(m, oldMeta *meta.Meta) {
 	if _, found := m.Get(meta.KeyCreated); !found {
-		if val, crFound := oldZettel.Meta.Get(meta.KeyCreated); crFound {
+		if val, crFound := oldMeta.Get(meta.KeyCreated); crFound {
 			m.Set(meta.KeyCreated, val)
 		}
 	}
@@ -67,12 +98,4 @@ func (uc *UpdateZettel) Run(ctx context.Context, zettel zettel.Zettel, hasConten
 	if m.Zid == id.ZidConfiguration {
 		m.Set(meta.KeySyntax, meta.ValueSyntaxNone)
 	}
-
-	if !hasContent {
-		zettel.Content = oldZettel.Content
-	}
-	zettel.Content.TrimSpace()
-	err = uc.port.UpdateZettel(ctx, zettel)
-	uc.logger.Info("Update zettel", "zid", m.Zid, logging.User(ctx), logging.Err(err))
-	return err
 }
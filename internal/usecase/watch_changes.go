@@ -0,0 +1,201 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/query"
+)
+
+// WatchChangesPort is the interface used by this use case.
+type WatchChangesPort interface {
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+	SelectMeta(ctx context.Context, metaSeq []*meta.Meta, q *query.Query) ([]*meta.Meta, error)
+}
+
+// WatchChanges is the data for this use case.
+type WatchChanges struct {
+	logger   *slog.Logger
+	port     WatchChangesPort
+	mx       *sync.Mutex
+	watchers map[*changeWatcher]struct{}
+}
+
+// changeWatcher buffers the changes for one caller of the use case.
+type changeWatcher struct {
+	infos chan box.UpdateInfo
+	lost  atomic.Bool // Some changes were not buffered
+}
+
+// watchBufferSize is the number of changes that are buffered for a watcher.
+const watchBufferSize = 64
+
+// NewWatchChanges creates a new use case. It observes all changes of the
+// given subject, which must be the same data source as the port.
+func NewWatchChanges(logger *slog.Logger, subject box.Subject, port WatchChangesPort) WatchChanges {
+	uc := WatchChanges{
+		logger:   logger,
+		port:     port,
+		mx:       &sync.Mutex{},
+		watchers: map[*changeWatcher]struct{}{},
+	}
+	subject.RegisterObserver(uc.observe)
+	return uc
+}
+
+// observe must not block, because it is called by the notifier of the box.
+func (uc *WatchChanges) observe(ci box.UpdateInfo) {
+	uc.mx.Lock()
+	defer uc.mx.Unlock()
+	for w := range uc.watchers {
+		select {
+		case w.infos <- ci:
+		default:
+			w.lost.Store(true)
+		}
+	}
+}
+
+// Run executes the use case. It calls yield for every change of a zettel
+// that the current user is allowed to read and that is selected by the given
+// query, until the context is done or yield returns false. A zettel that no
+// longer matches the query is reported once. OnReady and OnReload are always
+// reported, with an invalid zettel identifier. If too many changes occur in a
+// short time, they are reported as OnReload. Directives of the query are
+// ignored.
+func (uc *WatchChanges) Run(ctx context.Context, q *query.Query, yield func(box.UpdateReason, id.Zid) bool) error {
+	w := &changeWatcher{infos: make(chan box.UpdateInfo, watchBufferSize)}
+	uc.mx.Lock()
+	uc.watchers[w] = struct{}{}
+	uc.mx.Unlock()
+	defer func() {
+		uc.mx.Lock()
+		delete(uc.watchers, w)
+		uc.mx.Unlock()
+	}()
+
+	visible, err := uc.selectVisible(ctx, q)
+	if err != nil {
+		return err
+	}
+	uc.logger.Debug("Watch changes", "query", q, logging.User(ctx))
+	for {
+		var ci box.UpdateInfo
+		select {
+		case <-ctx.Done():
+			return nil
+		case ci = <-w.infos:
+		}
+		if w.lost.Swap(false) {
+			ci = box.UpdateInfo{Reason: box.OnReload}
+		}
+
+		reason, zid := ci.Reason, ci.Zid
+		switch reason {
+		case box.OnReady, box.OnReload:
+			if visible, err = uc.selectVisible(ctx, q); err != nil {
+				return err
+			}
+			zid = id.Invalid
+		case box.OnZettel:
+			isVisible, err2 := uc.isVisible(ctx, q, zid)
+			if err2 != nil {
+				return err2
+			}
+			if _, wasVisible := visible[zid]; !isVisible && !wasVisible {
+				continue
+			}
+			if isVisible {
+				visible[zid] = struct{}{}
+			} else {
+				delete(visible, zid)
+			}
+		case box.OnDelete:
+			if _, wasVisible := visible[zid]; !wasVisible {
+				continue
+			}
+			delete(visible, zid)
+		default:
+			continue
+		}
+		if !yield(reason, zid) {
+			return nil
+		}
+	}
+}
+
+// selectVisible returns the identifier of all zettel that are relevant for
+// the current user. They are needed to decide whether a deleted zettel must
+// be reported.
+func (uc *WatchChanges) selectVisible(ctx context.Context, q *query.Query) (map[id.Zid]struct{}, error) {
+	var metaSeq []*meta.Meta
+	if zids := q.GetZids(); zids != nil {
+		for _, zid := range zids {
+			m, err := uc.port.GetMeta(ctx, zid)
+			if err != nil {
+				if isInvisible(err) {
+					continue
+				}
+				return nil, err
+			}
+			metaSeq = append(metaSeq, m)
+		}
+		if len(metaSeq) == 0 {
+			return map[id.Zid]struct{}{}, nil
+		}
+	}
+	metaSeq, err := uc.port.SelectMeta(ctx, metaSeq, q)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[id.Zid]struct{}, len(metaSeq))
+	for _, m := range metaSeq {
+		result[m.Zid] = struct{}{}
+	}
+	return result, nil
+}
+
+func (uc *WatchChanges) isVisible(ctx context.Context, q *query.Query, zid id.Zid) (bool, error) {
+	if zids := q.GetZids(); zids != nil && !slices.Contains(zids, zid) {
+		return false, nil
+	}
+	m, err := uc.port.GetMeta(ctx, zid)
+	if err != nil {
+		if isInvisible(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if q == nil {
+		return true, nil
+	}
+	metaSeq, err := uc.port.SelectMeta(ctx, []*meta.Meta{m}, q)
+	return len(metaSeq) > 0, err
+}
+
+func isInvisible(err error) bool {
+	_, isErr := errors.AsType[box.ErrZettelNotFound](err)
+	return isErr || errors.Is(err, &box.ErrNotAllowed{})
+}
@@ -0,0 +1,232 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/query"
+)
+
+// watchTestPort simulates a box, where some zettel must not be read by the
+// current user.
+type watchTestPort struct {
+	mx       sync.Mutex
+	zettel   map[id.Zid]bool // Zettel exists, value is true if the user may read it
+	observer box.UpdateFunc
+	selected chan struct{} // Closed on first select
+	once     sync.Once
+	read     chan id.Zid // If not nil, receives the zettel that were read
+}
+
+func newWatchTestPort() *watchTestPort {
+	return &watchTestPort{zettel: map[id.Zid]bool{}, selected: make(chan struct{})}
+}
+
+func (tp *watchTestPort) RegisterObserver(f box.UpdateFunc) { tp.observer = f }
+
+func (tp *watchTestPort) notify(reason box.UpdateReason, zid id.Zid) {
+	tp.observer(box.UpdateInfo{Reason: reason, Zid: zid})
+}
+
+func (tp *watchTestPort) set(zid id.Zid, canRead bool) {
+	tp.mx.Lock()
+	tp.zettel[zid] = canRead
+	tp.mx.Unlock()
+}
+
+func (tp *watchTestPort) remove(zid id.Zid) {
+	tp.mx.Lock()
+	delete(tp.zettel, zid)
+	tp.mx.Unlock()
+}
+
+func (tp *watchTestPort) GetMeta(_ context.Context, zid id.Zid) (*meta.Meta, error) {
+	if tp.read != nil {
+		tp.read <- zid
+	}
+	tp.mx.Lock()
+	defer tp.mx.Unlock()
+	canRead, found := tp.zettel[zid]
+	if !found {
+		return nil, box.ErrZettelNotFound{Zid: zid}
+	}
+	if !canRead {
+		return nil, box.NewErrNotAllowed("GetMeta", nil, zid)
+	}
+	return meta.New(zid), nil
+}
+
+func (tp *watchTestPort) SelectMeta(_ context.Context, metaSeq []*meta.Meta, _ *query.Query) ([]*meta.Meta, error) {
+	defer tp.once.Do(func() { close(tp.selected) })
+	if metaSeq != nil {
+		return metaSeq, nil
+	}
+	tp.mx.Lock()
+	defer tp.mx.Unlock()
+	var result []*meta.Meta
+	for zid, canRead := range tp.zettel {
+		if canRead {
+			result = append(result, meta.New(zid))
+		}
+	}
+	return result, nil
+}
+
+type watchEvent struct {
+	reason box.UpdateReason
+	zid    id.Zid
+}
+
+// startWatch runs the use case in the background, until the test ends. It
+// returns after the use case has selected the visible zettel.
+func startWatch(t *testing.T, port *watchTestPort) <-chan watchEvent {
+	t.Helper()
+	uc := NewWatchChanges(slog.New(slog.DiscardHandler), port, port)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan watchEvent)
+	done := make(chan error, 1)
+	go func() {
+		done <- uc.Run(ctx, nil, func(reason box.UpdateReason, zid id.Zid) bool {
+			select {
+			case events <- watchEvent{reason, zid}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("watch failed: %v", err)
+		}
+	})
+	select {
+	case <-port.selected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not start")
+	}
+	return events
+}
+
+func checkWatchEvents(t *testing.T, events <-chan watchEvent, exp ...watchEvent) {
+	t.Helper()
+	for _, e := range exp {
+		select {
+		case got := <-events:
+			if got != e {
+				t.Errorf("expected event %v, but got %v", e, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected event %v, but got none", e)
+		}
+	}
+}
+
+const (
+	watchReadZid   = id.Zid(20250101000001)
+	watchDenyZid   = id.Zid(20250101000002)
+	watchChangeZid = id.Zid(20250101000003)
+)
+
+func TestWatchReadPermission(t *testing.T) {
+	t.Parallel()
+	port := newWatchTestPort()
+	port.set(watchReadZid, true)
+	port.set(watchDenyZid, false)
+	events := startWatch(t, port)
+
+	port.notify(box.OnZettel, watchDenyZid)
+	port.notify(box.OnZettel, watchReadZid)
+	port.notify(box.OnDelete, watchDenyZid)
+	port.remove(watchReadZid)
+	port.notify(box.OnDelete, watchReadZid)
+	port.notify(box.OnDelete, watchReadZid)
+	port.notify(box.OnReload, watchDenyZid)
+	checkWatchEvents(t, events,
+		watchEvent{box.OnZettel, watchReadZid},
+		watchEvent{box.OnDelete, watchReadZid},
+		watchEvent{box.OnReload, id.Invalid},
+	)
+}
+
+func TestWatchPermissionChange(t *testing.T) {
+	t.Parallel()
+	port := newWatchTestPort()
+	port.set(watchChangeZid, true)
+	events := startWatch(t, port)
+
+	// Zettel becomes unreadable: it is reported once.
+	port.set(watchChangeZid, false)
+	port.notify(box.OnZettel, watchChangeZid)
+	port.notify(box.OnZettel, watchChangeZid)
+	port.notify(box.OnDelete, watchChangeZid)
+
+	// Zettel becomes readable again.
+	port.set(watchChangeZid, true)
+	port.notify(box.OnZettel, watchChangeZid)
+	port.notify(box.OnReady, id.Invalid)
+	checkWatchEvents(t, events,
+		watchEvent{box.OnZettel, watchChangeZid},
+		watchEvent{box.OnZettel, watchChangeZid},
+		watchEvent{box.OnReady, id.Invalid},
+	)
+}
+
+func TestWatchReload(t *testing.T) {
+	t.Parallel()
+	port := newWatchTestPort()
+	port.set(watchReadZid, false)
+	events := startWatch(t, port)
+
+	// After a reload, the zettel is readable, and its deletion is reported.
+	port.set(watchReadZid, true)
+	port.notify(box.OnReload, id.Invalid)
+	checkWatchEvents(t, events, watchEvent{box.OnReload, id.Invalid})
+	port.remove(watchReadZid)
+	port.notify(box.OnDelete, watchReadZid)
+	checkWatchEvents(t, events, watchEvent{box.OnDelete, watchReadZid})
+}
+
+func TestWatchLostChanges(t *testing.T) {
+	t.Parallel()
+	port := newWatchTestPort()
+	port.set(watchReadZid, true)
+	port.set(watchDenyZid, false)
+	port.read = make(chan id.Zid, 2*watchBufferSize)
+	events := startWatch(t, port)
+
+	// The first change blocks the watcher, until it is received. All other
+	// changes fill the buffer, so that some are lost.
+	port.notify(box.OnZettel, watchReadZid)
+	if zid := <-port.read; zid != watchReadZid {
+		t.Fatalf("expected %v to be read, but got %v", watchReadZid, zid)
+	}
+	for range watchBufferSize + 2 {
+		port.notify(box.OnZettel, watchDenyZid)
+	}
+	checkWatchEvents(t, events,
+		watchEvent{box.OnZettel, watchReadZid},
+		watchEvent{box.OnReload, id.Invalid},
+	)
+}
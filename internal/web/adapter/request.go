@@ -14,15 +14,19 @@
 package adapter
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
 	"t73f.de/r/zsc/webapi"
 
 	"zettelstore.de/z/internal/kernel"
 	"zettelstore.de/z/internal/query"
+	"zettelstore.de/z/internal/usecase"
 )
 
 // GetCredentialsViaForm retrieves the authentication credentions from a form.
@@ -56,3 +60,97 @@ func GetQuery(vals url.Values) (result *query.Query) {
 	}
 	return result
 }
+
+// Keys of values that specify how to merge two zettel.
+const (
+	MergeKeyTarget  = "target"
+	MergeKeyMeta    = "meta"
+	MergeKeyContent = "content"
+	MergeKeyArchive = "archive"
+)
+
+var (
+	mergeMetaRules = map[string]usecase.MergeMetaRule{
+		"":       usecase.MergeMetaTarget,
+		"target": usecase.MergeMetaTarget,
+		"source": usecase.MergeMetaSource,
+		"keep":   usecase.MergeMetaKeep,
+	}
+	mergeContentRules = map[string]usecase.MergeContentRule{
+		"":       usecase.MergeContentAppend,
+		"append": usecase.MergeContentAppend,
+		"target": usecase.MergeContentTarget,
+		"source": usecase.MergeContentSource,
+	}
+)
+
+// GetMergeOptions retrieves the target zettel and the options to merge
+// zettel from the given values.
+func GetMergeOptions(vals url.Values) (id.Zid, usecase.MergeOptions, error) {
+	var opts usecase.MergeOptions
+	tgt := strings.TrimSpace(vals.Get(MergeKeyTarget))
+	tgtZid, err := id.Parse(tgt)
+	if err != nil {
+		return id.Invalid, opts, NewErrBadRequest("Missing or invalid target zettel: " + tgt)
+	}
+	var found bool
+	val := strings.TrimSpace(vals.Get(MergeKeyMeta))
+	if opts.Meta, found = mergeMetaRules[val]; !found {
+		return id.Invalid, opts, NewErrBadRequest("Unknown rule for metadata: " + val)
+	}
+	val = strings.TrimSpace(vals.Get(MergeKeyContent))
+	if opts.Content, found = mergeContentRules[val]; !found {
+		return id.Invalid, opts, NewErrBadRequest("Unknown rule for content: " + val)
+	}
+	if archive, isArchive := vals[MergeKeyArchive]; isArchive {
+		opts.Archive = len(archive) == 0 || archive[0] != "false"
+	}
+	return tgtZid, opts, nil
+}
+
+// BulkMetaKeyOps is the key of the form value that contains the operations
+// of a bulk metadata change.
+const BulkMetaKeyOps = "ops"
+
+var bulkMetaOpKinds = map[string]usecase.BulkMetaOpKind{
+	"add":    usecase.BulkMetaAdd,
+	"remove": usecase.BulkMetaRemove,
+	"set":    usecase.BulkMetaSet,
+	"rename": usecase.BulkMetaRename,
+}
+
+// ParseBulkMetaOps parses operations to change the metadata of many zettel.
+// Every non-empty line contains one operation: "add KEY VALUE", "remove KEY
+// [VALUE]", "set KEY VALUE", or "rename KEY OLD NEW".
+func ParseBulkMetaOps(text string) ([]usecase.BulkMetaOp, error) {
+	var result []usecase.BulkMetaOp
+	for num, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		opName, rest, _ := strings.Cut(line, " ")
+		kind, found := bulkMetaOpKinds[strings.ToLower(opName)]
+		if !found {
+			return nil, NewErrBadRequest(fmt.Sprintf("Line %d: unknown operation %q", num+1, opName))
+		}
+		key, val, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		op := usecase.BulkMetaOp{
+			Kind:  kind,
+			Key:   key,
+			Value: meta.Value(meta.RemoveNonGraphic(strings.TrimSpace(val))),
+		}
+		if kind == usecase.BulkMetaRename {
+			fields := strings.Fields(val)
+			if len(fields) != 2 {
+				return nil, NewErrBadRequest(fmt.Sprintf("Line %d: rename needs a current and a new value", num+1))
+			}
+			op.Value, op.NewValue = meta.Value(fields[0]), meta.Value(fields[1])
+		}
+		result = append(result, op)
+	}
+	if len(result) == 0 {
+		return nil, NewErrBadRequest("No metadata operation given")
+	}
+	return result, nil
+}
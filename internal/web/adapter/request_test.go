@@ -0,0 +1,72 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package adapter
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/usecase"
+)
+
+func TestParseBulkMetaOps(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name string
+		text string
+		exp  []usecase.BulkMetaOp
+	}{
+		{"empty", "", nil},
+		{"blank", " \n\t\n", nil},
+		{"unknown", "append tags #a", nil},
+		{"rename-one", "rename tags #a", nil},
+		{"rename-three", "rename tags #a #b #c", nil},
+		{"add", "add tags #a #b",
+			[]usecase.BulkMetaOp{{Kind: usecase.BulkMetaAdd, Key: meta.KeyTags, Value: "#a #b"}}},
+		{"remove-key", "remove title",
+			[]usecase.BulkMetaOp{{Kind: usecase.BulkMetaRemove, Key: meta.KeyTitle}}},
+		{"remove-value", "REMOVE tags #a",
+			[]usecase.BulkMetaOp{{Kind: usecase.BulkMetaRemove, Key: meta.KeyTags, Value: "#a"}}},
+		{"set", "  set   title   A new title  ",
+			[]usecase.BulkMetaOp{{Kind: usecase.BulkMetaSet, Key: meta.KeyTitle, Value: "A new title"}}},
+		{"rename", "rename tags #a #b",
+			[]usecase.BulkMetaOp{{Kind: usecase.BulkMetaRename, Key: meta.KeyTags, Value: "#a", NewValue: "#b"}}},
+		{"many", "add tags #a\n\nset role zettel\r\nrename title Old New",
+			[]usecase.BulkMetaOp{
+				{Kind: usecase.BulkMetaAdd, Key: meta.KeyTags, Value: "#a"},
+				{Kind: usecase.BulkMetaSet, Key: meta.KeyRole, Value: "zettel"},
+				{Kind: usecase.BulkMetaRename, Key: meta.KeyTitle, Value: "Old", NewValue: "New"},
+			}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseBulkMetaOps(tc.text)
+			if tc.exp == nil {
+				if _, isErr := errors.AsType[ErrBadRequest](err); !isErr {
+					t.Errorf("expected bad request, but got %v / %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.exp) {
+				t.Errorf("expected %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+}
@@ -74,6 +74,40 @@ func CodeMessageFromError(err error) (int, string) {
 	if erznf, isErr := errors.AsType[usecase.ErrRoleZettelNotFound](err); isErr {
 		return http.StatusNotFound, "Role zettel not found: " + string(erznf.Role)
 	}
+	if evc, isErr := errors.AsType[usecase.ErrVersionConflict](err); isErr {
+		return http.StatusPreconditionFailed, fmt.Sprintf("Zettel %v was changed in the meantime", evc.Current.Meta.Zid)
+	}
+	if ezu, isErr := errors.AsType[usecase.ErrZidInUse](err); isErr {
+		return http.StatusConflict, fmt.Sprintf("Zettel-ID %v is already in use", ezu.Zid)
+	}
+	if ecm, isErr := errors.AsType[usecase.ErrCannotMerge](err); isErr {
+		return http.StatusBadRequest, "Cannot merge zettel: " + ecm.Reason
+	}
+	if eibo, isErr := errors.AsType[usecase.ErrInvalidBulkMetaOp](err); isErr {
+		return http.StatusBadRequest, "Invalid metadata operation: " + eibo.Reason
+	}
+	if eptd, isErr := errors.AsType[usecase.ErrPersonalTokenData](err); isErr {
+		return http.StatusBadRequest, "Invalid personal access token: " + eptd.Reason
+	}
+	if esd, isErr := errors.AsType[usecase.ErrShareData](err); isErr {
+		return http.StatusBadRequest, "Invalid share link: " + esd.Reason
+	}
+	if errors.Is(err, usecase.ErrInvalidShareLink) {
+		return http.StatusForbidden, "Share link is invalid or has expired"
+	}
+	if errors.Is(err, usecase.ErrTokenNotAllowed) {
+		return http.StatusForbidden, "Not allowed without authentication by password"
+	}
+	if errors.Is(err, usecase.ErrInvalidOTP) || errors.Is(err, usecase.ErrTOTPEnabled) {
+		msg := err.Error()
+		return http.StatusBadRequest, strings.ToUpper(msg[:1]) + msg[1:]
+	}
+	if errors.Is(err, usecase.ErrTOTPRequired) {
+		return http.StatusForbidden, "One-time passwords are required and cannot be disabled"
+	}
+	if errors.Is(err, usecase.ErrTOTPEnrolment) {
+		return http.StatusForbidden, "One-time passwords are required, enable them by logging in via the web user interface"
+	}
 	if ebr, isErr := errors.AsType[ErrBadRequest](err); isErr {
 		return http.StatusBadRequest, ebr.Text
 	}
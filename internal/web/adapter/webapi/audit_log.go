@@ -0,0 +1,93 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webapi
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/webapi"
+
+	"zettelstore.de/z/internal/audit"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/content"
+)
+
+// MakeGetAuditLogHandler creates a new HTTP handler to return the records of
+// the audit log, either all records or those of a specific zettel.
+func (a *WebAPI) MakeGetAuditLogHandler(ucAuditLog usecase.GetAuditLog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zid := id.Invalid
+		if path := r.URL.Path[1:]; path != "" {
+			var err error
+			if zid, err = id.Parse(path); err != nil {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		records, err := ucAuditLog.Run(r.Context(), zid)
+		if err != nil {
+			a.reportUsecaseError(w, err)
+			return
+		}
+
+		switch enc, encStr := getEncoding(r, r.URL.Query()); enc {
+		case webapi.EncoderData:
+			var lb sx.ListBuilder
+			for _, rec := range records {
+				lb.Add(auditRecord2sx(&rec))
+			}
+			if err = a.writeObject(w, zid, lb.List()); err != nil {
+				a.logger.Error("write sx audit log", "err", err, "zid", zid)
+			}
+		case webapi.EncoderPlain:
+			var buf bytes.Buffer
+			for _, rec := range records {
+				buf.WriteString(rec.String())
+				buf.WriteByte('\n')
+			}
+			if err = writeBuffer(w, &buf, content.PlainTextUTF8); err != nil {
+				a.logger.Error("write plain audit log", "err", err, "zid", zid)
+			}
+		default:
+			invalidEncoding(w, zid, encStr)
+		}
+	})
+}
+
+func auditRecord2sx(rec *audit.Record) *sx.Pair {
+	var lb sx.ListBuilder
+	addString := func(key, val string) {
+		if val != "" {
+			lb.Add(sx.MakeList(sx.MakeSymbol(key), sx.MakeString(val)))
+		}
+	}
+	addString("time", rec.Time.UTC().Format(time.RFC3339))
+	addString("source", string(rec.Source))
+	addString("op", string(rec.Op))
+	if rec.Zid.IsValid() {
+		addString("zid", rec.Zid.String())
+	}
+	addString("user", rec.User)
+	if rec.UserZid.IsValid() {
+		addString("user-zid", rec.UserZid.String())
+	}
+	addString("before", rec.Before)
+	addString("after", rec.After)
+	addString("detail", rec.Detail)
+	return lb.List()
+}
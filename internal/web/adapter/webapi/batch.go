@@ -0,0 +1,201 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/sx/sxreader"
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+	"zettelstore.de/z/internal/web/content"
+)
+
+// Symbols of batch operations and of their results.
+var (
+	symBatchCreate = sx.MakeSymbol("create")
+	symBatchUpdate = sx.MakeSymbol("update")
+	symBatchDelete = sx.MakeSymbol("delete")
+
+	batchStateSym = map[usecase.BatchState]*sx.Symbol{
+		usecase.BatchStateOK:      sx.MakeSymbol("ok"),
+		usecase.BatchStateInvalid: sx.MakeSymbol("invalid"),
+		usecase.BatchStateSkipped: sx.MakeSymbol("skipped"),
+		usecase.BatchStateFailed:  sx.MakeSymbol("failed"),
+		usecase.BatchStateUndone:  sx.MakeSymbol("undone"),
+		usecase.BatchStateLost:    sx.MakeSymbol("lost"),
+	}
+)
+
+// MakePostBatchHandler creates a new HTTP handler to apply a list of
+// operations on zettel, all or nothing.
+func (a *WebAPI) MakePostBatchHandler(ucBatch *usecase.Batch) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ops, err := buildBatchOps(r)
+		if err != nil {
+			a.reportUsecaseError(w, adapter.NewErrBadRequest(err.Error()))
+			return
+		}
+
+		results, err := ucBatch.Run(r.Context(), ops)
+		code := http.StatusOK
+		if err != nil {
+			if code, _ = adapter.CodeMessageFromError(err); code == http.StatusInternalServerError {
+				a.logger.Error("Batch", "err", err)
+			}
+		}
+
+		var lb sx.ListBuilder
+		for _, res := range results {
+			var rb sx.ListBuilder
+			rb.Add(batchStateSym[res.State])
+			rb.Add(sx.Int64(res.Zid))
+			if res.Err != nil {
+				_, msg := adapter.CodeMessageFromError(res.Err)
+				rb.Add(sx.MakeString(msg))
+			}
+			lb.Add(rb.List())
+		}
+		var buf bytes.Buffer
+		if _, err = sx.Print(&buf, lb.List()); err != nil {
+			a.logger.Error("Unable to store batch result in buffer", "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		adapter.PrepareHeader(w, content.SXPFUTF8)
+		w.WriteHeader(code)
+		if _, err = w.Write(buf.Bytes()); err != nil {
+			a.logger.Error("Write batch result", "err", err)
+		}
+	})
+}
+
+// buildBatchOps reads the list of batch operations from the request body.
+// Every operation is one of "(create ZETTEL [LABEL])", "(update ZID ZETTEL
+// [VERSION])", or "(delete ZID [VERSION])", where ZETTEL is in the data
+// encoding.
+func buildBatchOps(r *http.Request) ([]usecase.BatchOp, error) {
+	defer func() { _ = r.Body.Close() }()
+	rdr := sxreader.MakeReader(r.Body)
+	obj, err := rdr.Read()
+	if err != nil {
+		return nil, err
+	}
+	lst, isPair := sx.GetPair(obj)
+	if !isPair {
+		return nil, errors.New("batch must be a list of operations")
+	}
+	var result []usecase.BatchOp
+	for opObj := range lst.Values() {
+		op, err2 := buildBatchOp(opObj)
+		if err2 != nil {
+			return nil, fmt.Errorf("operation %d: %w", len(result)+1, err2)
+		}
+		result = append(result, op)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("empty batch")
+	}
+	return result, nil
+}
+
+func buildBatchOp(obj sx.Object) (usecase.BatchOp, error) {
+	pair, isPair := sx.GetPair(obj)
+	if !isPair || pair == nil {
+		return usecase.BatchOp{}, errors.New("operation must be a list")
+	}
+	var args []sx.Object
+	for arg := range pair.Tail().Values() {
+		args = append(args, arg)
+	}
+	switch car := pair.Car(); {
+	case symBatchCreate.IsEqual(car):
+		if len(args) < 1 || len(args) > 2 {
+			return usecase.BatchOp{}, errors.New("create needs a zettel and an optional label")
+		}
+		z, err := zettelFromData(args[0], id.Invalid)
+		if err != nil {
+			return usecase.BatchOp{}, err
+		}
+		label, err := getBatchString(args[1:], "label")
+		if err != nil {
+			return usecase.BatchOp{}, err
+		}
+		return usecase.BatchOp{Kind: usecase.BatchCreate, Zettel: z, Label: label}, nil
+
+	case symBatchUpdate.IsEqual(car):
+		if len(args) < 2 || len(args) > 3 {
+			return usecase.BatchOp{}, errors.New("update needs a zettel identifier, a zettel, and an optional version")
+		}
+		zid, err := getBatchZid(args[0])
+		if err != nil {
+			return usecase.BatchOp{}, err
+		}
+		z, err := zettelFromData(args[1], zid)
+		if err != nil {
+			return usecase.BatchOp{}, err
+		}
+		version, err := getBatchString(args[2:], "version")
+		if err != nil {
+			return usecase.BatchOp{}, err
+		}
+		return usecase.BatchOp{Kind: usecase.BatchUpdate, Zid: zid, Zettel: z, Version: version}, nil
+
+	case symBatchDelete.IsEqual(car):
+		if len(args) < 1 || len(args) > 2 {
+			return usecase.BatchOp{}, errors.New("delete needs a zettel identifier and an optional version")
+		}
+		zid, err := getBatchZid(args[0])
+		if err != nil {
+			return usecase.BatchOp{}, err
+		}
+		version, err := getBatchString(args[1:], "version")
+		if err != nil {
+			return usecase.BatchOp{}, err
+		}
+		return usecase.BatchOp{Kind: usecase.BatchDelete, Zid: zid, Version: version}, nil
+	}
+	return usecase.BatchOp{}, fmt.Errorf("unknown operation %v", pair.Car())
+}
+
+// getBatchZid accepts a zettel identifier as a number or as a string.
+func getBatchZid(obj sx.Object) (id.Zid, error) {
+	if n, isInt := obj.(sx.Int64); isInt {
+		if zid := id.Zid(n); zid.IsValid() {
+			return zid, nil
+		}
+		return id.Invalid, fmt.Errorf("invalid zettel identifier %v", n)
+	}
+	if s, isString := sx.GetString(obj); isString {
+		return id.Parse(s.GetValue())
+	}
+	return id.Invalid, fmt.Errorf("invalid zettel identifier %v", obj)
+}
+
+// getBatchString returns the optional string argument.
+func getBatchString(args []sx.Object, what string) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	if s, isString := sx.GetString(args[0]); isString {
+		return s.GetValue(), nil
+	}
+	return "", fmt.Errorf("%s must be a string, but got %v", what, args[0])
+}
@@ -0,0 +1,88 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webapi
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"t73f.de/r/sx"
+
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+	"zettelstore.de/z/internal/web/content"
+)
+
+// maxBulkMetaOps limits the size of the request body with bulk operations.
+const maxBulkMetaOps = 64 * 1024
+
+var bulkMetaStateSym = map[usecase.BulkMetaState]*sx.Symbol{
+	usecase.BulkMetaChanged:   sx.MakeSymbol("changed"),
+	usecase.BulkMetaUnchanged: sx.MakeSymbol("unchanged"),
+	usecase.BulkMetaDenied:    sx.MakeSymbol("denied"),
+	usecase.BulkMetaFailed:    sx.MakeSymbol("failed"),
+}
+
+// MakePatchBulkMetaHandler creates a new HTTP handler to change the metadata
+// of all zettel selected by a query.
+func (a *WebAPI) MakePatchBulkMetaHandler(queryMeta *usecase.Query, bulkMeta *usecase.BulkMeta) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBulkMetaOps))
+		_ = r.Body.Close()
+		if err != nil {
+			a.reportUsecaseError(w, adapter.NewErrBadRequest("Unable to read metadata operations"))
+			return
+		}
+		ops, err := adapter.ParseBulkMetaOps(string(body))
+		if err != nil {
+			a.reportUsecaseError(w, err)
+			return
+		}
+
+		sq := adapter.GetQuery(r.URL.Query())
+		metaSeq, err := queryMeta.Run(ctx, sq)
+		if err != nil {
+			a.reportUsecaseError(w, err)
+			return
+		}
+		results, err := bulkMeta.Run(ctx, metaSeq, ops)
+		if err != nil {
+			a.reportUsecaseError(w, err)
+			return
+		}
+
+		var lb sx.ListBuilder
+		for _, res := range results {
+			var rb sx.ListBuilder
+			rb.Add(bulkMetaStateSym[res.State])
+			rb.Add(sx.Int64(res.Zid))
+			if res.Err != nil {
+				_, msg := adapter.CodeMessageFromError(res.Err)
+				rb.Add(sx.MakeString(msg))
+			}
+			lb.Add(rb.List())
+		}
+		var buf bytes.Buffer
+		if _, err = sx.Print(&buf, lb.List()); err != nil {
+			a.logger.Error("Unable to store bulk metadata result in buffer", "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if err = writeBuffer(w, &buf, content.SXPFUTF8); err != nil {
+			a.logger.Error("Write bulk metadata result", "err", err)
+		}
+	})
+}
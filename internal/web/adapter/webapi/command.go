@@ -14,18 +14,28 @@
 package webapi
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 
+	"t73f.de/r/sx"
 	"t73f.de/r/zsc/webapi"
 
 	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/content"
+)
+
+// Command and query key to check the consistency of the index.
+const (
+	commandCheckIndex = webapi.Command("check-index")
+	queryKeyRepair    = "repair"
 )
 
 // MakePostCommandHandler creates a new HTTP handler to execute certain commands.
 func (a *WebAPI) MakePostCommandHandler(
 	ucIsAuth *usecase.IsAuthenticated,
 	ucRefresh *usecase.Refresh,
+	ucCheckIndex *usecase.CheckIndex,
 ) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -41,11 +51,35 @@ func (a *WebAPI) MakePostCommandHandler(
 			}
 			w.WriteHeader(http.StatusNoContent)
 			return
+		case commandCheckIndex:
+			a.handleCheckIndex(ctx, w, ucCheckIndex, r.URL.Query().Has(queryKeyRepair))
+			return
 		}
 		http.Error(w, "Unknown command", http.StatusBadRequest)
 	})
 }
 
+func (a *WebAPI) handleCheckIndex(ctx context.Context, w http.ResponseWriter, ucCheckIndex *usecase.CheckIndex, repair bool) {
+	problems, err := ucCheckIndex.Run(ctx, repair)
+	if err != nil {
+		a.reportUsecaseError(w, err)
+		return
+	}
+	var lb sx.ListBuilder
+	for _, p := range problems {
+		lb.Add(sx.MakeList(sx.MakeSymbol(string(p.Kind)), sx.Int64(p.Zid), sx.MakeString(p.Detail)))
+	}
+	var buf bytes.Buffer
+	if _, err = sx.Print(&buf, lb.List()); err != nil {
+		a.logger.Error("Unable to store index problems in buffer", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if err = writeBuffer(w, &buf, content.SXPFUTF8); err != nil {
+		a.logger.Error("Write index problems", "err", err)
+	}
+}
+
 func handleIsAuthenticated(ctx context.Context, w http.ResponseWriter, ucIsAuth *usecase.IsAuthenticated) {
 	switch ucIsAuth.Run(ctx) {
 	case usecase.IsAuthenticatedDisabled:
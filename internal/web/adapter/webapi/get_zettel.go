@@ -106,6 +106,7 @@ func (a *WebAPI) writePlainData(ctx context.Context, w http.ResponseWriter, zid
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
+	setETag(w, z)
 	if err = writeBuffer(w, &buf, contentType); err != nil {
 		a.logger.Error("write plain data", "err", err, "zid", zid)
 	}
@@ -138,6 +139,7 @@ func (a *WebAPI) writeSzData(ctx context.Context, w http.ResponseWriter, zid id.
 		zContent, zEncoding := z.Content.Encode()
 		obj = sexp.EncodeContent(zContent, zEncoding)
 	}
+	setETag(w, z)
 	if err = a.writeObject(w, zid, obj); err != nil {
 		a.logger.Error("write sx data", "err", err, "zid", zid)
 	}
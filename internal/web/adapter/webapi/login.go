@@ -37,7 +37,10 @@ func (a *WebAPI) MakePostLoginHandler(ucAuth *usecase.Authenticate) http.Handler
 		var token []byte
 		if ident, cred := retrieveIdentCred(r); ident != "" {
 			var err error
-			token, err = ucAuth.Run(r.Context(), r, ident, cred, a.tokenLifetime, auth.KindAPI)
+			// The one-time password must not be given as a query parameter,
+			// because the URL may be logged.
+			otp := r.PostFormValue("otp")
+			token, err = ucAuth.Run(r.Context(), r, ident, cred, otp, a.tokenLifetime, auth.KindAPI)
 			if err != nil {
 				a.reportUsecaseError(w, err)
 				return
@@ -80,6 +83,11 @@ func (a *WebAPI) MakeRenewAuthHandler() http.Handler {
 			adapter.BadRequest(w, "Not authenticated")
 			return
 		}
+		if auth.IsTokenUser(authData.User) {
+			// A personal access token must not be exchanged for an unrestricted token
+			a.reportUsecaseError(w, usecase.ErrTokenNotAllowed)
+			return
+		}
 		totalLifetime := authData.Expires.Sub(authData.Issued)
 		currentLifetime := authData.Now.Sub(authData.Issued)
 		// If we are in the first quarter of the tokens lifetime, return the token
@@ -91,7 +99,7 @@ func (a *WebAPI) MakeRenewAuthHandler() http.Handler {
 		}
 
 		// Token is a little bit aged. Create a new one
-		token, err := a.getToken(authData.User)
+		token, err := a.renewToken(authData.User, authData.Session)
 		if err != nil {
 			a.reportUsecaseError(w, err)
 			return
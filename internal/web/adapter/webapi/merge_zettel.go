@@ -0,0 +1,54 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webapi
+
+import (
+	"net/http"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/webapi"
+
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+)
+
+// MakePostMergeZettelHandler creates a new HTTP handler to merge a zettel
+// into another zettel.
+func (a *WebAPI) MakePostMergeZettelHandler(mergeZettel *usecase.MergeZettel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		tgtZid, opts, err := adapter.GetMergeOptions(q)
+		if err != nil {
+			a.reportUsecaseError(w, err)
+			return
+		}
+		enc, _ := getEncoding(r, q)
+		if enc != webapi.EncoderPlain && enc != webapi.EncoderData {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		changed, err := mergeZettel.Run(r.Context(), zid, tgtZid, opts)
+		if err != nil {
+			a.reportUsecaseError(w, err)
+			return
+		}
+		a.writeChangedZids(w, enc, http.StatusOK, tgtZid, changed)
+	})
+}
@@ -0,0 +1,109 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/webapi"
+
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+	"zettelstore.de/z/internal/web/content"
+)
+
+const (
+	headerDestination = "Destination"
+	queryKeyStub      = "stub"
+)
+
+// MakeRenameZettelHandler creates a new HTTP handler to give a zettel a new
+// zettel identifier.
+func (a *WebAPI) MakeRenameZettelHandler(renameZettel *usecase.RenameZettel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		newZid, err := getDestinationZid(r.Header.Get(headerDestination))
+		if err != nil {
+			a.reportUsecaseError(w, adapter.NewErrBadRequest("Missing or invalid destination"))
+			return
+		}
+		q := r.URL.Query()
+		_, withStub := q[queryKeyStub]
+		enc, _ := getEncoding(r, q)
+		if enc != webapi.EncoderPlain && enc != webapi.EncoderData {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		changed, err := renameZettel.Run(r.Context(), zid, newZid, withStub)
+		if err != nil {
+			a.reportUsecaseError(w, err)
+			return
+		}
+
+		a.writeChangedZids(w, enc, http.StatusCreated, newZid, changed)
+	})
+}
+
+// writeChangedZids writes the identifier of all changed zettel. The location
+// header refers to the given zettel.
+func (a *WebAPI) writeChangedZids(w http.ResponseWriter, enc webapi.EncodingEnum, code int, zid id.Zid, changed []id.Zid) {
+	var buf bytes.Buffer
+	var contentType string
+	switch enc {
+	case webapi.EncoderPlain:
+		for _, czid := range changed {
+			buf.Write(czid.Bytes())
+			buf.WriteByte('\n')
+		}
+		contentType = content.PlainTextUTF8
+	case webapi.EncoderData:
+		var lb sx.ListBuilder
+		for _, czid := range changed {
+			lb.Add(sx.Int64(czid))
+		}
+		if _, err := sx.Print(&buf, lb.List()); err != nil {
+			a.logger.Error("Unable to encode changed zettel", "err", err, "zid", zid)
+		}
+		contentType = content.SXPFUTF8
+	}
+
+	h := adapter.PrepareHeader(w, contentType)
+	h.Set(webapi.HeaderLocation, a.NewURLBuilder('z').SetZid(zid).String())
+	w.WriteHeader(code)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		a.logger.Error("Unable to write changed zettel", "err", err, "zid", zid)
+	}
+}
+
+// getDestinationZid returns the zettel identifier of the destination header.
+// It is either a plain identifier or an URL whose last path element is the
+// identifier.
+func getDestinationZid(dest string) (id.Zid, error) {
+	dest = strings.TrimSpace(dest)
+	if u, err := url.Parse(dest); err == nil && u.Path != "" {
+		dest = path.Base(u.Path)
+	}
+	return id.Parse(dest)
+}
@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"t73f.de/r/sx"
 	"t73f.de/r/sx/sxreader"
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
@@ -107,6 +108,11 @@ func buildZettelFromData(r *http.Request, zid id.Zid) (zettel.Zettel, error) {
 	if err != nil {
 		return zettel.Zettel{}, err
 	}
+	return zettelFromData(obj, zid)
+}
+
+// zettelFromData builds a zettel from its data encoding.
+func zettelFromData(obj sx.Object, zid id.Zid) (zettel.Zettel, error) {
 	zd, err := sexp.ParseZettel(obj)
 	if err != nil {
 		return zettel.Zettel{}, err
@@ -49,7 +49,7 @@ func (a *WebAPI) MakeUpdateZettelHandler(updateZettel *usecase.UpdateZettel) htt
 			a.reportUsecaseError(w, adapter.NewErrBadRequest(err.Error()))
 			return
 		}
-		if err = updateZettel.Run(r.Context(), zettel, true); err != nil {
+		if err = updateZettel.Run(r.Context(), zettel, getIfMatch(r), true); err != nil {
 			a.reportUsecaseError(w, err)
 			return
 		}
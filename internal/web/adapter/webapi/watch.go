@@ -0,0 +1,136 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+	"zettelstore.de/z/internal/web/content"
+	"zettelstore.de/z/internal/web/server"
+)
+
+// watchCheckInterval is the time between two checks, whether the token of a
+// watching client is still valid.
+const watchCheckInterval = time.Minute
+
+// Names of server-sent events.
+var watchEventNames = map[box.UpdateReason]string{
+	box.OnReady:  "ready",
+	box.OnReload: "reload",
+	box.OnZettel: "zettel",
+	box.OnDelete: "delete",
+}
+
+// MakeWatchHandler creates a new HTTP handler that streams changes of zettel
+// as server-sent events.
+//
+// Streaming stops, when the token of the client expires, or when its session or
+// its personal access token is revoked. The token is checked before every event
+// and every watchCheckInterval.
+func (a *WebAPI) MakeWatchHandler(ucWatch *usecase.WatchChanges, ptr server.PersonalTokenRetriever) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if a.withAuth() && auth.GetCurrentUser(ctx) == nil {
+			a.reportUsecaseError(w, box.NewErrNotAllowed("Watch", nil, id.Invalid))
+			return
+		}
+		rc := http.NewResponseController(w)
+		authData := a.getAuthData(ctx)
+		if authData != nil && !authData.Expires.IsZero() {
+			// Stop streaming when the token expires. The client must
+			// re-authenticate before it connects again.
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, authData.Expires)
+			defer cancel()
+		}
+		if authData != nil && len(authData.Token) > 0 {
+			// Stop streaming when the token is revoked.
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			defer cancel()
+			go a.checkWatchToken(ctx, cancel, ptr, authData)
+		} else {
+			authData = nil
+		}
+
+		h := adapter.PrepareHeader(w, content.EventStream)
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if _, err := fmt.Fprint(w, ": watching zettel changes\n\n"); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			a.logger.Error("Watch: unable to flush", "err", err)
+			return
+		}
+
+		err := ucWatch.Run(ctx, adapter.GetQuery(r.URL.Query()), func(reason box.UpdateReason, zid id.Zid) bool {
+			if authData != nil && !a.isValidWatchToken(ctx, ptr, authData) {
+				return false
+			}
+			data := ""
+			if zid.IsValid() {
+				data = zid.String()
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", watchEventNames[reason], data); err != nil {
+				return false
+			}
+			return rc.Flush() == nil
+		})
+		if err != nil {
+			a.logger.Error("Watch", "err", err)
+		}
+	})
+}
+
+// checkWatchToken cancels the context, if the token is not valid any more.
+func (a *WebAPI) checkWatchToken(ctx context.Context, cancel context.CancelFunc, ptr server.PersonalTokenRetriever, authData *auth.UserData) {
+	ticker := time.NewTicker(watchCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !a.isValidWatchToken(ctx, ptr, authData) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// isValidWatchToken returns true, if the given token was not revoked.
+func (a *WebAPI) isValidWatchToken(ctx context.Context, ptr server.PersonalTokenRetriever, authData *auth.UserData) bool {
+	token := authData.Token
+	if auth.IsPersonalToken(token) {
+		if ptr == nil {
+			return false
+		}
+		_, _, err := ptr.GetUserByToken(ctx, token)
+		return err == nil
+	}
+	_, err := a.token.CheckToken(token, authData.Kind)
+	return err == nil
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"t73f.de/r/zsc/domain/meta"
@@ -29,6 +30,7 @@ import (
 	"zettelstore.de/z/internal/kernel"
 	"zettelstore.de/z/internal/web/adapter"
 	"zettelstore.de/z/internal/web/server"
+	"zettelstore.de/z/internal/zettel"
 )
 
 // WebAPI holds all data and methods for delivering WebAPI call results.
@@ -66,8 +68,8 @@ func (a *WebAPI) getAuthData(ctx context.Context) *auth.UserData {
 	return auth.GetAuthData(ctx)
 }
 func (a *WebAPI) withAuth() bool { return a.authz.WithAuth() }
-func (a *WebAPI) getToken(ident *meta.Meta) ([]byte, error) {
-	return a.token.GetToken(ident, a.tokenLifetime, auth.KindAPI)
+func (a *WebAPI) renewToken(ident *meta.Meta, session string) ([]byte, error) {
+	return a.token.RenewToken(ident, a.tokenLifetime, auth.KindAPI, session)
 }
 
 func (a *WebAPI) reportUsecaseError(w http.ResponseWriter, err error) {
@@ -85,6 +87,27 @@ func writeBuffer(w http.ResponseWriter, buf *bytes.Buffer, contentType string) e
 	return adapter.WriteData(w, buf.Bytes(), contentType)
 }
 
+// Header fields for optimistic concurrency control.
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// setETag announces the version of the given zettel.
+func setETag(w http.ResponseWriter, z zettel.Zettel) {
+	w.Header().Set(headerETag, `"`+z.Version()+`"`)
+}
+
+// getIfMatch returns the zettel version that a client expects to change.
+// An empty string signals that any version may be changed.
+func getIfMatch(r *http.Request) string {
+	val := strings.TrimSpace(r.Header.Get(headerIfMatch))
+	if val == "*" {
+		return ""
+	}
+	return strings.Trim(strings.TrimPrefix(val, "W/"), `"`)
+}
+
 func (a *WebAPI) getRights(ctx context.Context, m *meta.Meta) (result webapi.ZettelRights) {
 	pol := a.policy
 	user := auth.GetCurrentUser(ctx)
@@ -0,0 +1,129 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webui
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+	"t73f.de/r/zsc/sz"
+	"t73f.de/r/zsc/webapi"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box/constbox"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+)
+
+// MakeGetBulkMetaHandler creates a new HTTP handler to display the form to
+// change the metadata of all zettel selected by a query.
+func (wui *WebUI) MakeGetBulkMetaHandler(queryMeta *usecase.Query) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		q := adapter.GetQuery(r.URL.Query())
+		metaSeq, err := queryMeta.Run(ctx, q)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		wui.renderBulkMeta(ctx, w, q.String(), "", len(metaSeq), nil, nil)
+	})
+}
+
+// MakePostBulkMetaHandler creates a new HTTP handler to change the metadata
+// of all zettel selected by a query.
+func (wui *WebUI) MakePostBulkMetaHandler(queryMeta *usecase.Query, bulkMeta *usecase.BulkMeta) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := r.ParseForm(); err != nil {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unable to read metadata form"))
+			return
+		}
+		opsText := r.PostForm.Get(adapter.BulkMetaKeyOps)
+		ops, err := adapter.ParseBulkMetaOps(opsText)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		q := adapter.GetQuery(url.Values{webapi.QueryKeyQuery: r.PostForm[webapi.QueryKeyQuery]})
+		metaSeq, err := queryMeta.Run(ctx, q)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		results, err := bulkMeta.Run(ctx, metaSeq, ops)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		wui.renderBulkMeta(ctx, w, q.String(), opsText, len(metaSeq), metaSeq, results)
+	})
+}
+
+func (wui *WebUI) renderBulkMeta(
+	ctx context.Context, w http.ResponseWriter,
+	query, opsText string, numMeta int,
+	metaSeq []*meta.Meta, results []usecase.BulkMetaResult,
+) {
+	user := auth.GetCurrentUser(ctx)
+	env, rb := wui.createRenderEnvironment(ctx, "bulkmeta", wui.getUserLang(ctx), "Change Metadata", user)
+	rb.bindString("query-value", sx.MakeString(query))
+	rb.bindString("ops", sx.MakeString(opsText))
+	rb.bindString("num-meta", sx.Int64(numMeta))
+	if results != nil {
+		titles := make(map[id.Zid]string, len(metaSeq))
+		for _, m := range metaSeq {
+			titles[m.Zid] = sz.NormalizedSpacedText(m.GetTitle())
+		}
+		var changed, denied, failed sx.ListBuilder
+		lists := map[usecase.BulkMetaState]*sx.ListBuilder{
+			usecase.BulkMetaChanged: &changed,
+			usecase.BulkMetaDenied:  &denied,
+			usecase.BulkMetaFailed:  &failed,
+		}
+		counts := map[usecase.BulkMetaState]int{}
+		for _, res := range results {
+			counts[res.State]++
+			if lb, found := lists[res.State]; found {
+				title := titles[res.Zid]
+				if title == "" {
+					title = res.Zid.String()
+				}
+				href := sx.MakeString(wui.NewURLBuilder('h').SetZid(res.Zid).String())
+				lb.Add(sx.Cons(sx.MakeString(title), href))
+			}
+		}
+		rb.bindString("changed", changed.List())
+		rb.bindString("denied", denied.List())
+		rb.bindString("failed", failed.List())
+		rb.bindString("num-changed", sx.Int64(counts[usecase.BulkMetaChanged]))
+		rb.bindString("num-unchanged", sx.Int64(counts[usecase.BulkMetaUnchanged]))
+		rb.bindString("num-denied", sx.Int64(counts[usecase.BulkMetaDenied]))
+		rb.bindString("num-failed", sx.Int64(counts[usecase.BulkMetaFailed]))
+	}
+
+	var err error
+	if rb.err == nil {
+		err = wui.renderSxnTemplate(ctx, w, constbox.ZidBulkMetaTemplate, env)
+	} else {
+		err = rb.err
+	}
+	if err != nil {
+		wui.reportError(ctx, w, err)
+	}
+}
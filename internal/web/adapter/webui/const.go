@@ -17,6 +17,9 @@ package webui
 
 const queryKeyAction = "_action"
 
+// queryKeyShare contains the token of a share link.
+const queryKeyShare = "share"
+
 // Values for queryKeyAction
 const (
 	valueActionCopy   = "copy"
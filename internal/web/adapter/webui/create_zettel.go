@@ -61,15 +61,15 @@ func (wui *WebUI) MakeGetCreateZettelHandler(
 		roleData, syntaxData := retrieveDataLists(ctx, ucListRoles, ucListSyntax)
 		switch op {
 		case actionCopy:
-			wui.renderZettelForm(ctx, w, createZettel.PrepareCopy(origZettel), "Copy Zettel", "", roleData, syntaxData)
+			wui.renderZettelForm(ctx, w, createZettel.PrepareCopy(origZettel), "Copy Zettel", "", roleData, syntaxData, nil)
 		case actionFolge:
-			wui.renderZettelForm(ctx, w, createZettel.PrepareFolge(origZettel), "Folge Zettel", "", roleData, syntaxData)
+			wui.renderZettelForm(ctx, w, createZettel.PrepareFolge(origZettel), "Folge Zettel", "", roleData, syntaxData, nil)
 		case actionNew:
 			title := sz.NormalizedSpacedText(origZettel.Meta.GetTitle())
 			newTitle := sz.NormalizedSpacedText(q.Get(meta.KeyTitle))
-			wui.renderZettelForm(ctx, w, createZettel.PrepareNew(origZettel, newTitle), title, "", roleData, syntaxData)
+			wui.renderZettelForm(ctx, w, createZettel.PrepareNew(origZettel, newTitle), title, "", roleData, syntaxData, nil)
 		case actionSequel:
-			wui.renderZettelForm(ctx, w, createZettel.PrepareSequel(origZettel), "Sequel Zettel", "", roleData, syntaxData)
+			wui.renderZettelForm(ctx, w, createZettel.PrepareSequel(origZettel), "Sequel Zettel", "", roleData, syntaxData, nil)
 		}
 	})
 }
@@ -97,6 +97,7 @@ func (wui *WebUI) renderZettelForm(
 	formActionURL string,
 	roleData []string,
 	syntaxData []string,
+	edit *editState,
 ) {
 	user := auth.GetCurrentUser(ctx)
 	m := ztl.Meta
@@ -117,6 +118,9 @@ func (wui *WebUI) renderZettelForm(
 	if !ztl.Content.IsBinary() {
 		rb.bindString("content", sx.MakeString(ztl.Content.AsString()))
 	}
+	if edit != nil {
+		wui.bindEditState(&rb, edit)
+	}
 	wui.bindCommonZettelData(ctx, &rb, user, m, "", &ztl.Content)
 	if rb.err == nil {
 		rb.err = wui.renderSxnTemplate(ctx, w, id.ZidFormTemplate, env)
@@ -189,6 +193,6 @@ func (wui *WebUI) MakeGetZettelFromListHandler(
 		}
 		zettel := zettel.Zettel{Meta: m, Content: zettel.NewContent(zmkContent.Bytes())}
 		roleData, syntaxData := retrieveDataLists(ctx, ucListRoles, ucListSyntax)
-		wui.renderZettelForm(ctx, w, zettel, "Zettel from list", wui.createNewURL, roleData, syntaxData)
+		wui.renderZettelForm(ctx, w, zettel, "Zettel from list", wui.createNewURL, roleData, syntaxData, nil)
 	})
 }
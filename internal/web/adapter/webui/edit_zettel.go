@@ -14,13 +14,17 @@
 package webui
 
 import (
+	"bytes"
+	"errors"
 	"net/http"
 
+	"t73f.de/r/sx"
 	"t73f.de/r/zsc/domain/id"
 
 	"zettelstore.de/z/internal/box"
 	"zettelstore.de/z/internal/usecase"
 	"zettelstore.de/z/internal/web/adapter"
+	"zettelstore.de/z/internal/zettel"
 )
 
 // MakeEditGetZettelHandler creates a new HTTP handler to display the
@@ -46,13 +50,37 @@ func (wui *WebUI) MakeEditGetZettelHandler(
 		}
 
 		roleData, syntaxData := retrieveDataLists(ctx, ucListRoles, ucListSyntax)
-		wui.renderZettelForm(ctx, w, zettel, "Edit Zettel", "", roleData, syntaxData)
+		wui.renderZettelForm(ctx, w, zettel, "Edit Zettel", "", roleData, syntaxData, &editState{version: zettel.Version()})
 	})
 }
 
+// editState contains additional data to edit an existing zettel.
+type editState struct {
+	version  string         // Version of the zettel, when editing started
+	conflict *zettel.Zettel // Current zettel, if it was changed while editing
+}
+
+func (wui *WebUI) bindEditState(rb *renderBinder, edit *editState) {
+	rb.bindString("version", sx.MakeString(edit.version))
+	if cur := edit.conflict; cur != nil {
+		var buf bytes.Buffer
+		_, _ = cur.Meta.Write(&buf)
+		rb.bindString("conflict-meta", sx.MakeString(buf.String()))
+		rb.bindString("conflict-url", sx.MakeString(wui.NewURLBuilder('h').SetZid(cur.Meta.Zid).String()))
+		if !cur.Content.IsBinary() {
+			rb.bindString("conflict-content", sx.MakeString(cur.Content.AsString()))
+		}
+	}
+}
+
 // MakeEditSetZettelHandler creates a new HTTP handler to store content of
-// an existing zettel.
-func (wui *WebUI) MakeEditSetZettelHandler(updateZettel *usecase.UpdateZettel) http.Handler {
+// an existing zettel. If the zettel was changed while it was edited, both
+// versions are shown, so that the user is able to resolve the conflict.
+func (wui *WebUI) MakeEditSetZettelHandler(
+	updateZettel *usecase.UpdateZettel,
+	ucListRoles usecase.ListRoles,
+	ucListSyntax usecase.ListSyntax,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		path := r.URL.Path[1:]
@@ -73,7 +101,14 @@ func (wui *WebUI) MakeEditSetZettelHandler(updateZettel *usecase.UpdateZettel) h
 			}
 			hasContent = false
 		}
-		if err = updateZettel.Run(r.Context(), zettel, hasContent); err != nil {
+		version, _ := trimmedFormValue(r, "version")
+		if err = updateZettel.Run(r.Context(), zettel, version, hasContent); err != nil {
+			if evc, isConflict := errors.AsType[usecase.ErrVersionConflict](err); isConflict {
+				roleData, syntaxData := retrieveDataLists(ctx, ucListRoles, ucListSyntax)
+				wui.renderZettelForm(ctx, w, zettel, "Edit Zettel", "", roleData, syntaxData,
+					&editState{version: evc.Current.Version(), conflict: &evc.Current})
+				return
+			}
 			wui.reportError(ctx, w, err)
 			return
 		}
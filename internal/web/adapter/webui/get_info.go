@@ -106,6 +106,9 @@ func (wui *WebUI) MakeGetInfoHandler(
 		rb.bindString("enc-eval", wui.infoAPIMatrix(zid, false, encTexts))
 		rb.bindString("enc-parsed", wui.infoAPIMatrixParsed(zid, encTexts))
 		rb.bindString("shadow-links", shadowLinks)
+		if wui.policy.CanShare(user, zn.InhMeta) {
+			rb.bindString("share-url", sx.MakeString(wui.NewURLBuilder('k').SetZid(zid).String()))
+		}
 		rb.bindRoleSpecific(zn.InhMeta)
 		wui.bindCommonZettelData(ctx, &rb, user, zn.InhMeta, title, &zn.Content)
 		if rb.err == nil {
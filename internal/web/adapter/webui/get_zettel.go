@@ -76,6 +76,9 @@ func (wui *WebUI) MakeGetHTMLZettelHandler(
 		rb.bindSymbol(symJSScriptsAsync, sx.MakeList(sx.MakeString(wui.jsBaseURL)))
 		rb.bindSymbol(symJSScripts, sx.MakeList(sx.MakeString(wui.jsCopyRefURL)))
 		rb.bindString("heading", sx.MakeString(title))
+		if meta.Value(wui.getConfig(ctx, zn.InhMeta, config.KeyAutoReload)).AsBool() {
+			rb.bindString("watch-url", sx.MakeString(wui.NewURLBuilder('w').AppendQuery(zid.String()).String()))
+		}
 		if role, found := zn.InhMeta.Get(meta.KeyRole); found && role != "" {
 			rb.bindString(
 				"role-url",
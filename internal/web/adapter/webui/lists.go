@@ -128,6 +128,9 @@ func (wui *WebUI) MakeListHTMLMetaHandler(
 				rb.bindString("create-url", sx.MakeString(wui.createNewURL))
 				rb.bindString("seed", sx.Int64(seed))
 			}
+			if slices.ContainsFunc(metaSeq, func(m *meta.Meta) bool { return wui.policy.CanWrite(user, m, m) }) {
+				rb.bindString("bulk-url", sx.MakeString(wui.NewURLBuilder('u').AppendQuery(q.String()).String()))
+			}
 		}
 		if rb.err == nil {
 			err = wui.renderSxnTemplate(ctx, w, id.ZidListTemplate, env)
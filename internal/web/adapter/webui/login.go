@@ -15,23 +15,47 @@ package webui
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"strings"
 
 	"t73f.de/r/sx"
 	"t73f.de/r/zsc/domain/id"
 
 	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/impl"
 	"zettelstore.de/z/internal/usecase"
 	"zettelstore.de/z/internal/web/adapter"
 )
 
 // MakeGetLoginOutHandler creates a new HTTP handler to display the HTML login view,
 // or to execute a logout.
-func (wui *WebUI) MakeGetLoginOutHandler() http.Handler {
+//
+// If "ucOIDC" is not nil, a login via an OpenID Connect provider is
+// supported: the query key "oidc" starts the login, the provider redirects
+// back with the query keys "state" and "code".
+func (wui *WebUI) MakeGetLoginOutHandler(ucLogout *usecase.Logout, ucOIDC *usecase.AuthenticateOIDC) http.Handler {
+	if ucOIDC != nil {
+		// The login form will show a link to the identity provider.
+		wui.oidcLoginURL = wui.NewURLBuilder('i').AppendKVQuery("oidc", "").String()
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query()
+		if ucOIDC != nil {
+			if query.Has("oidc") {
+				wui.startOIDCLogin(w, r, ucOIDC)
+				return
+			}
+			if query.Has("state") {
+				wui.finishOIDCLogin(w, r, ucOIDC)
+				return
+			}
+		}
 		if query.Has("logout") {
-			wui.clearToken(r.Context(), w)
+			ctx := r.Context()
+			ucLogout.Run(ctx)
+			wui.clearToken(ctx, w)
 			wui.redirectFound(w, r, wui.NewURLBuilder('/'))
 			return
 		}
@@ -40,8 +64,27 @@ func (wui *WebUI) MakeGetLoginOutHandler() http.Handler {
 }
 
 func (wui *WebUI) renderLoginForm(ctx context.Context, w http.ResponseWriter, retry bool) {
+	wui.renderLogin(ctx, w, retry, "", "")
+}
+
+// renderEnrolForm renders the login form together with a new secret for
+// one-time passwords. It is used for a user who must enable one-time
+// passwords before logging in.
+func (wui *WebUI) renderEnrolForm(ctx context.Context, w http.ResponseWriter, retry bool, ident, secret string) {
+	wui.renderLogin(ctx, w, retry, ident, secret)
+}
+
+func (wui *WebUI) renderLogin(ctx context.Context, w http.ResponseWriter, retry bool, ident, secret string) {
 	env, rb := wui.createRenderEnvironment(ctx, "login", wui.getUserLang(ctx), "Login", nil)
 	rb.bindString("retry", sx.MakeBoolean(retry))
+	if secret != "" {
+		rb.bindString("username", sx.MakeString(ident))
+		rb.bindString("totp-secret", sx.MakeString(secret))
+		rb.bindString("totp-uri", sx.MakeString(impl.TOTPURI(wui.rtConfig.SiteName(), ident, secret)))
+	}
+	if wui.oidcLoginURL != "" {
+		rb.bindString("oidc-url", sx.MakeString(wui.oidcLoginURL))
+	}
 	if rb.err == nil {
 		rb.err = wui.renderSxnTemplate(ctx, w, id.ZidLoginTemplate, env)
 	}
@@ -51,6 +94,11 @@ func (wui *WebUI) renderLoginForm(ctx context.Context, w http.ResponseWriter, re
 }
 
 // MakePostLoginHandler creates a new HTTP handler to authenticate the given user.
+//
+// If one-time passwords are required for the user, but not enabled, the login
+// form is shown again together with a new secret. The form then contains the
+// secret in the field "totp-secret", and the user is logged in only after a
+// valid one-time password for the secret was given.
 func (wui *WebUI) MakePostLoginHandler(ucAuth *usecase.Authenticate) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !wui.authz.WithAuth() {
@@ -63,7 +111,16 @@ func (wui *WebUI) MakePostLoginHandler(ucAuth *usecase.Authenticate) http.Handle
 			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unable to read login form"))
 			return
 		}
-		token, err := ucAuth.Run(ctx, r, ident, cred, wui.tokenLifetime, auth.KindwebUI)
+		otp := r.PostFormValue("otp")
+		if secret := r.PostFormValue("totp-secret"); secret != "" {
+			wui.enrolTOTP(w, r, ucAuth, ident, cred, secret, otp)
+			return
+		}
+		token, err := ucAuth.Run(ctx, r, ident, cred, otp, wui.tokenLifetime, auth.KindwebUI)
+		if errors.Is(err, usecase.ErrTOTPEnrolment) {
+			wui.renderEnrolForm(wui.clearToken(ctx, w), w, false, ident, impl.NewTOTPSecret())
+			return
+		}
 		if err != nil {
 			wui.reportError(ctx, w, err)
 			return
@@ -77,3 +134,80 @@ func (wui *WebUI) MakePostLoginHandler(ucAuth *usecase.Authenticate) http.Handle
 		wui.redirectFound(w, r, wui.NewURLBuilder('/'))
 	})
 }
+
+func (wui *WebUI) enrolTOTP(w http.ResponseWriter, r *http.Request, ucAuth *usecase.Authenticate, ident, cred, secret, otp string) {
+	ctx := r.Context()
+	result, err := ucAuth.Enrol(ctx, r, ident, cred, secret, otp, wui.tokenLifetime, auth.KindwebUI)
+	if err != nil {
+		wui.reportError(ctx, w, err)
+		return
+	}
+	if result == nil {
+		wui.renderEnrolForm(wui.clearToken(ctx, w), w, true, ident, secret)
+		return
+	}
+
+	// The recovery codes are shown only once, so the page cannot be reached
+	// by a redirect.
+	wui.setToken(w, result.Token)
+	wui.renderTOTP(auth.UpdateContext(ctx, result.User, nil), w, "", result.RecoveryCodes)
+}
+
+// oidcStateName is the name of the cookie that binds a login via an OpenID
+// Connect provider to the user agent that started it.
+const oidcStateName = "zsoidc"
+
+func (wui *WebUI) startOIDCLogin(w http.ResponseWriter, r *http.Request, ucOIDC *usecase.AuthenticateOIDC) {
+	ctx := r.Context()
+	authURL, state, err := ucOIDC.Start(ctx)
+	if err != nil {
+		wui.logger.Error("Unable to start login via identity provider", "err", err)
+		wui.reportError(ctx, w, err)
+		return
+	}
+	wui.setOIDCState(w, state, 600)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (wui *WebUI) finishOIDCLogin(w http.ResponseWriter, r *http.Request, ucOIDC *usecase.AuthenticateOIDC) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateName)
+	wui.setOIDCState(w, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		wui.logger.Info("Login via identity provider from another user agent")
+		wui.renderLoginForm(wui.clearToken(ctx, w), w, true)
+		return
+	}
+	if errCode := query.Get("error"); errCode != "" {
+		wui.logger.Info("Identity provider denied login", "error", errCode, "descr", query.Get("error_description"))
+		wui.renderLoginForm(wui.clearToken(ctx, w), w, true)
+		return
+	}
+	token, err := ucOIDC.Run(ctx, state, query.Get("code"), wui.tokenLifetime, auth.KindwebUI)
+	if err != nil {
+		wui.reportError(ctx, w, err)
+		return
+	}
+	if token == nil {
+		wui.renderLoginForm(wui.clearToken(ctx, w), w, true)
+		return
+	}
+	wui.setToken(w, token)
+	wui.redirectFound(w, r, wui.NewURLBuilder('/'))
+}
+
+// setOIDCState sends the state of a login to the user agent. A negative
+// value of "maxAge" removes the cookie.
+func (wui *WebUI) setOIDCState(w http.ResponseWriter, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateName,
+		Value:    state,
+		Path:     wui.NewURLBuilder('i').String(),
+		MaxAge:   maxAge,
+		Secure:   strings.HasPrefix(wui.ab.NewURLBuilderAbs('i').String(), "https:"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
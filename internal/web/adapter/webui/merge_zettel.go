@@ -0,0 +1,94 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webui
+
+import (
+	"net/http"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/constbox"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+)
+
+// MakeGetMergeZettelHandler creates a new HTTP handler to display the
+// HTML merge view of a zettel. The target zettel may be given as a query
+// value.
+func (wui *WebUI) MakeGetMergeZettelHandler(getZettel usecase.GetZettel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := r.URL.Path[1:]
+		zid, err := id.Parse(path)
+		if err != nil {
+			wui.reportError(ctx, w, box.ErrInvalidZid{Zid: path})
+			return
+		}
+
+		z, err := getZettel.Run(ctx, zid, true)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		m := z.Meta
+
+		user := auth.GetCurrentUser(ctx)
+		env, rb := wui.createRenderEnvironment(
+			ctx, "merge", wui.getUserLang(ctx), "Merge Zettel "+m.Zid.String(), user)
+		rb.bindString("target", sx.MakeString(r.URL.Query().Get(adapter.MergeKeyTarget)))
+		rb.bindString("incoming", wui.encodeIncoming(m, wui.makeGetTextTitle(ctx, getZettel)))
+		wui.bindCommonZettelData(ctx, &rb, user, m, "", nil)
+
+		if rb.err == nil {
+			err = wui.renderSxnTemplate(ctx, w, constbox.ZidMergeTemplate, env)
+		} else {
+			err = rb.err
+		}
+		if err != nil {
+			wui.reportError(ctx, w, err)
+		}
+	})
+}
+
+// MakePostMergeZettelHandler creates a new HTTP handler to merge a zettel
+// into another zettel.
+func (wui *WebUI) MakePostMergeZettelHandler(mergeZettel *usecase.MergeZettel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := r.URL.Path[1:]
+		zid, err := id.Parse(path)
+		if err != nil {
+			wui.reportError(ctx, w, box.ErrInvalidZid{Zid: path})
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unable to read merge form"))
+			return
+		}
+		tgtZid, opts, err := adapter.GetMergeOptions(r.PostForm)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+
+		if _, err = mergeZettel.Run(ctx, zid, tgtZid, opts); err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		wui.redirectFound(w, r, wui.NewURLBuilder('h').SetZid(tgtZid))
+	})
+}
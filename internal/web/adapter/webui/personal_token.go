@@ -0,0 +1,135 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webui
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"t73f.de/r/sx"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box/constbox"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+)
+
+// MakeGetPersonalTokensHandler creates a new HTTP handler to display the
+// personal access tokens of the current user.
+func (wui *WebUI) MakeGetPersonalTokensHandler(getZettel usecase.GetZettel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wui.renderPersonalTokens(r.Context(), w, getZettel, "", nil)
+	})
+}
+
+// MakePostPersonalTokensHandler creates a new HTTP handler to create or to
+// revoke a personal access token of the current user.
+func (wui *WebUI) MakePostPersonalTokensHandler(
+	getZettel usecase.GetZettel,
+	ucCreate *usecase.CreatePersonalToken,
+	ucRevoke *usecase.RevokePersonalToken,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := r.ParseForm(); err != nil {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unable to read token form"))
+			return
+		}
+		if tokenID := r.PostForm.Get("revoke"); tokenID != "" {
+			if err := ucRevoke.Run(ctx, tokenID); err != nil {
+				wui.reportError(ctx, w, err)
+				return
+			}
+			wui.redirectFound(w, r, wui.NewURLBuilder('t'))
+			return
+		}
+
+		days, err := strconv.Atoi(r.PostForm.Get("days"))
+		if err != nil || days <= 0 {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Invalid number of days"))
+			return
+		}
+		pt := auth.PersonalToken{
+			Name:     r.PostForm.Get("name"),
+			Expires:  time.Now().Round(time.Second).AddDate(0, 0, days),
+			ReadOnly: r.PostForm.Get("scope") != auth.TokenScopeWrite,
+			Roles:    strings.Fields(r.PostForm.Get("roles")),
+		}
+		token, err := ucCreate.Run(ctx, pt)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		wui.renderPersonalTokens(ctx, w, getZettel, pt.Name, token)
+	})
+}
+
+func (wui *WebUI) renderPersonalTokens(
+	ctx context.Context, w http.ResponseWriter, getZettel usecase.GetZettel, newName string, newToken []byte,
+) {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		wui.reportError(ctx, w, usecase.ErrTokenNotAllowed)
+		return
+	}
+	userZettel, err := getZettel.Run(ctx, user.Zid, false)
+	if err != nil {
+		wui.reportError(ctx, w, err)
+		return
+	}
+
+	now := time.Now()
+	var tokens sx.ListBuilder
+	for _, pt := range auth.GetPersonalTokens(userZettel.Meta) {
+		scope := "Read"
+		if !pt.ReadOnly {
+			scope = "Write"
+		}
+		roles := "All"
+		if len(pt.Roles) > 0 {
+			roles = strings.Join(pt.Roles, ", ")
+		}
+		expires := pt.Expires.Local().Format(time.DateTime)
+		if pt.IsExpired(now) {
+			expires += " (expired)"
+		}
+		tokens.Add(sx.MakeList(
+			sx.MakeString(pt.ID),
+			sx.MakeString(pt.Name),
+			sx.MakeString(scope),
+			sx.MakeString(roles),
+			sx.MakeString(expires),
+		))
+	}
+
+	env, rb := wui.createRenderEnvironment(ctx, "tokens", wui.getUserLang(ctx), "Personal Access Tokens", user)
+	rb.bindString("tokens", tokens.List())
+	rb.bindString("new-token-name", sx.MakeString(newName))
+	if newToken != nil {
+		rb.bindString("new-token", sx.MakeString(string(newToken)))
+	} else {
+		rb.bindString("new-token", sx.Nil())
+	}
+	if rb.err == nil {
+		err = wui.renderSxnTemplate(ctx, w, constbox.ZidPersonalTokenTemplate, env)
+	} else {
+		err = rb.err
+	}
+	if err != nil {
+		wui.reportError(ctx, w, err)
+	}
+}
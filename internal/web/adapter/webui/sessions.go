@@ -0,0 +1,115 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webui
+
+import (
+	"net/http"
+	"time"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box/constbox"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+)
+
+// MakeGetSessionsHandler creates a new HTTP handler to display the active
+// sessions, which the current user is allowed to see.
+func (wui *WebUI) MakeGetSessionsHandler(ucList usecase.ListSessions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		sessions, err := ucList.Run(ctx)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+
+		user := auth.GetCurrentUser(ctx)
+		var current string
+		if authData := auth.GetAuthData(ctx); authData != nil {
+			current = authData.Session
+		}
+		var lb sx.ListBuilder
+		for _, sd := range sessions {
+			kind := "Web"
+			if sd.Kind == auth.KindAPI {
+				kind = "API"
+			}
+			lb.Add(sx.MakeList(
+				sx.MakeString(sd.Session),
+				sx.MakeBoolean(sd.Session == current),
+				sx.MakeString(sd.Zid.String()),
+				sx.MakeString(wui.NewURLBuilder('h').SetZid(sd.Zid).String()),
+				sx.MakeString(sd.Ident),
+				sx.MakeString(kind),
+				sx.MakeString(sd.Issued.Local().Format(time.DateTime)),
+				sx.MakeString(sd.LastSeen.Local().Format(time.DateTime)),
+				sx.MakeString(sd.Expires.Local().Format(time.DateTime)),
+			))
+		}
+
+		env, rb := wui.createRenderEnvironment(ctx, "sessions", wui.getUserLang(ctx), "Sessions", user)
+		rb.bindString("sessions", lb.List())
+		rb.bindString("user-zid", sx.MakeString(user.Zid.String()))
+		rb.bindString("is-owner", sx.MakeBoolean(wui.authz.IsOwner(user.Zid)))
+		if rb.err == nil {
+			err = wui.renderSxnTemplate(ctx, w, constbox.ZidSessionsTemplate, env)
+		} else {
+			err = rb.err
+		}
+		if err != nil {
+			wui.reportError(ctx, w, err)
+		}
+	})
+}
+
+// MakePostSessionsHandler creates a new HTTP handler to end one session or
+// all sessions of a user.
+func (wui *WebUI) MakePostSessionsHandler(ucRevoke *usecase.RevokeSession, ucRevokeAll *usecase.RevokeAllSessions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := r.ParseForm(); err != nil {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unable to read session form"))
+			return
+		}
+		authData := auth.GetAuthData(ctx)
+		endedCurrent := false
+		if session := r.PostForm.Get("end"); session != "" {
+			if err := ucRevoke.Run(ctx, session); err != nil {
+				wui.reportError(ctx, w, err)
+				return
+			}
+			endedCurrent = authData != nil && authData.Session == session
+		} else if val := r.PostForm.Get("end-all"); val != "" {
+			zid, err := id.Parse(val)
+			if err != nil {
+				wui.reportError(ctx, w, adapter.NewErrBadRequest("Invalid user zettel identifier"))
+				return
+			}
+			if err = ucRevokeAll.Run(ctx, zid); err != nil {
+				wui.reportError(ctx, w, err)
+				return
+			}
+			endedCurrent = authData != nil && authData.User != nil && authData.User.Zid == zid
+		}
+		if endedCurrent {
+			wui.clearToken(ctx, w)
+			wui.redirectFound(w, r, wui.NewURLBuilder('/'))
+			return
+		}
+		wui.redirectFound(w, r, wui.NewURLBuilder('s'))
+	})
+}
@@ -0,0 +1,166 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webui
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+	"t73f.de/r/zsc/sz"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/box/constbox"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+)
+
+// maxShareDays is the maximum number of days a share link is valid.
+const maxShareDays = 366
+
+// MakeGetShareHandler creates a new HTTP handler to display the form to
+// create a share link for a zettel.
+func (wui *WebUI) MakeGetShareHandler(getZettel usecase.GetZettel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := r.URL.Path[1:]
+		zid, err := id.Parse(path)
+		if err != nil {
+			wui.reportError(ctx, w, box.ErrInvalidZid{Zid: path})
+			return
+		}
+		wui.renderShare(ctx, w, getZettel, zid, nil, time.Time{})
+	})
+}
+
+// MakePostShareHandler creates a new HTTP handler to create a share link for
+// a zettel.
+func (wui *WebUI) MakePostShareHandler(getZettel usecase.GetZettel, ucCreate *usecase.CreateShareLink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := r.URL.Path[1:]
+		zid, err := id.Parse(path)
+		if err != nil {
+			wui.reportError(ctx, w, box.ErrInvalidZid{Zid: path})
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unable to read share form"))
+			return
+		}
+		days, err := strconv.Atoi(r.PostForm.Get("days"))
+		if err != nil || days <= 0 || maxShareDays < days {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Invalid number of days"))
+			return
+		}
+		expires := time.Now().Round(time.Second).AddDate(0, 0, days)
+		transclusions := r.PostForm.Get("transclusions") == "true"
+		token, err := ucCreate.Run(ctx, zid, expires, transclusions)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		wui.renderShare(ctx, w, getZettel, zid, token, expires)
+	})
+}
+
+func (wui *WebUI) renderShare(
+	ctx context.Context, w http.ResponseWriter, getZettel usecase.GetZettel, zid id.Zid, token []byte, expires time.Time,
+) {
+	z, err := getZettel.Run(ctx, zid, true)
+	if err != nil {
+		wui.reportError(ctx, w, err)
+		return
+	}
+	user := auth.GetCurrentUser(ctx)
+	if !wui.policy.CanShare(user, z.Meta) {
+		wui.reportError(ctx, w, box.NewErrNotAllowed("Share", user, zid))
+		return
+	}
+
+	title := sz.NormalizedSpacedText(z.Meta.GetTitle())
+	env, rb := wui.createRenderEnvironment(ctx, "share", wui.getUserLang(ctx), "Share "+title, user)
+	rb.bindString("heading", sx.MakeString(title))
+	rb.bindString("web-url", sx.MakeString(wui.NewURLBuilder('h').SetZid(zid).String()))
+	rb.bindString("info-url", sx.MakeString(wui.NewURLBuilder('i').SetZid(zid).String()))
+	if token != nil {
+		shareURL := wui.ab.NewURLBuilderAbs('v').SetZid(zid).AppendKVQuery(queryKeyShare, string(token))
+		rb.bindString("share-url", sx.MakeString(shareURL.String()))
+		rb.bindString("share-expires", sx.MakeString(expires.Local().Format(time.DateTime)))
+	} else {
+		rb.bindString("share-url", sx.Nil())
+	}
+	if rb.err == nil {
+		err = wui.renderSxnTemplate(ctx, w, constbox.ZidShareTemplate, env)
+	} else {
+		err = rb.err
+	}
+	if err != nil {
+		wui.reportError(ctx, w, err)
+	}
+}
+
+// MakeGetSharedZettelHandler creates a new HTTP handler to show a zettel that
+// was shared by a share link.
+func (wui *WebUI) MakeGetSharedZettelHandler(ucShared *usecase.GetSharedZettel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := r.URL.Path[1:]
+		zid, err := id.Parse(path)
+		if err != nil {
+			wui.reportError(ctx, w, box.ErrInvalidZid{Zid: path})
+			return
+		}
+
+		q := r.URL.Query()
+		zn, sd, err := ucShared.Run(ctx, zid, []byte(q.Get(queryKeyShare)), q.Get(meta.KeySyntax))
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+		if zn.Syntax == meta.ValueSyntaxNone {
+			zn.Blocks = contentFromMetadata(zn.Meta)
+		}
+
+		zettelLang := wui.getConfig(ctx, zn.InhMeta, meta.KeyLang)
+		enc := wui.getSimpleHTMLEncoder(zettelLang)
+		content, endnotes, err := enc.BlocksSxn(zn.Blocks)
+		if err != nil {
+			wui.reportError(ctx, w, err)
+			return
+		}
+
+		title := sz.NormalizedSpacedText(zn.InhMeta.GetTitle())
+		env, rb := wui.createRenderEnvironment(ctx, "shared", zettelLang, title, auth.GetCurrentUser(ctx))
+		rb.bindString("heading", sx.MakeString(title))
+		rb.bindString("content", content)
+		rb.bindString("endnotes", endnotes)
+		rb.bindString("share-expires", sx.MakeString(sd.Expires.Local().Format(time.DateTime)))
+		if rb.err == nil {
+			// The token must not be sent to other sites when following links.
+			w.Header().Set("Referrer-Policy", "no-referrer")
+			err = wui.renderSxnTemplate(ctx, w, constbox.ZidSharedZettelTemplate, env)
+		} else {
+			err = rb.err
+		}
+		if err != nil {
+			wui.reportError(ctx, w, err)
+		}
+	})
+}
@@ -185,6 +185,13 @@ func (wui *WebUI) createRenderEnvironment(ctx context.Context, name, lang, title
 	rb.bindString("user-ident", sx.MakeString(userIdent))
 	rb.bindString("login-url", sx.MakeString(wui.loginURL))
 	rb.bindString("logout-url", sx.MakeString(wui.logoutURL))
+	if wui.withAuth && user != nil && !auth.IsTokenUser(user) {
+		rb.bindString("sessions-url", sx.MakeString(wui.sessionsURL))
+		if !wui.authz.IsReadonly() {
+			rb.bindString("tokens-url", sx.MakeString(wui.tokensURL))
+			rb.bindString("totp-url", sx.MakeString(wui.totpURL))
+		}
+	}
 	rb.bindString("list-urls", wui.buildListsMenuSxn(ctx, lang))
 	if wui.canRefresh(user) {
 		rb.bindString("refresh-url", sx.MakeString(wui.refreshURL))
@@ -316,6 +323,7 @@ func (wui *WebUI) bindCommonZettelData(ctx context.Context, rb *renderBinder, us
 	}
 	if wui.canDelete(ctx, user, m) {
 		rb.bindString("delete-url", sx.MakeString(newURLBuilder('d').SetZid(zid).String()))
+		rb.bindString("merge-url", sx.MakeString(newURLBuilder('j').SetZid(zid).String()))
 	}
 	if val, found := m.Get(meta.KeyUselessFiles); found {
 		rb.bindString("useless", sx.Cons(sx.MakeString(string(val)), nil))
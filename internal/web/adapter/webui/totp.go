@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webui
+
+import (
+	"context"
+	"net/http"
+
+	"t73f.de/r/sx"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+	"zettelstore.de/z/internal/auth/impl"
+	"zettelstore.de/z/internal/box/constbox"
+	"zettelstore.de/z/internal/usecase"
+	"zettelstore.de/z/internal/web/adapter"
+)
+
+// MakeGetTOTPHandler creates a new HTTP handler to display the state of
+// one-time passwords of the current user, and to start to enable them.
+func (wui *WebUI) MakeGetTOTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wui.renderTOTP(r.Context(), w, impl.NewTOTPSecret(), nil)
+	})
+}
+
+// MakePostTOTPHandler creates a new HTTP handler to enable or to disable
+// one-time passwords for the current user.
+func (wui *WebUI) MakePostTOTPHandler(ucEnable *usecase.EnableTOTP, ucDisable *usecase.DisableTOTP) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := r.ParseForm(); err != nil {
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unable to read one-time password form"))
+			return
+		}
+		code := r.PostForm.Get("code")
+		switch r.PostForm.Get("action") {
+		case "enable":
+			codes, err := ucEnable.Run(ctx, r.PostForm.Get("secret"), code)
+			if err != nil {
+				wui.reportError(ctx, w, err)
+				return
+			}
+			wui.renderTOTP(ctx, w, "", codes)
+		case "disable":
+			if err := ucDisable.Run(ctx, code); err != nil {
+				wui.reportError(ctx, w, err)
+				return
+			}
+			wui.redirectFound(w, r, wui.NewURLBuilder('o'))
+		default:
+			wui.reportError(ctx, w, adapter.NewErrBadRequest("Unknown action"))
+		}
+	})
+}
+
+func (wui *WebUI) renderTOTP(ctx context.Context, w http.ResponseWriter, secret string, recoveryCodes []string) {
+	user := auth.GetCurrentUser(ctx)
+	if user == nil || auth.IsTokenUser(user) {
+		wui.reportError(ctx, w, usecase.ErrTokenNotAllowed)
+		return
+	}
+	env, rb := wui.createRenderEnvironment(ctx, "totp", wui.getUserLang(ctx), "Two-Factor Authentication", user)
+	enabled := auth.HasTOTP(user) || recoveryCodes != nil
+	rb.bindString("totp-enabled", sx.MakeBoolean(enabled))
+	rb.bindString("totp-required", sx.MakeBoolean(wui.rtConfig.IsTOTPRequired(wui.authz.GetUserRole(user))))
+	if !enabled {
+		ident := string(user.GetDefault(meta.KeyUserID, ""))
+		rb.bindString("totp-secret", sx.MakeString(secret))
+		rb.bindString("totp-uri", sx.MakeString(impl.TOTPURI(wui.rtConfig.SiteName(), ident, secret)))
+	}
+	var lb sx.ListBuilder
+	for _, code := range recoveryCodes {
+		lb.Add(sx.MakeString(code))
+	}
+	rb.bindString("recovery-codes", lb.List())
+	var err error
+	if rb.err == nil {
+		err = wui.renderSxnTemplate(ctx, w, constbox.ZidTOTPTemplate, env)
+	} else {
+		err = rb.err
+	}
+	if err != nil {
+		wui.reportError(ctx, w, err)
+	}
+}
@@ -66,6 +66,10 @@ type WebUI struct {
 	withAuth      bool
 	loginURL      string
 	logoutURL     string
+	oidcLoginURL  string
+	tokensURL     string
+	sessionsURL   string
+	totpURL       string
 	searchURL     string
 	createNewURL  string
 
@@ -117,6 +121,9 @@ func New(logger *slog.Logger, ab server.AuthBuilder, authz auth.AuthzManager, rt
 		withAuth:      authz.WithAuth(),
 		loginURL:      loginoutBase.String(),
 		logoutURL:     loginoutBase.AppendKVQuery("logout", "").String(),
+		tokensURL:     ab.NewURLBuilder('t').String(),
+		sessionsURL:   ab.NewURLBuilder('s').String(),
+		totpURL:       ab.NewURLBuilder('o').String(),
 		searchURL:     ab.NewURLBuilder('h').String(),
 		createNewURL:  ab.NewURLBuilder('c').String(),
 
@@ -31,6 +31,7 @@ const (
 	charsetUTF8      = "; charset=utf-8"
 	mimeCSS          = "text/css"
 	mimeCSSUTF8      = mimeCSS + charsetUTF8
+	EventStream      = "text/event-stream"
 	mimeGIF          = "image/gif"
 	mimeHTML         = "text/html"
 	mimeHTMLUTF8     = mimeHTML + charsetUTF8
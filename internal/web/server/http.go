@@ -18,6 +18,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"t73f.de/r/webs/middleware"
@@ -26,6 +27,7 @@ import (
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/webapi"
 
+	"zettelstore.de/z/internal/audit"
 	"zettelstore.de/z/internal/auth"
 )
 
@@ -46,9 +48,11 @@ type ConfigData struct {
 	BaseURL          string
 	URLPrefix        string
 	MaxRequestSize   int64
-	Auth             auth.TokenManager
+	Auth             TokenManager
 	LoopbackIdent    string
 	LoopbackZid      id.Zid
+	ProxyHeader      string
+	ProxyAddresses   []netip.Prefix
 	PersistentCookie bool
 	SecureCookie     bool
 	Profiling        bool
@@ -71,6 +75,8 @@ func New(sd ConfigData) Server {
 		auth:           sd.Auth,
 		loopbackIdent:  sd.LoopbackIdent,
 		loopbackZid:    sd.LoopbackZid,
+		proxyHeader:    sd.ProxyHeader,
+		proxyAddrs:     sd.ProxyAddresses,
 		profiling:      sd.Profiling,
 	}
 	srv.router.initializeRouter(rd)
@@ -91,21 +97,33 @@ func (srv *webServer) Handle(pattern string, handler http.Handler) {
 	srv.router.Handle(pattern, handler)
 }
 func (srv *webServer) AddListRoute(isAPI bool, key byte, method Method, handler http.Handler) {
-	if !isAPI {
-		handler = srv.cop.Handler(handler)
-	}
-	srv.router.addListRoute(key, method, handler)
+	srv.router.addListRoute(key, method, srv.wrapHandler(isAPI, handler))
 }
 func (srv *webServer) AddZettelRoute(isAPI bool, key byte, method Method, handler http.Handler) {
+	srv.router.addZettelRoute(key, method, srv.wrapHandler(isAPI, handler))
+}
+func (srv *webServer) wrapHandler(isAPI bool, handler http.Handler) http.Handler {
+	src := audit.SourceAPI
 	if !isAPI {
 		handler = srv.cop.Handler(handler)
+		src = audit.SourceWebUI
 	}
-	srv.router.addZettelRoute(key, method, handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(audit.WithSource(r.Context(), src)))
+	})
 }
 func (srv *webServer) SetUserRetriever(ur UserRetriever) {
 	srv.router.ur = ur
 }
 
+func (srv *webServer) SetPersonalTokenRetriever(ptr PersonalTokenRetriever) {
+	srv.router.ptr = ptr
+}
+
+func (srv *webServer) SetProxyUserRetriever(pur ProxyUserRetriever) {
+	srv.router.pur = pur
+}
+
 func (srv *webServer) NewURLBuilder(key byte) *webapi.URLBuilder {
 	return webapi.NewURLBuilder(srv.router.urlPrefix, key)
 }
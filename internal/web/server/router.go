@@ -17,8 +17,10 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/pprof"
+	"net/netip"
 	"regexp"
 	rtprf "runtime/pprof"
+	"slices"
 	"strings"
 
 	"t73f.de/r/webs/ip"
@@ -38,21 +40,27 @@ var mapMethod = map[string]Method{
 	http.MethodPost:   MethodPost,
 	http.MethodPut:    MethodPut,
 	http.MethodDelete: MethodDelete,
+	"MOVE":            MethodMove,
+	http.MethodPatch:  MethodPatch,
 }
 
 // httpRouter handles all routing for zettelstore.
 type httpRouter struct {
 	log           *slog.Logger
 	urlPrefix     string
-	auth          auth.TokenManager
+	auth          TokenManager
 	loopbackIdent string
 	loopbackZid   id.Zid
+	proxyHeader   string
+	proxyAddrs    []netip.Prefix
 	minKey        byte
 	maxKey        byte
 	reURL         *regexp.Regexp
 	listTable     routingTable
 	zettelTable   routingTable
 	ur            UserRetriever
+	ptr           PersonalTokenRetriever
+	pur           ProxyUserRetriever
 	mux           *http.ServeMux
 	maxReqSize    int64
 }
@@ -61,9 +69,11 @@ type routerData struct {
 	log            *slog.Logger
 	urlPrefix      string
 	maxRequestSize int64
-	auth           auth.TokenManager
+	auth           TokenManager
 	loopbackIdent  string
 	loopbackZid    id.Zid
+	proxyHeader    string
+	proxyAddrs     []netip.Prefix
 	profiling      bool
 }
 
@@ -74,6 +84,8 @@ func (rt *httpRouter) initializeRouter(rd routerData) {
 	rt.auth = rd.auth
 	rt.loopbackIdent = rd.loopbackIdent
 	rt.loopbackZid = rd.loopbackZid
+	rt.proxyHeader = rd.proxyHeader
+	rt.proxyAddrs = rd.proxyAddrs
 	rt.minKey = 255
 	rt.maxKey = 0
 	rt.reURL = regexp.MustCompile("^$")
@@ -147,6 +159,7 @@ func (rt *httpRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r.URL.Path = r.URL.Path[prefixLen-1:]
 	}
 	r.Body = http.MaxBytesReader(w, r.Body, rt.maxReqSize)
+	r = r.WithContext(auth.UpdateContextRemote(r.Context(), rt.clientAddr(r)))
 	match := rt.reURL.FindStringSubmatch(r.URL.Path)
 	if len(match) != 3 {
 		rt.mux.ServeHTTP(w, rt.addUserContext(r))
@@ -178,8 +191,17 @@ func (rt *httpRouter) addUserContext(r *http.Request) *http.Request {
 	}
 	ctx := r.Context()
 
+	if rt.proxyHeader != "" {
+		if ident := r.Header.Get(rt.proxyHeader); ident != "" {
+			if rt.isTrustedProxy(r) {
+				return rt.addProxyUser(r, ident)
+			}
+			rt.log.Info("identity header from untrusted address", "header", rt.proxyHeader, "remote", r.RemoteAddr)
+		}
+	}
+
 	if rt.loopbackZid.IsValid() {
-		if remoteAddr := ip.GetRemoteAddr(r); ip.IsLoopbackAddr(remoteAddr) {
+		if remoteAddr := rt.clientAddr(r); ip.IsLoopbackAddr(remoteAddr) {
 			if u, err := rt.ur.GetUser(ctx, rt.loopbackZid, rt.loopbackIdent); err == nil {
 				if u != nil {
 					return r.WithContext(auth.UpdateContext(ctx, u, nil))
@@ -200,6 +222,9 @@ func (rt *httpRouter) addUserContext(r *http.Request) *http.Request {
 		rt.log.Debug("no auth token found in request") // IP already logged: ServeHTTP
 		return r
 	}
+	if k == auth.KindAPI && auth.IsPersonalToken(t) {
+		return rt.addPersonalTokenUser(r, t)
+	}
 	tokenData, err := rt.auth.CheckToken(t, k)
 	if err != nil {
 		rt.log.Info("invalid auth token", "err", err, "remote", ip.GetRemoteAddr(r))
@@ -214,9 +239,64 @@ func (rt *httpRouter) addUserContext(r *http.Request) *http.Request {
 			"remote", ip.GetRemoteAddr(r))
 		return r
 	}
+	if u != nil && auth.IsSessionRevoked(u, &tokenData) {
+		// Session was revoked before the last restart
+		rt.auth.RevokeSession(tokenData.Session, tokenData.Expires)
+		rt.log.Info("revoked auth token", "ident", tokenData.Ident, "remote", ip.GetRemoteAddr(r))
+		return r
+	}
 	return r.WithContext(auth.UpdateContext(ctx, u, &tokenData))
 }
 
+func (rt *httpRouter) addPersonalTokenUser(r *http.Request, t []byte) *http.Request {
+	if rt.ptr == nil {
+		rt.log.Info("personal token not supported", "remote", ip.GetRemoteAddr(r))
+		return r
+	}
+	ctx := r.Context()
+	u, tokenData, err := rt.ptr.GetUserByToken(ctx, t)
+	if err != nil {
+		rt.log.Info("invalid personal token", "err", err, "remote", ip.GetRemoteAddr(r))
+		return r
+	}
+	return r.WithContext(auth.UpdateContext(ctx, u, tokenData))
+}
+
+// isTrustedProxy returns true, if the request was sent directly by one of the
+// configured proxies. Any forwarding headers must be ignored, because they
+// could be set by everybody.
+func (rt *httpRouter) isTrustedProxy(r *http.Request) bool {
+	ap, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := ap.Addr().Unmap()
+	return slices.ContainsFunc(rt.proxyAddrs, func(prefix netip.Prefix) bool { return prefix.Contains(addr) })
+}
+
+// clientAddr returns the address of the client. Forwarding headers are only
+// used, if the request was sent by a trusted proxy.
+func (rt *httpRouter) clientAddr(r *http.Request) string {
+	if rt.isTrustedProxy(r) {
+		return ip.GetRemoteAddr(r)
+	}
+	return r.RemoteAddr
+}
+
+func (rt *httpRouter) addProxyUser(r *http.Request, ident string) *http.Request {
+	if rt.pur == nil {
+		rt.log.Info("proxy authentication not supported", "remote", r.RemoteAddr)
+		return r
+	}
+	ctx := r.Context()
+	u, err := rt.pur.GetUserByIdent(ctx, ident)
+	if err != nil || u == nil {
+		rt.log.Info("proxy user not found", "ident", ident, "err", err, "remote", r.RemoteAddr)
+		return r
+	}
+	return r.WithContext(auth.UpdateContext(ctx, u, nil))
+}
+
 func getSessionToken(r *http.Request) []byte {
 	cookie, err := r.Cookie(sessionName)
 	if err != nil {
@@ -0,0 +1,156 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/auth"
+)
+
+const testProxyHeader = "X-Remote-User"
+
+// testUserRetriever knows one user.
+type testUserRetriever struct{}
+
+func (testUserRetriever) GetUser(context.Context, id.Zid, string) (*meta.Meta, error) {
+	return nil, nil
+}
+
+func (testUserRetriever) GetUserByIdent(_ context.Context, ident string) (*meta.Meta, error) {
+	if ident != "user" {
+		return nil, nil
+	}
+	m := meta.New(13)
+	m.Set(meta.KeyUserID, meta.Value(ident))
+	return m, nil
+}
+
+func newProxyTestRouter(proxies ...string) *httpRouter {
+	var prefixes []netip.Prefix
+	for _, proxy := range proxies {
+		prefixes = append(prefixes, netip.MustParsePrefix(proxy))
+	}
+	var rt httpRouter
+	rt.initializeRouter(routerData{
+		log:         slog.New(slog.DiscardHandler),
+		proxyHeader: testProxyHeader,
+		proxyAddrs:  prefixes,
+	})
+	rt.ur = testUserRetriever{}
+	rt.pur = testUserRetriever{}
+	return &rt
+}
+
+func TestIsTrustedProxy(t *testing.T) {
+	t.Parallel()
+	rt := newProxyTestRouter("192.0.2.1/32", "10.0.0.0/8", "2001:db8::/32")
+	testcases := []struct {
+		remote string
+		exp    bool
+	}{
+		{"192.0.2.1:1234", true},
+		{"192.0.2.2:1234", false},
+		{"10.1.2.3:80", true},
+		{"[2001:db8::1]:1234", true},
+		{"[2001:db9::1]:1234", false},
+		{"[::ffff:192.0.2.1]:1234", true},
+		{"[::ffff:192.0.2.2]:1234", false},
+		{"192.0.2.1", false},
+		{"", false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.remote, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remote
+			if got := rt.isTrustedProxy(r); got != tc.exp {
+				t.Errorf("expected %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+	if newProxyTestRouter().isTrustedProxy(httptest.NewRequest(http.MethodGet, "/", nil)) {
+		t.Error("no address must be trusted without configured proxies")
+	}
+}
+
+func TestProxyUser(t *testing.T) {
+	t.Parallel()
+	rt := newProxyTestRouter("192.0.2.1/32")
+	testcases := []struct {
+		name   string
+		remote string
+		ident  string
+		exp    id.Zid
+	}{
+		{"trusted", "192.0.2.1:1234", "user", 13},
+		{"mapped", "[::ffff:192.0.2.1]:1234", "user", 13},
+		{"untrusted", "192.0.2.2:1234", "user", id.Invalid},
+		{"untrusted-mapped", "[::ffff:192.0.2.2]:1234", "user", id.Invalid},
+		{"unknown", "192.0.2.1:1234", "other", id.Invalid},
+		{"no-header", "192.0.2.1:1234", "", id.Invalid},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remote
+			if tc.ident != "" {
+				r.Header.Set(testProxyHeader, tc.ident)
+			}
+			got := id.Invalid
+			if user := auth.GetCurrentUser(rt.addUserContext(r).Context()); user != nil {
+				got = user.Zid
+			}
+			if got != tc.exp {
+				t.Errorf("expected user %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	t.Parallel()
+	rt := newProxyTestRouter("192.0.2.1/32")
+	testcases := []struct {
+		name      string
+		remote    string
+		forwarded string
+		exp       string
+	}{
+		{"trusted", "192.0.2.1:1234", "198.51.100.7", "198.51.100.7"},
+		{"untrusted", "192.0.2.2:1234", "198.51.100.7", "192.0.2.2:1234"},
+		{"untrusted-loopback", "192.0.2.2:1234", "127.0.0.1", "192.0.2.2:1234"},
+		{"no-header", "192.0.2.2:1234", "", "192.0.2.2:1234"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remote
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			if got := rt.clientAddr(r); !strings.Contains(got, tc.exp) {
+				t.Errorf("expected %q, but got %q", tc.exp, got)
+			}
+		})
+	}
+}
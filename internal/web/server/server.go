@@ -22,6 +22,8 @@ import (
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
 	"t73f.de/r/zsc/webapi"
+
+	"zettelstore.de/z/internal/auth"
 )
 
 // UserRetriever allows to retrieve user data based on a given zettel identifier.
@@ -29,6 +31,25 @@ type UserRetriever interface {
 	GetUser(ctx context.Context, zid id.Zid, ident string) (*meta.Meta, error)
 }
 
+// TokenManager checks authentication tokens and keeps track of their
+// sessions.
+type TokenManager interface {
+	auth.TokenManager
+	auth.SessionManager
+}
+
+// PersonalTokenRetriever allows to retrieve user data based on a personal
+// access token.
+type PersonalTokenRetriever interface {
+	GetUserByToken(ctx context.Context, token []byte) (*meta.Meta, *auth.TokenData, error)
+}
+
+// ProxyUserRetriever allows to retrieve user data based on the user
+// identification, which was sent by a trusted proxy.
+type ProxyUserRetriever interface {
+	GetUserByIdent(ctx context.Context, ident string) (*meta.Meta, error)
+}
+
 // Method enumerates the allowed HTTP methods.
 type Method uint8
 
@@ -39,6 +60,8 @@ const (
 	MethodPost
 	MethodPut
 	MethodDelete
+	MethodMove
+	MethodPatch
 	methodLAST // must always be the last one
 )
 
@@ -48,6 +71,8 @@ type Router interface {
 	AddListRoute(isAPI bool, key byte, method Method, handler http.Handler)
 	AddZettelRoute(isAPI bool, key byte, method Method, handler http.Handler)
 	SetUserRetriever(ur UserRetriever)
+	SetPersonalTokenRetriever(ptr PersonalTokenRetriever)
+	SetProxyUserRetriever(pur ProxyUserRetriever)
 }
 
 // Builder allows to build new URLs for the web service.
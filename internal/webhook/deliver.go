@@ -0,0 +1,228 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+)
+
+// HTTP header fields of a webhook request.
+const (
+	HeaderEvent     = "X-Zettelstore-Event"
+	HeaderDelivery  = "X-Zettelstore-Delivery"
+	HeaderSignature = "X-Zettelstore-Signature"
+)
+
+var errInvalidScheme = errors.New("scheme must be http or https")
+
+// payload is the body of a webhook request.
+type payload struct {
+	Event    string `json:"event"`
+	Zid      string `json:"zid"`
+	Target   string `json:"target"`
+	Delivery string `json:"delivery"`
+	Time     string `json:"time"`
+}
+
+// DeadLetter records an event that could not be delivered.
+type DeadLetter struct {
+	Time     time.Time
+	Target   id.Zid // Zettel that defines the webhook target
+	URL      string
+	Event    string
+	Zid      id.Zid // Zettel that was changed
+	Attempts int
+	Err      string
+}
+
+// Sign computes the signature of the body of a request, as it is sent in the
+// header field "X-Zettelstore-Signature".
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// delivery is an event to be delivered to a target.
+type delivery struct {
+	id       string
+	target   id.Zid
+	url      string // URL of target, when the event was first delivered
+	event    string
+	zid      id.Zid
+	time     time.Time
+	attempts int
+}
+
+func (d *Dispatcher) newDelivery(t *target, event string, zid id.Zid) *delivery {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return &delivery{
+		id:     hex.EncodeToString(buf[:]),
+		target: t.zid,
+		url:    t.url,
+		event:  event,
+		zid:    zid,
+		time:   time.Now().UTC(),
+	}
+}
+
+func (dl *delivery) deadLetter(msg string) DeadLetter {
+	return DeadLetter{Target: dl.target, URL: dl.url, Event: dl.event, Zid: dl.zid, Attempts: dl.attempts, Err: msg}
+}
+
+// deliver sends the event to the target, and retries it, if needed. Until it
+// succeeds or is abandoned, the delivery is pending.
+func (d *Dispatcher) deliver(t *target, dl *delivery) {
+	pl := payload{
+		Event:    dl.event,
+		Zid:      dl.zid.String(),
+		Target:   t.zid.String(),
+		Delivery: dl.id,
+		Time:     dl.time.Format(time.RFC3339),
+	}
+	body, err := json.Marshal(pl)
+	if err != nil {
+		d.logger.Error("Unable to encode webhook payload", "err", err)
+		return
+	}
+	d.mx.Lock()
+	d.pending[dl.id] = dl
+	d.dirty = true
+	d.mx.Unlock()
+
+	d.wg.Go(func() {
+		select {
+		case d.sem <- struct{}{}:
+		case <-d.ctx.Done():
+			return
+		}
+		defer func() { <-d.sem }()
+
+		wait := d.backoff
+		for {
+			d.mx.Lock()
+			dl.attempts++
+			attempts := dl.attempts
+			d.dirty = true
+			d.mx.Unlock()
+			retry, err2 := d.post(t, pl, body)
+			if err2 != nil && d.ctx.Err() != nil {
+				// Dispatcher was stopped while posting, the delivery stays pending.
+				d.mx.Lock()
+				dl.attempts--
+				d.mx.Unlock()
+				return
+			}
+			if err2 == nil {
+				d.logger.Debug("Webhook delivered", "target", t.zid, "event", dl.event, "zid", dl.zid, "attempts", attempts)
+				d.finish(dl, nil)
+				return
+			}
+			d.logger.Info("Webhook delivery failed", "target", t.zid, "event", dl.event, "zid", dl.zid, "attempt", attempts, "err", err2)
+			if !retry || attempts >= d.maxAttempts {
+				d.logger.Warn("Webhook delivery abandoned", "target", t.zid, "event", dl.event, "zid", dl.zid, "attempts", attempts)
+				d.finish(dl, err2)
+				return
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-d.ctx.Done():
+				// Delivery stays pending and is saved.
+				timer.Stop()
+				return
+			}
+			wait = min(2*wait, maxBackoff)
+		}
+	})
+}
+
+// finish removes the delivery from the pending ones. If it failed, it becomes
+// a dead letter.
+func (d *Dispatcher) finish(dl *delivery, err error) {
+	d.mx.Lock()
+	delete(d.pending, dl.id)
+	d.dirty = true
+	d.mx.Unlock()
+	if err != nil {
+		d.addDeadLetter(dl.deadLetter(err.Error()))
+	}
+}
+
+// post sends one request. It returns true if it makes sense to retry the
+// request after an error.
+func (d *Dispatcher) post(t *target, pl payload, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, pl.Event)
+	req.Header.Set(HeaderDelivery, pl.Delivery)
+	if len(t.secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(t.secret, body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	if code := resp.StatusCode; code < 200 || 299 < code {
+		retry := code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+		return retry, fmt.Errorf("unexpected HTTP status %d", code)
+	}
+	return false, nil
+}
+
+func (d *Dispatcher) addDeadLetter(dl DeadLetter) {
+	if dl.Time.IsZero() {
+		dl.Time = time.Now()
+	}
+	d.mx.Lock()
+	if len(d.dead) >= maxDeadLetters {
+		d.dead = slices.Delete(d.dead, 0, len(d.dead)-maxDeadLetters+1)
+	}
+	d.dead = append(d.dead, dl)
+	d.lastDead = dl.Time
+	d.dirty = true
+	d.mx.Unlock()
+}
+
+// DeadLetters returns all events that could not be delivered, most recent
+// first, and the time of the last one.
+func (d *Dispatcher) DeadLetters() ([]DeadLetter, time.Time) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	result := slices.Clone(d.dead)
+	slices.Reverse(result)
+	return result, d.lastDead
+}
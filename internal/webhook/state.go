@@ -0,0 +1,226 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+
+	"zettelstore.de/z/internal/box"
+)
+
+// state is the content of the file of a dispatcher.
+type state struct {
+	Changes []stateChange   `json:"changes,omitempty"`
+	Pending []stateDelivery `json:"pending,omitempty"`
+	Dead    []stateDelivery `json:"dead,omitempty"`
+}
+
+// stateChange is a change that was not processed.
+type stateChange struct {
+	Zid     string `json:"zid"`
+	Reason  string `json:"reason"`
+	Created bool   `json:"created,omitempty"`
+}
+
+// Values for stateChange.Reason
+const (
+	reasonZettel = "zettel"
+	reasonDelete = "delete"
+)
+
+// stateDelivery is a pending delivery or a dead letter.
+type stateDelivery struct {
+	ID       string    `json:"id,omitempty"`
+	Target   string    `json:"target"`
+	URL      string    `json:"url"`
+	Event    string    `json:"event"`
+	Zid      string    `json:"zid"`
+	Time     time.Time `json:"time"`
+	Attempts int       `json:"attempts"`
+	Err      string    `json:"err,omitempty"`
+}
+
+// saver saves the state regularly, if it was changed.
+func (d *Dispatcher) saver() {
+	ticker := time.NewTicker(saveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.save()
+		}
+	}
+}
+
+// save writes the state to the file, if it was changed.
+func (d *Dispatcher) save() {
+	if d.file == "" {
+		return
+	}
+	d.mx.Lock()
+	if !d.dirty {
+		d.mx.Unlock()
+		return
+	}
+	st := d.makeState()
+	d.dirty = false
+	d.mx.Unlock()
+
+	if err := writeState(d.file, &st); err != nil {
+		d.logger.Error("Unable to save webhook state", "file", d.file, "err", err)
+		d.mx.Lock()
+		d.dirty = true
+		d.mx.Unlock()
+	}
+}
+
+// makeState must be called with a locked mutex.
+func (d *Dispatcher) makeState() state {
+	var st state
+	for _, ci := range d.queue {
+		switch ci.Reason {
+		case box.OnZettel:
+			st.Changes = append(st.Changes, stateChange{Zid: ci.Zid.String(), Reason: reasonZettel, Created: ci.Created})
+		case box.OnDelete:
+			st.Changes = append(st.Changes, stateChange{Zid: ci.Zid.String(), Reason: reasonDelete})
+		}
+	}
+	for _, dl := range d.restored {
+		st.Pending = append(st.Pending, dl.state())
+	}
+	for _, dl := range d.pending {
+		st.Pending = append(st.Pending, dl.state())
+	}
+	slices.SortFunc(st.Pending, func(a, b stateDelivery) int { return a.Time.Compare(b.Time) })
+	for _, dl := range d.dead {
+		st.Dead = append(st.Dead, stateDelivery{
+			Target:   dl.Target.String(),
+			URL:      dl.URL,
+			Event:    dl.Event,
+			Zid:      dl.Zid.String(),
+			Time:     dl.Time,
+			Attempts: dl.Attempts,
+			Err:      dl.Err,
+		})
+	}
+	return st
+}
+
+func (dl *delivery) state() stateDelivery {
+	return stateDelivery{
+		ID:       dl.id,
+		Target:   dl.target.String(),
+		URL:      dl.url,
+		Event:    dl.event,
+		Zid:      dl.zid.String(),
+		Time:     dl.time,
+		Attempts: dl.attempts,
+	}
+}
+
+// writeState replaces the file, so that it is never written partially.
+func writeState(file string, st *state) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmpFile := file + ".tmp"
+	if err = os.WriteFile(tmpFile, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
+
+// load reads the state from the file. A missing file is not an error.
+func (d *Dispatcher) load() error {
+	if d.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(d.file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var st state
+	if err = json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	var errs []string
+	for _, sc := range st.Changes {
+		zid, err2 := id.Parse(sc.Zid)
+		if err2 != nil {
+			errs = append(errs, err2.Error())
+			continue
+		}
+		switch sc.Reason {
+		case reasonZettel:
+			d.queue = append(d.queue, box.UpdateInfo{Reason: box.OnZettel, Zid: zid, Created: sc.Created})
+		case reasonDelete:
+			d.queue = append(d.queue, box.UpdateInfo{Reason: box.OnDelete, Zid: zid})
+		default:
+			errs = append(errs, fmt.Sprintf("unknown reason %q", sc.Reason))
+		}
+	}
+	for _, sd := range st.Pending {
+		target, err1 := id.Parse(sd.Target)
+		zid, err2 := id.Parse(sd.Zid)
+		if err1 != nil || err2 != nil || sd.ID == "" {
+			errs = append(errs, fmt.Sprintf("invalid pending delivery %v", sd))
+			continue
+		}
+		d.restored = append(d.restored, &delivery{
+			id:       sd.ID,
+			target:   target,
+			url:      sd.URL,
+			event:    sd.Event,
+			zid:      zid,
+			time:     sd.Time,
+			attempts: sd.Attempts,
+		})
+	}
+	for _, sd := range st.Dead {
+		target, _ := id.Parse(sd.Target)
+		zid, _ := id.Parse(sd.Zid)
+		d.dead = append(d.dead, DeadLetter{
+			Time:     sd.Time,
+			Target:   target,
+			URL:      sd.URL,
+			Event:    sd.Event,
+			Zid:      zid,
+			Attempts: sd.Attempts,
+			Err:      sd.Err,
+		})
+		d.lastDead = sd.Time
+	}
+	if len(d.dead) > maxDeadLetters {
+		d.dead = slices.Delete(d.dead, 0, len(d.dead)-maxDeadLetters)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
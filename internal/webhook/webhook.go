@@ -0,0 +1,400 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+// Package webhook notifies external services about changed zettel.
+//
+// Every zettel with a metadata key "webhook-url" defines a webhook target.
+// For every created, updated, or deleted zettel that matches the query of a
+// target, a signed HTTP POST request is sent to the URL of the target.
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/kernel"
+	"zettelstore.de/z/internal/logging"
+	"zettelstore.de/z/internal/query"
+)
+
+// Metadata keys of a zettel that defines a webhook target.
+const (
+	KeyURL    = "webhook-url"    // URL that receives the events
+	KeyQuery  = "webhook-query"  // Only zettel that match this query are reported
+	KeyEvents = "webhook-events" // Events to be reported, default: all
+	KeySecret = "webhook-secret" // Secret to sign a request
+	KeyWriter = "webhook-writer" // Zettel identifier of the user who stored the target last
+)
+
+// Names of events.
+const (
+	EventCreate = "create"
+	EventUpdate = "update"
+	EventDelete = "delete"
+)
+
+// Port is the interface used by the dispatcher.
+type Port interface {
+	box.Subject
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+	SelectMeta(ctx context.Context, metaSeq []*meta.Meta, q *query.Query) ([]*meta.Meta, error)
+}
+
+// Dispatcher observes all changes of zettel and delivers them to the
+// webhook targets.
+type Dispatcher struct {
+	logger *slog.Logger
+	port   Port
+	owner  id.Zid // Only targets stored by the owner are used, if valid
+	client *http.Client
+	file   string          // Stores the state, if not empty
+	signal chan struct{}   // Signals new changes in queue
+	ctx    context.Context // Done, when the dispatcher is stopped
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	sem    chan struct{} // Limits the number of concurrent deliveries
+
+	backoff     time.Duration // Wait time before the first retry
+	maxAttempts int
+
+	mx       sync.Mutex
+	queue    []box.UpdateInfo     // Changes not yet processed
+	pending  map[string]*delivery // Deliveries not yet finished, by their identifier
+	restored []*delivery          // Pending deliveries of the state file, not yet resumed
+	dead     []DeadLetter
+	lastDead time.Time
+	dirty    bool // State was changed since it was saved
+}
+
+// Default values of a dispatcher.
+const (
+	maxDeliveries      = 8
+	defaultBackoff     = 2 * time.Second
+	maxBackoff         = 5 * time.Minute
+	defaultMaxAttempts = 8
+	requestTimeout     = 30 * time.Second
+	maxDeadLetters     = 1024
+	saveInterval       = time.Second
+)
+
+// NewDispatcher creates a new dispatcher and starts it. If owner is valid, only
+// targets that were stored by the owner are used. If file is not empty,
+// pending deliveries, unprocessed changes, and dead letters are stored in this
+// file. They are restored when a dispatcher is created with the same file.
+func NewDispatcher(logger *slog.Logger, port Port, owner id.Zid, file string) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		logger:      logger,
+		port:        port,
+		owner:       owner,
+		client:      &http.Client{Timeout: requestTimeout},
+		file:        file,
+		signal:      make(chan struct{}, 1),
+		ctx:         ctx,
+		cancel:      cancel,
+		sem:         make(chan struct{}, maxDeliveries),
+		backoff:     defaultBackoff,
+		maxAttempts: defaultMaxAttempts,
+		pending:     map[string]*delivery{},
+	}
+	if err := d.load(); err != nil {
+		logger.Error("Unable to load webhook state", "file", file, "err", err)
+	}
+	port.RegisterObserver(d.observe)
+	if len(d.queue) > 0 || len(d.restored) > 0 {
+		d.signal <- struct{}{}
+	}
+	go d.run()
+	if file != "" {
+		d.wg.Go(d.saver)
+	}
+	return d
+}
+
+// Stop the dispatcher. Pending deliveries and unprocessed changes are saved
+// in the file of the dispatcher, so that they are delivered when the next
+// dispatcher starts.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+	d.save()
+}
+
+// observe must not block, because it is called by the notifier of the box.
+// Changes are never dropped, the queue grows as needed.
+func (d *Dispatcher) observe(ci box.UpdateInfo) {
+	select {
+	case <-d.ctx.Done():
+		return
+	default:
+	}
+	d.mx.Lock()
+	d.queue = append(d.queue, ci)
+	d.dirty = true
+	d.mx.Unlock()
+	select {
+	case d.signal <- struct{}{}:
+	default:
+	}
+}
+
+// target is a webhook target, as defined by a zettel.
+type target struct {
+	zid     id.Zid
+	url     string
+	q       *query.Query
+	events  []string
+	secret  []byte
+	matched map[id.Zid]struct{} // Zettel that matched the query
+}
+
+func (d *Dispatcher) run() {
+	// A panic must not stop the dispatcher.
+	defer func() {
+		if ri := recover(); ri != nil {
+			kernel.Main.LogRecover("Webhook", ri)
+			go d.run()
+		}
+	}()
+
+	var targets []*target
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.signal:
+		}
+		targets = d.resume(targets)
+		for {
+			select {
+			case <-d.ctx.Done():
+				return
+			default:
+			}
+			ci, found := d.nextChange()
+			if !found {
+				break
+			}
+			targets = d.process(ci, targets)
+		}
+	}
+}
+
+// nextChange removes the first change from the queue.
+func (d *Dispatcher) nextChange() (box.UpdateInfo, bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if len(d.queue) == 0 {
+		return box.UpdateInfo{}, false
+	}
+	ci := d.queue[0]
+	d.queue[0] = box.UpdateInfo{}
+	d.queue = d.queue[1:]
+	d.dirty = true
+	return ci, true
+}
+
+// resume starts the pending deliveries that were restored from the file.
+func (d *Dispatcher) resume(targets []*target) []*target {
+	d.mx.Lock()
+	restored := d.restored
+	d.restored = nil
+	d.mx.Unlock()
+	if len(restored) == 0 {
+		return targets
+	}
+	if targets == nil {
+		var err error
+		if targets, err = d.loadTargets(context.Background()); err != nil {
+			d.logger.Error("Unable to load webhook targets", "err", err)
+			d.mx.Lock()
+			d.restored = append(restored, d.restored...)
+			d.mx.Unlock()
+			return nil
+		}
+	}
+	for _, dl := range restored {
+		idx := slices.IndexFunc(targets, func(t *target) bool { return t.zid == dl.target })
+		if idx < 0 {
+			d.addDeadLetter(dl.deadLetter("webhook target not found"))
+			continue
+		}
+		d.deliver(targets[idx], dl)
+	}
+	return targets
+}
+
+// process delivers one change to all relevant targets.
+func (d *Dispatcher) process(ci box.UpdateInfo, targets []*target) []*target {
+	ctx := context.Background()
+	var m *meta.Meta
+	switch ci.Reason {
+	case box.OnReady, box.OnReload:
+		return nil
+	case box.OnZettel:
+		m, _ = d.port.GetMeta(ctx, ci.Zid)
+		if m == nil {
+			return targets
+		}
+	case box.OnDelete:
+	default:
+		return targets
+	}
+	if isTarget(m) || slices.ContainsFunc(targets, func(t *target) bool { return t.zid == ci.Zid }) {
+		// Changes of webhook targets are not reported.
+		return nil
+	}
+	if targets == nil {
+		var err error
+		if targets, err = d.loadTargets(ctx); err != nil {
+			d.logger.Error("Unable to load webhook targets", "err", err)
+			return nil
+		}
+	}
+	for _, t := range targets {
+		if event := d.matchEvent(ctx, t, ci, m); event != "" {
+			d.deliver(t, d.newDelivery(t, event, ci.Zid))
+		}
+	}
+	return targets
+}
+
+func isTarget(m *meta.Meta) bool {
+	if m == nil {
+		return false
+	}
+	_, found := m.Get(KeyURL)
+	return found
+}
+
+func (d *Dispatcher) loadTargets(ctx context.Context) ([]*target, error) {
+	metaSeq, err := d.port.SelectMeta(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := []*target{}
+	for _, m := range metaSeq {
+		if !isTarget(m) {
+			continue
+		}
+		if !d.isTrustedTarget(m) {
+			d.logger.Info("Webhook target ignored, not stored by owner", "zid", m.Zid)
+			continue
+		}
+		t, err2 := d.makeTarget(ctx, m)
+		if err2 != nil {
+			d.logger.Error("Invalid webhook target", "zid", m.Zid, "err", err2)
+			continue
+		}
+		result = append(result, t)
+	}
+	logging.LogTrace(d.logger, "Webhook targets loaded", "count", len(result))
+	return result, nil
+}
+
+// isTrustedTarget returns true, if the target was stored by the owner. A user
+// zettel is never a target, because its user may change it.
+func (d *Dispatcher) isTrustedTarget(m *meta.Meta) bool {
+	if _, isUser := m.Get(meta.KeyUserID); isUser {
+		return false
+	}
+	if !d.owner.IsValid() {
+		return true
+	}
+	writer, _ := m.Get(KeyWriter)
+	return string(writer) == d.owner.String()
+}
+
+func (d *Dispatcher) makeTarget(ctx context.Context, m *meta.Meta) (*target, error) {
+	val, _ := m.Get(KeyURL)
+	u, err := url.Parse(strings.TrimSpace(string(val)))
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &url.Error{Op: "webhook", URL: u.String(), Err: errInvalidScheme}
+	}
+	t := &target{zid: m.Zid, url: u.String()}
+	if val, found := m.Get(KeySecret); found {
+		t.secret = []byte(val)
+	}
+	if val, found := m.Get(KeyEvents); found {
+		t.events = strings.Fields(strings.ToLower(string(val)))
+	}
+	if val, found := m.Get(KeyQuery); found && strings.TrimSpace(string(val)) != "" {
+		t.q = query.Parse(string(val))
+		metaSeq, err2 := d.port.SelectMeta(ctx, nil, t.q)
+		if err2 != nil {
+			return nil, err2
+		}
+		t.matched = make(map[id.Zid]struct{}, len(metaSeq))
+		for _, sm := range metaSeq {
+			t.matched[sm.Zid] = struct{}{}
+		}
+	}
+	return t, nil
+}
+
+// matchEvent returns the name of the event for the given target, or the empty
+// string if the change is not relevant for the target. m is nil for a
+// deleted zettel.
+func (d *Dispatcher) matchEvent(ctx context.Context, t *target, ci box.UpdateInfo, m *meta.Meta) string {
+	event := EventDelete
+	if m != nil {
+		event = EventUpdate
+		if ci.Created {
+			event = EventCreate
+		}
+	}
+	if !d.matchQuery(ctx, t, ci.Zid, m) {
+		return ""
+	}
+	if len(t.events) > 0 && !slices.Contains(t.events, event) {
+		return ""
+	}
+	return event
+}
+
+// matchQuery checks whether the zettel matches the query of the target. A
+// zettel that matched before is reported a last time.
+func (d *Dispatcher) matchQuery(ctx context.Context, t *target, zid id.Zid, m *meta.Meta) bool {
+	if t.q == nil {
+		return true
+	}
+	_, wasMatched := t.matched[zid]
+	if m == nil {
+		delete(t.matched, zid)
+		return wasMatched
+	}
+	metaSeq, err := d.port.SelectMeta(ctx, []*meta.Meta{m}, t.q)
+	if err != nil {
+		d.logger.Error("Unable to match webhook query", "target", t.zid, "zid", zid, "err", err)
+		return false
+	}
+	if len(metaSeq) > 0 {
+		t.matched[zid] = struct{}{}
+		return true
+	}
+	delete(t.matched, zid)
+	return wasMatched
+}
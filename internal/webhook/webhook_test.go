@@ -0,0 +1,328 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/box"
+	"zettelstore.de/z/internal/query"
+)
+
+type testPort struct {
+	mx       sync.Mutex
+	metas    map[id.Zid]*meta.Meta
+	observer box.UpdateFunc
+}
+
+func (tp *testPort) RegisterObserver(f box.UpdateFunc) { tp.observer = f }
+func (tp *testPort) GetMeta(_ context.Context, zid id.Zid) (*meta.Meta, error) {
+	tp.mx.Lock()
+	defer tp.mx.Unlock()
+	if m, found := tp.metas[zid]; found {
+		return m.Clone(), nil
+	}
+	return nil, box.ErrZettelNotFound{Zid: zid}
+}
+func (tp *testPort) SelectMeta(_ context.Context, metaSeq []*meta.Meta, _ *query.Query) ([]*meta.Meta, error) {
+	if metaSeq != nil {
+		return metaSeq, nil
+	}
+	tp.mx.Lock()
+	defer tp.mx.Unlock()
+	result := make([]*meta.Meta, 0, len(tp.metas))
+	for _, m := range tp.metas {
+		result = append(result, m.Clone())
+	}
+	return result, nil
+}
+
+type received struct {
+	event, signature string
+	pl               payload
+}
+
+func TestDispatcher(t *testing.T) {
+	var mx sync.Mutex
+	var got []received
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mx.Lock()
+		defer mx.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var pl payload
+		if err := json.Unmarshal(body, &pl); err != nil {
+			t.Error(err)
+		}
+		if sig := r.Header.Get(HeaderSignature); sig != Sign([]byte("secret"), body) {
+			t.Errorf("wrong signature %q", sig)
+		}
+		got = append(got, received{r.Header.Get(HeaderEvent), r.Header.Get(HeaderSignature), pl})
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	hook := meta.New(1)
+	hook.Set(KeyURL, meta.Value(srv.URL))
+	hook.Set(KeySecret, "secret")
+	hook.Set(KeyEvents, "create delete")
+	zettel := meta.New(2)
+	port := &testPort{metas: map[id.Zid]*meta.Meta{1: hook, 2: zettel}}
+
+	numGot := func() int {
+		mx.Lock()
+		defer mx.Unlock()
+		return len(got)
+	}
+
+	d := NewDispatcher(slog.New(slog.DiscardHandler), port, id.Invalid, "")
+	d.backoff = time.Millisecond
+	port.observer(box.UpdateInfo{Reason: box.OnZettel, Zid: 2, Created: true})
+	waitFor(func() bool { return numGot() >= 1 })
+	port.mx.Lock()
+	zettel.Set(meta.KeyModified, "20261018150000")
+	port.mx.Unlock()
+	port.observer(box.UpdateInfo{Reason: box.OnZettel, Zid: 2}) // update is not requested
+	port.mx.Lock()
+	delete(port.metas, 2)
+	port.mx.Unlock()
+	port.observer(box.UpdateInfo{Reason: box.OnDelete, Zid: 2})
+	waitFor(func() bool { return numGot() >= 2 })
+	d.Stop()
+
+	if len(got) != 2 {
+		t.Fatalf("expected two deliveries, but got %v", got)
+	}
+	events := map[string]bool{}
+	for _, r := range got {
+		if r.event != r.pl.Event || r.pl.Zid != "00000000000002" || r.pl.Target != "00000000000001" {
+			t.Errorf("wrong delivery: %v", r)
+		}
+		events[r.event] = true
+	}
+	if !events[EventCreate] || !events[EventDelete] {
+		t.Errorf("expected create and delete, but got %v", events)
+	}
+	if dead, _ := d.DeadLetters(); len(dead) != 0 {
+		t.Errorf("expected no dead letters, but got %v", dead)
+	}
+}
+
+func TestLoadTargets(t *testing.T) {
+	const owner = id.Zid(9)
+	makeHook := func(zid id.Zid, keyVals ...string) *meta.Meta {
+		m := meta.New(zid)
+		m.Set(KeyURL, "https://example.com/hook")
+		for i := 0; i < len(keyVals); i += 2 {
+			m.Set(keyVals[i], meta.Value(keyVals[i+1]))
+		}
+		return m
+	}
+	port := &testPort{metas: map[id.Zid]*meta.Meta{
+		2: makeHook(2, KeyWriter, owner.String()),
+		3: makeHook(3, KeyWriter, id.Zid(5).String()),
+		4: makeHook(4),
+		5: makeHook(5, meta.KeyUserID, "writer", KeyWriter, owner.String()),
+	}}
+	testcases := []struct {
+		name  string
+		owner id.Zid
+		exp   []id.Zid
+	}{
+		{"owner", owner, []id.Zid{2}},
+		{"no-auth", id.Invalid, []id.Zid{2, 3, 4}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Dispatcher{logger: slog.New(slog.DiscardHandler), port: port, owner: tc.owner}
+			targets, err := d.loadTargets(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]id.Zid, 0, len(targets))
+			for _, target := range targets {
+				got = append(got, target.zid)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tc.exp) {
+				t.Errorf("expected targets %v, but got %v", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	hook := meta.New(1)
+	hook.Set(KeyURL, meta.Value(srv.URL))
+	port := &testPort{metas: map[id.Zid]*meta.Meta{1: hook, 2: meta.New(2)}}
+	d := NewDispatcher(slog.New(slog.DiscardHandler), port, id.Invalid, "")
+	port.observer(box.UpdateInfo{Reason: box.OnZettel, Zid: 2, Created: true})
+	waitFor(func() bool {
+		dead, _ := d.DeadLetters()
+		return len(dead) > 0
+	})
+	d.Stop()
+	dead, last := d.DeadLetters()
+	if len(dead) != 1 || last.IsZero() {
+		t.Fatalf("expected one dead letter, but got %v", dead)
+	}
+	if dl := dead[0]; dl.Target != 1 || dl.Zid != 2 || dl.Event != EventCreate || dl.Attempts != 1 {
+		t.Errorf("wrong dead letter: %v", dl)
+	}
+}
+
+func TestPersistentState(t *testing.T) {
+	var mx sync.Mutex
+	var got []payload
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		defer mx.Unlock()
+		if status == http.StatusNoContent {
+			var pl payload
+			if err := json.NewDecoder(r.Body).Decode(&pl); err != nil {
+				t.Error(err)
+			}
+			got = append(got, pl)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	numGot := func() int {
+		mx.Lock()
+		defer mx.Unlock()
+		return len(got)
+	}
+
+	hook := meta.New(1)
+	hook.Set(KeyURL, meta.Value(srv.URL))
+	hook.Set(KeyEvents, "update")
+	port := &testPort{metas: map[id.Zid]*meta.Meta{1: hook, 2: meta.New(2)}}
+	file := filepath.Join(t.TempDir(), "webhook.json")
+
+	// First delivery fails, and waits for a retry, when the dispatcher stops.
+	d := NewDispatcher(slog.New(slog.DiscardHandler), port, id.Invalid, file)
+	d.backoff = time.Hour
+	port.observer(box.UpdateInfo{Reason: box.OnZettel, Zid: 2})
+	waitFor(func() bool {
+		d.mx.Lock()
+		defer d.mx.Unlock()
+		for _, dl := range d.pending {
+			return dl.attempts > 0
+		}
+		return false
+	})
+	d.addDeadLetter(DeadLetter{Target: 1, URL: srv.URL, Event: EventDelete, Zid: 3, Attempts: 8, Err: "failed"})
+	d.Stop()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st state
+	if err = json.Unmarshal(data, &st); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Pending) != 1 || len(st.Dead) != 1 {
+		t.Fatalf("expected one pending delivery and one dead letter, but got %s", data)
+	}
+	deliveryID := st.Pending[0].ID
+
+	// Next dispatcher resumes the delivery, with the same delivery identifier.
+	mx.Lock()
+	status = http.StatusNoContent
+	mx.Unlock()
+	d = NewDispatcher(slog.New(slog.DiscardHandler), port, id.Invalid, file)
+	waitFor(func() bool { return numGot() >= 1 })
+	d.Stop()
+	if len(got) != 1 || got[0].Delivery != deliveryID || got[0].Event != EventUpdate || got[0].Zid != "00000000000002" {
+		t.Errorf("expected delivery %q, but got %v", deliveryID, got)
+	}
+	if dead, last := d.DeadLetters(); len(dead) != 1 || dead[0].Zid != 3 || last.IsZero() {
+		t.Errorf("expected restored dead letter, but got %v", dead)
+	}
+}
+
+func TestRestoredChanges(t *testing.T) {
+	var mx sync.Mutex
+	var got []payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pl payload
+		if err := json.NewDecoder(r.Body).Decode(&pl); err != nil {
+			t.Error(err)
+		}
+		mx.Lock()
+		got = append(got, pl)
+		mx.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	hook := meta.New(1)
+	hook.Set(KeyURL, meta.Value(srv.URL))
+	port := &testPort{metas: map[id.Zid]*meta.Meta{1: hook, 2: meta.New(2)}}
+	file := filepath.Join(t.TempDir(), "webhook.json")
+	err := writeState(file, &state{
+		Changes: []stateChange{{Zid: "00000000000002", Reason: reasonZettel, Created: true}},
+		Pending: []stateDelivery{{ID: "unknown", Target: "00000000000009", URL: "http://unknown", Event: EventDelete, Zid: "00000000000003"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(slog.New(slog.DiscardHandler), port, id.Invalid, file)
+	waitFor(func() bool {
+		mx.Lock()
+		defer mx.Unlock()
+		return len(got) >= 1
+	})
+	d.Stop()
+	if len(got) != 1 || got[0].Event != EventCreate || got[0].Zid != "00000000000002" {
+		t.Errorf("expected create of zettel 2, but got %v", got)
+	}
+	dead, _ := d.DeadLetters()
+	if len(dead) != 1 || dead[0].Target != 9 || dead[0].Err != "webhook target not found" {
+		t.Errorf("expected dead letter for unknown target, but got %v", dead)
+	}
+}
+
+func waitFor(cond func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); !cond() && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -15,6 +15,11 @@
 package zettel
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+
 	"t73f.de/r/sx"
 	"t73f.de/r/zsc/domain/id"
 	"t73f.de/r/zsc/domain/meta"
@@ -35,6 +40,24 @@ func (z Zettel) Equal(o Zettel, allowComputed bool) bool {
 	return z.Meta.Equal(o.Meta, allowComputed) && z.Content.Equal(&o.Content)
 }
 
+// Version returns a token that identifies the current state of the zettel.
+// It changes whenever the stored metadata or the content changes. Computed
+// metadata is ignored.
+func (z Zettel) Version() string {
+	h := sha256.New()
+	m := z.Meta.Map()
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if !meta.IsComputed(key) {
+			_, _ = h.Write([]byte(key))
+			_, _ = h.Write([]byte{0})
+			_, _ = h.Write([]byte(m[key]))
+			_, _ = h.Write([]byte{0})
+		}
+	}
+	_, _ = h.Write(z.Content.AsBytes())
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
 // ParsedZettel is the root node of the abstract syntax tree.
 type ParsedZettel struct {
 	Meta    *meta.Meta // Original metadata
@@ -0,0 +1,50 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2026-present Detlef Stern
+//
+// This file is part of Zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//
+// SPDX-License-Identifier: EUPL-1.2
+// SPDX-FileCopyrightText: 2026-present Detlef Stern
+//-----------------------------------------------------------------------------
+
+package zettel_test
+
+import (
+	"testing"
+
+	"t73f.de/r/zsc/domain/id"
+	"t73f.de/r/zsc/domain/meta"
+
+	"zettelstore.de/z/internal/zettel"
+)
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+	makeZettel := func(title, content string) zettel.Zettel {
+		m := meta.New(id.ZidDefaultHome)
+		m.Set(meta.KeyTitle, meta.Value(title))
+		return zettel.Zettel{Meta: m, Content: zettel.NewContent([]byte(content))}
+	}
+	z := makeZettel("Title", "Content")
+	version := z.Version()
+	if version == "" {
+		t.Fatal("version must not be empty")
+	}
+	if got := makeZettel("Title", "Content").Version(); got != version {
+		t.Errorf("same zettel must have same version, but got %q and %q", version, got)
+	}
+	if got := makeZettel("Other", "Content").Version(); got == version {
+		t.Error("changed metadata must result in another version")
+	}
+	if got := makeZettel("Title", "Other").Version(); got == version {
+		t.Error("changed content must result in another version")
+	}
+	z.Meta.Set(meta.KeyBoxName, "box")
+	if got := z.Version(); got != version {
+		t.Errorf("computed metadata must not change version, but got %q and %q", version, got)
+	}
+}
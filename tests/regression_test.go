@@ -170,6 +170,7 @@ func (*myConfig) MarkdownDialect() string               { return meta.ValueSynta
 func (*myConfig) IsSimpleMode() bool                       { return false }
 func (*myConfig) IsExpertMode() bool                       { return false }
 func (*myConfig) GetVisibility(*meta.Meta) meta.Visibility { return meta.VisibilityPublic }
+func (*myConfig) IsTOTPRequired(meta.UserRole) bool        { return false }
 func (*myConfig) MaxTransclusions() int                    { return 1024 }
 
 var testConfig = &myConfig{}